@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+// Operations the simulator times.
+const (
+	OpBook   = "book"
+	OpPay    = "pay"
+	OpCancel = "cancel"
+)
+
+// Call is the outcome of one timed API call.
+type Call struct {
+	Op       string
+	Status   int
+	Code     string
+	Latency  time.Duration
+	TimedOut bool
+	Err      error
+}
+
+// OK reports whether the call got a 2xx response.
+func (c Call) OK() bool {
+	return c.Err == nil && c.Status >= 200 && c.Status < 300
+}
+
+type apiClient struct {
+	http       *http.Client
+	bookingURL string
+	paymentURL string
+}
+
+func newAPIClient(bookingURL, paymentURL string, timeout time.Duration, maxConns int) *apiClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxConns
+	transport.MaxIdleConnsPerHost = maxConns
+	return &apiClient{
+		http:       &http.Client{Transport: transport, Timeout: timeout},
+		bookingURL: bookingURL,
+		paymentURL: paymentURL,
+	}
+}
+
+// session carries what identifies one buyer to the services.
+type session struct {
+	token    string
+	clientIP string
+}
+
+// do sends body as JSON and decodes a 2xx response into out. Error responses
+// are read as problem details so their code ends up in the report.
+func (a *apiClient) do(ctx context.Context, op string, s session, method, url string, body, out any) Call {
+	call := Call{Op: op}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			call.Err = err
+			return call
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		call.Err = err
+		return call
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.AddCookie(&http.Cookie{Name: "token", Value: s.token})
+	}
+	if s.clientIP != "" {
+		req.Header.Set("X-Forwarded-For", s.clientIP)
+	}
+
+	start := time.Now()
+	resp, err := a.http.Do(req)
+	if err != nil {
+		call.Latency = time.Since(start)
+		call.Err = err
+		var netErr interface{ Timeout() bool }
+		call.TimedOut = errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+		return call
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	call.Latency = time.Since(start)
+	call.Status = resp.StatusCode
+	if err != nil {
+		call.Err = err
+		return call
+	}
+
+	if !call.OK() {
+		var problem models.ProblemDetails
+		if json.Unmarshal(respBody, &problem) == nil {
+			call.Code = problem.Code
+		}
+		return call
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			call.Err = fmt.Errorf("decoding %s response: %w", op, err)
+		}
+	}
+	return call
+}
+
+func (a *apiClient) createBooking(ctx context.Context, s session, req models.CreateBookingRequest) (models.BookingResponse, Call) {
+	var booking models.BookingResponse
+	call := a.do(ctx, OpBook, s, http.MethodPost, a.bookingURL+"/bookings/", req, &booking)
+	return booking, call
+}
+
+func (a *apiClient) pay(ctx context.Context, s session, booking models.BookingResponse) Call {
+	req := struct {
+		BookingID     string  `json:"booking_id"`
+		Amount        float64 `json:"amount"`
+		PaymentMethod string  `json:"payment_method"`
+		CardNumber    string  `json:"card_number"`
+		ExpiryDate    string  `json:"expiry_date"`
+		CVV           string  `json:"cvv"`
+	}{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    "12/30",
+		CVV:           "123",
+	}
+	return a.do(ctx, OpPay, s, http.MethodPost, a.paymentURL+"/payments/", req, nil)
+}
+
+func (a *apiClient) cancel(ctx context.Context, s session, bookingID string) Call {
+	return a.do(ctx, OpCancel, s, http.MethodPut, a.bookingURL+"/bookings/"+bookingID+"/cancel", nil, nil)
+}
+
+func (a *apiClient) getConcert(ctx context.Context, id uint) (models.ConcertResponse, error) {
+	var concert models.ConcertResponse
+	call := a.do(ctx, "get concert", session{}, http.MethodGet, fmt.Sprintf("%s/concerts/%d", a.bookingURL, id), nil, &concert)
+	return concert, call.asError()
+}
+
+func (a *apiClient) getSeats(ctx context.Context, concertID uint) ([]models.SeatResponse, error) {
+	var seats []models.SeatResponse
+	call := a.do(ctx, "get seats", session{}, http.MethodGet, fmt.Sprintf("%s/concerts/%d/seats", a.bookingURL, concertID), nil, &seats)
+	return seats, call.asError()
+}
+
+func (a *apiClient) createConcert(ctx context.Context, adminToken string, req models.CreateConcertRequest) (models.ConcertResponse, error) {
+	var concert models.ConcertResponse
+	call := a.do(ctx, "create concert", session{token: adminToken}, http.MethodPost, a.bookingURL+"/admin/concerts/", req, &concert)
+	return concert, call.asError()
+}
+
+func (c Call) asError() error {
+	switch {
+	case c.Err != nil:
+		return fmt.Errorf("%s: %w", c.Op, c.Err)
+	case !c.OK():
+		return fmt.Errorf("%s: status %d %s", c.Op, c.Status, c.Code)
+	}
+	return nil
+}
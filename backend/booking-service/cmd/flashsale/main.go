@@ -0,0 +1,264 @@
+// Command flashsale simulates a flash sale: thousands of buyers arriving at a
+// concert at once, booking through the booking service and paying through the
+// payment service. It reports throughput, latency percentiles and an error
+// breakdown, and checks that no ticket class ended up with more seats handed
+// out than it has, which is what DecreaseAvailableSeatsAtomically guards.
+//
+// Buyers are authenticated with JWTs signed with JWT_SECRET (read like the
+// booking service reads it), so no accounts need to be registered. Point it at
+// an existing concert with -concert, or let it create one with -seats:
+//
+//	go run ./cmd/flashsale -seats VIP:50,Regular:450 -buyers 3000 -arrival ramp -window 20s
+//
+// The booking service rate limits all requests per client IP and booking
+// creation per user and per client IP. -spread-ips gives every buyer its own
+// X-Forwarded-For address so a single machine looks like many clients, which
+// only works if the services list this machine in TRUSTED_PROXIES; without
+// that, or with -spread-ips=false, it measures what one address gets through.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"backend/booking-service/config"
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+)
+
+func main() {
+	cfg := config.LoadConfig()
+
+	bookingURL := flag.String("booking-url", "http://localhost:"+cfg.ServicePort+"/api/v1", "booking service API base URL")
+	paymentURL := flag.String("payment-url", cfg.PaymentServiceAPIURL, "payment service API base URL")
+	concertID := flag.Uint("concert", 0, "ID of the concert to sell; 0 creates a new concert laid out by -seats")
+	seatsSpec := flag.String("seats", "VIP:50,Regular:450", "ticket classes and seat counts of a created concert")
+	buyerCount := flag.Int("buyers", 2000, "number of simulated buyers")
+	arrival := flag.String("arrival", ArrivalBurst, "arrival curve: burst, uniform, ramp or poisson")
+	window := flag.Duration("window", 10*time.Second, "time over which buyers arrive (ignored by burst)")
+	basketSpec := flag.String("basket", "1:60,2:25,4:15", "ticket quantities per booking as quantity:weight pairs")
+	classSpec := flag.String("classes", "", "ticket class mix as name:weight pairs; empty weighs all classes equally")
+	cancelRate := flag.Float64("cancel-rate", 0.1, "fraction of successful bookings cancelled instead of paid")
+	abandonRate := flag.Float64("abandon-rate", 0.05, "fraction of successful bookings left to expire")
+	maxInFlight := flag.Int("max-inflight", 1000, "maximum concurrent buyers; 0 means unlimited")
+	timeout := flag.Duration("timeout", 30*time.Second, "per-request timeout")
+	userIDBase := flag.Uint("user-id-base", 1_000_000, "user ID of the first simulated buyer")
+	spreadIPs := flag.Bool("spread-ips", true, "send a distinct X-Forwarded-For address per buyer")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed for arrivals, baskets and outcomes")
+	flag.Parse()
+
+	utils.InitJWT(cfg)
+	rng := rand.New(rand.NewSource(*seed))
+	ctx := context.Background()
+	conns := *maxInFlight
+	if conns <= 0 {
+		conns = *buyerCount
+	}
+	api := newAPIClient(*bookingURL, *paymentURL, *timeout, conns)
+
+	concert, err := prepareConcert(ctx, api, *concertID, *seatsSpec)
+	if err != nil {
+		log.Fatalf("Failed to prepare concert: %v", err)
+	}
+	classes, err := classMix(concert, *classSpec)
+	if err != nil {
+		log.Fatalf("Invalid -classes: %v", err)
+	}
+	basket, err := parseBasket(*basketSpec)
+	if err != nil {
+		log.Fatalf("Invalid -basket: %v", err)
+	}
+	buyers, err := planBuyers(planConfig{
+		buyers:      *buyerCount,
+		arrival:     *arrival,
+		window:      *window,
+		basket:      basket,
+		classes:     classes,
+		cancelRate:  *cancelRate,
+		abandonRate: *abandonRate,
+		userIDBase:  *userIDBase,
+		runID:       time.Now().Unix(),
+	}, rng)
+	if err != nil {
+		log.Fatalf("Invalid run configuration: %v", err)
+	}
+
+	log.Printf("Selling concert %d (%s) to %d buyers, %s arrival, seed %d", concert.ID, concert.Name, len(buyers), *arrival, *seed)
+
+	recorder := NewRecorder()
+	elapsed := run(ctx, api, concert.ID, buyers, *maxInFlight, *spreadIPs, recorder)
+
+	after, err := api.getConcert(ctx, concert.ID)
+	if err != nil {
+		log.Fatalf("Failed to read final seat counts: %v", err)
+	}
+	seats, err := api.getSeats(ctx, concert.ID)
+	if err != nil {
+		log.Fatalf("Failed to read final seats: %v", err)
+	}
+
+	if recorder.WriteReport(os.Stdout, len(buyers), elapsed, checkClasses(concert, after, seats, recorder.held)) {
+		os.Exit(1)
+	}
+}
+
+// run starts every buyer at their arrival time and waits for all of them.
+func run(ctx context.Context, api *apiClient, concertID uint, buyers []Buyer, maxInFlight int, spreadIPs bool, recorder *Recorder) time.Duration {
+	var slots chan struct{}
+	if maxInFlight > 0 {
+		slots = make(chan struct{}, maxInFlight)
+	}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, buyer := range buyers {
+		if wait := buyer.Arrival - time.Since(start); wait > 0 {
+			time.Sleep(wait)
+		}
+		if slots != nil {
+			slots <- struct{}{}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+			shop(ctx, api, concertID, buyer, spreadIPs, recorder)
+		}()
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+// shop runs one buyer's journey: book, then pay, cancel or walk away.
+func shop(ctx context.Context, api *apiClient, concertID uint, buyer Buyer, spreadIPs bool, recorder *Recorder) {
+	username := fmt.Sprintf("flashsale-%d", buyer.UserID)
+	token, err := utils.GenerateJWT(buyer.UserID, username, "user")
+	if err != nil {
+		log.Fatalf("Failed to sign token for buyer %d: %v", buyer.Index, err)
+	}
+	s := session{token: token}
+	if spreadIPs {
+		s.clientIP = fmt.Sprintf("10.%d.%d.%d", buyer.Index>>16&0xff, buyer.Index>>8&0xff, buyer.Index&0xff)
+	}
+
+	booking, call := api.createBooking(ctx, s, models.CreateBookingRequest{
+		ConcertID:      concertID,
+		TicketsByClass: []models.TicketQuantityByClass{{TicketClassID: buyer.TicketClassID, Quantity: buyer.Quantity}},
+		BuyerInfo: models.BuyerRequest{
+			FullName:    fmt.Sprintf("Flash Sale Buyer %d", buyer.Index),
+			PhoneNumber: fmt.Sprintf("0812%08d", buyer.Index%100000000),
+			Email:       username + "@example.com",
+			KTPNumber:   buyer.KTPNumber,
+		},
+	})
+	recorder.Record(call)
+	if !call.OK() {
+		return
+	}
+	recorder.Hold(buyer.TicketClassID, buyer.Quantity)
+
+	switch buyer.Outcome {
+	case OutcomePay:
+		recorder.Record(api.pay(ctx, s, booking))
+	case OutcomeCancel:
+		call := api.cancel(ctx, s, booking.ID)
+		recorder.Record(call)
+		if call.OK() {
+			recorder.Hold(buyer.TicketClassID, -buyer.Quantity)
+		}
+	}
+}
+
+// prepareConcert returns the concert to sell, creating it first if id is 0.
+func prepareConcert(ctx context.Context, api *apiClient, id uint, seatsSpec string) (models.ConcertResponse, error) {
+	if id != 0 {
+		return api.getConcert(ctx, id)
+	}
+
+	names, counts, err := parseSeats(seatsSpec)
+	if err != nil {
+		return models.ConcertResponse{}, fmt.Errorf("invalid -seats: %w", err)
+	}
+	req := models.CreateConcertRequest{
+		Name:     "Flash Sale " + time.Now().Format("2006-01-02 15:04:05"),
+		Artist:   "Load Test",
+		Date:     time.Now().Add(30 * 24 * time.Hour),
+		Venue:    "Simulator Arena",
+		ImageUrl: "https://example.com/flashsale.png",
+	}
+	for i, name := range names {
+		req.TicketClasses = append(req.TicketClasses, models.CreateTicketClassRequest{
+			Name:              name,
+			Price:             float64(100000 * (len(names) - i)),
+			TotalSeatsInClass: counts[i],
+		})
+	}
+
+	adminToken, err := utils.GenerateJWT(0, "flashsale-admin", "admin")
+	if err != nil {
+		return models.ConcertResponse{}, err
+	}
+	created, err := api.createConcert(ctx, adminToken, req)
+	if err != nil {
+		return models.ConcertResponse{}, err
+	}
+
+	// Seats are created by a background worker; wait until it is done.
+	deadline := time.Now().Add(time.Minute)
+	for {
+		concert, err := api.getConcert(ctx, created.ID)
+		if err != nil {
+			return models.ConcertResponse{}, err
+		}
+		switch concert.Status {
+		case models.ConcertStatusActive:
+			return concert, nil
+		case models.ConcertStatusFailed:
+			return models.ConcertResponse{}, fmt.Errorf("seat creation failed for concert %d", concert.ID)
+		}
+		if time.Now().After(deadline) {
+			return models.ConcertResponse{}, fmt.Errorf("concert %d is still %s after a minute", concert.ID, concert.Status)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// classMix turns -classes into ticket class IDs of the concert.
+func classMix(concert models.ConcertResponse, spec string) (*weighted[uint], error) {
+	mix := &weighted[uint]{}
+	if spec == "" {
+		for _, tc := range concert.TicketClasses {
+			mix.add(tc.ID, 1)
+		}
+		if len(mix.keys) == 0 {
+			return nil, fmt.Errorf("concert %d has no ticket classes", concert.ID)
+		}
+		return mix, nil
+	}
+
+	names, weights, err := parseMix(spec)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		found := false
+		for _, tc := range concert.TicketClasses {
+			if tc.Name == name {
+				mix.add(tc.ID, weights[i])
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("concert %d has no ticket class %q", concert.ID, name)
+		}
+	}
+	return mix, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+)
+
+// Arrival curves accepted by -arrival.
+const (
+	ArrivalBurst   = "burst"   // everyone arrives when the sale opens
+	ArrivalUniform = "uniform" // arrivals spread evenly over -window
+	ArrivalRamp    = "ramp"    // the arrival rate grows linearly over -window
+	ArrivalPoisson = "poisson" // exponential gaps averaging -window / -buyers
+)
+
+// arrivalOffsets returns, for each of n buyers, how long after the start of the
+// run they arrive. The offsets are sorted.
+func arrivalOffsets(curve string, n int, window time.Duration, rng *rand.Rand) ([]time.Duration, error) {
+	offsets := make([]time.Duration, n)
+	if n == 0 {
+		return offsets, nil
+	}
+	switch curve {
+	case ArrivalBurst:
+	case ArrivalUniform:
+		for i := range offsets {
+			offsets[i] = time.Duration(float64(window) * float64(i) / float64(n))
+		}
+	case ArrivalRamp:
+		// With a rate proportional to t, the number of arrivals by t grows
+		// with t², so buyer i arrives at window * sqrt(i/n).
+		for i := range offsets {
+			offsets[i] = time.Duration(float64(window) * math.Sqrt(float64(i)/float64(n)))
+		}
+	case ArrivalPoisson:
+		mean := float64(window) / float64(n)
+		var t float64
+		for i := range offsets {
+			offsets[i] = time.Duration(t)
+			t += rng.ExpFloat64() * mean
+		}
+	default:
+		return nil, fmt.Errorf("unknown arrival curve %q (want %s, %s, %s or %s)", curve, ArrivalBurst, ArrivalUniform, ArrivalRamp, ArrivalPoisson)
+	}
+	return offsets, nil
+}
+
+// weighted picks keys at random in proportion to their weights.
+type weighted[K any] struct {
+	keys       []K
+	cumulative []float64
+}
+
+func (w *weighted[K]) add(key K, weight float64) {
+	total := weight
+	if n := len(w.cumulative); n > 0 {
+		total += w.cumulative[n-1]
+	}
+	w.keys = append(w.keys, key)
+	w.cumulative = append(w.cumulative, total)
+}
+
+func (w *weighted[K]) pick(rng *rand.Rand) K {
+	r := rng.Float64() * w.cumulative[len(w.cumulative)-1]
+	i := sort.SearchFloat64s(w.cumulative, r)
+	if i == len(w.keys) {
+		i--
+	}
+	return w.keys[i]
+}
+
+// parseMix parses a comma-separated list of key:weight pairs, e.g.
+// "1:60,2:25,4:15". A missing weight counts as 1.
+func parseMix(spec string) ([]string, []float64, error) {
+	var keys []string
+	var weights []float64
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, weightText, hasWeight := strings.Cut(part, ":")
+		weight := 1.0
+		if hasWeight {
+			var err error
+			weight, err = strconv.ParseFloat(weightText, 64)
+			if err != nil || weight < 0 {
+				return nil, nil, fmt.Errorf("invalid weight in %q", part)
+			}
+		}
+		keys = append(keys, strings.TrimSpace(key))
+		weights = append(weights, weight)
+	}
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return nil, nil, fmt.Errorf("mix %q has no positive weights", spec)
+	}
+	return keys, weights, nil
+}
+
+// parseBasket parses a basket mix of ticket quantities, e.g. "1:60,2:25,4:15".
+func parseBasket(spec string) (*weighted[int], error) {
+	keys, weights, err := parseMix(spec)
+	if err != nil {
+		return nil, err
+	}
+	basket := &weighted[int]{}
+	for i, key := range keys {
+		quantity, err := strconv.Atoi(key)
+		if err != nil || quantity < 1 || quantity > 5 {
+			return nil, fmt.Errorf("invalid basket quantity %q (must be 1 to 5)", key)
+		}
+		basket.add(quantity, weights[i])
+	}
+	return basket, nil
+}
+
+// parseSeats parses a ticket class layout for a new concert, e.g.
+// "VIP:100,Regular:900" where the numbers are seat counts.
+func parseSeats(spec string) ([]string, []int, error) {
+	keys, counts, err := parseMix(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	seats := make([]int, len(counts))
+	for i, count := range counts {
+		if count < 1 || count != math.Trunc(count) {
+			return nil, nil, fmt.Errorf("invalid seat count for class %q", keys[i])
+		}
+		seats[i] = int(count)
+	}
+	return keys, seats, nil
+}
+
+// Outcomes a buyer chooses after getting a booking.
+const (
+	OutcomePay     = "pay"
+	OutcomeCancel  = "cancel"
+	OutcomeAbandon = "abandon"
+)
+
+// Buyer is one simulated customer: who they are, when they arrive and what
+// they try to buy.
+type Buyer struct {
+	Index         int
+	UserID        uint
+	Arrival       time.Duration
+	TicketClassID uint
+	Quantity      int
+	Outcome       string
+	KTPNumber     string
+}
+
+type planConfig struct {
+	buyers      int
+	arrival     string
+	window      time.Duration
+	basket      *weighted[int]
+	classes     *weighted[uint]
+	cancelRate  float64
+	abandonRate float64
+	userIDBase  uint
+	runID       int64
+}
+
+// jakartaDistricts are the district codes synthetic NIKs are issued in.
+var jakartaDistricts = []string{
+	"310101", "310102",
+	"317101", "317102", "317103", "317104", "317105", "317106", "317107", "317108", "317109", "317110",
+	"317201", "317202", "317203", "317204", "317205", "317206", "317207", "317208", "317209", "317210",
+	"317301", "317302", "317303", "317304", "317305", "317306", "317307", "317308",
+	"317401", "317402", "317403", "317404", "317405", "317406", "317407", "317408",
+	"317501", "317502", "317503", "317504", "317505", "317506",
+}
+
+// syntheticNIK returns a valid NIK that is unique for each run (within 100000
+// runs) and buyer (within 100000 buyers). The number is spread over the serial,
+// birth date (between 1960 and 1999, so every buyer is an adult), gender and
+// district.
+func syntheticNIK(runID int64, buyer int) string {
+	const birthDays = 40 * 365
+	n := uint64(runID%100000)*100000 + uint64(buyer%100000)
+	serial := int(n%9999) + 1
+	n /= 9999
+	birthDate := time.Date(1960, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(n%birthDays))
+	n /= birthDays
+	gender := models.GenderMale
+	if n%2 == 1 {
+		gender = models.GenderFemale
+	}
+	n /= 2
+	return utils.FormatNIK(jakartaDistricts[n%uint64(len(jakartaDistricts))], birthDate, gender, serial)
+}
+
+// planBuyers decides everything random about the run up front, so a seed
+// reproduces the same run.
+func planBuyers(cfg planConfig, rng *rand.Rand) ([]Buyer, error) {
+	if cfg.cancelRate < 0 || cfg.abandonRate < 0 || cfg.cancelRate+cfg.abandonRate > 1 {
+		return nil, fmt.Errorf("cancel rate %.2f and abandon rate %.2f must be non-negative and add up to at most 1", cfg.cancelRate, cfg.abandonRate)
+	}
+	offsets, err := arrivalOffsets(cfg.arrival, cfg.buyers, cfg.window, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	buyers := make([]Buyer, cfg.buyers)
+	for i := range buyers {
+		outcome := OutcomePay
+		switch r := rng.Float64(); {
+		case r < cfg.cancelRate:
+			outcome = OutcomeCancel
+		case r < cfg.cancelRate+cfg.abandonRate:
+			outcome = OutcomeAbandon
+		}
+		buyers[i] = Buyer{
+			Index:         i,
+			UserID:        cfg.userIDBase + uint(i),
+			Arrival:       offsets[i],
+			TicketClassID: cfg.classes.pick(rng),
+			Quantity:      cfg.basket.pick(rng),
+			Outcome:       outcome,
+			// Each buyer gets their own KTP number, which carries the run ID
+			// so repeated runs do not add up against KTP purchase limits.
+			KTPNumber: syntheticNIK(cfg.runID, i),
+		}
+	}
+	return buyers, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"backend/booking-service/utils"
+)
+
+func TestArrivalOffsetsStayInWindow(t *testing.T) {
+	const n = 1000
+	window := 10 * time.Second
+	for _, curve := range []string{ArrivalBurst, ArrivalUniform, ArrivalRamp} {
+		offsets, err := arrivalOffsets(curve, n, window, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, offset := range offsets {
+			if offset < 0 || offset > window {
+				t.Fatalf("%s: offset %d = %s, outside [0, %s]", curve, i, offset, window)
+			}
+			if i > 0 && offset < offsets[i-1] {
+				t.Fatalf("%s: offsets not sorted at %d", curve, i)
+			}
+		}
+	}
+
+	// Under a ramp, the second half of the window gets three quarters of the buyers.
+	offsets, _ := arrivalOffsets(ArrivalRamp, n, window, nil)
+	late := 0
+	for _, offset := range offsets {
+		if offset >= window/2 {
+			late++
+		}
+	}
+	if late != 3*n/4 {
+		t.Errorf("ramp: %d buyers in the second half, want %d", late, 3*n/4)
+	}
+
+	if _, err := arrivalOffsets("sawtooth", n, window, nil); err == nil {
+		t.Error("unknown curve accepted")
+	}
+}
+
+func TestParseBasket(t *testing.T) {
+	basket, err := parseBasket("1:3, 4:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	counts := map[int]int{}
+	for range 4000 {
+		counts[basket.pick(rng)]++
+	}
+	if len(counts) != 2 || counts[1] < 2800 || counts[1] > 3200 {
+		t.Errorf("picks = %v, want about 3000 ones and 1000 fours", counts)
+	}
+
+	for _, spec := range []string{"6:1", "0", "1:-1", "two:1", "1:0"} {
+		if _, err := parseBasket(spec); err == nil {
+			t.Errorf("parseBasket(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestSyntheticNIKsAreUniqueAcrossRuns(t *testing.T) {
+	seen := map[string]bool{}
+	for _, runID := range []int64{0, 1, 99999, 1760000042} {
+		for _, buyer := range []int{0, 1, 9998, 9999, 99999} {
+			nik := syntheticNIK(runID, buyer)
+			if _, err := utils.ParseNIK(nik, time.Now()); err != nil {
+				t.Errorf("syntheticNIK(%d, %d) = %s: %v", runID, buyer, nik, err)
+			}
+			if seen[nik] {
+				t.Errorf("syntheticNIK(%d, %d) = %s, already used", runID, buyer, nik)
+			}
+			seen[nik] = true
+		}
+	}
+}
+
+func TestPlanBuyersIsReproducible(t *testing.T) {
+	basket, _ := parseBasket("1,2")
+	classes := &weighted[uint]{}
+	classes.add(7, 1)
+	cfg := planConfig{
+		buyers:      500,
+		arrival:     ArrivalPoisson,
+		window:      time.Second,
+		basket:      basket,
+		classes:     classes,
+		cancelRate:  0.2,
+		abandonRate: 0.1,
+		userIDBase:  100,
+		runID:       42,
+	}
+
+	first, err := planBuyers(cfg, rand.New(rand.NewSource(9)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := planBuyers(cfg, rand.New(rand.NewSource(9)))
+	ktps := map[string]bool{}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("buyer %d differs between runs with the same seed", i)
+		}
+		if _, err := utils.ParseNIK(first[i].KTPNumber, time.Now()); err != nil || ktps[first[i].KTPNumber] {
+			t.Fatalf("buyer %d has KTP %q, want a unique valid NIK: %v", i, first[i].KTPNumber, err)
+		}
+		ktps[first[i].KTPNumber] = true
+	}
+
+	cfg.cancelRate = 0.95
+	if _, err := planBuyers(cfg, rand.New(rand.NewSource(9))); err == nil {
+		t.Error("cancel and abandon rates over 1 accepted")
+	}
+}
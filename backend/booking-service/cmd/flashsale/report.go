@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+// Recorder collects calls and the seats buyers ended up holding from many
+// goroutines.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+	// held counts, per ticket class, the seats of bookings this run created
+	// and did not cancel.
+	held map[uint]int
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{held: make(map[uint]int)}
+}
+
+func (r *Recorder) Record(call Call) {
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+}
+
+func (r *Recorder) Hold(ticketClassID uint, seats int) {
+	r.mu.Lock()
+	r.held[ticketClassID] += seats
+	r.mu.Unlock()
+}
+
+// percentile returns the nearest-rank percentile p (0-100) of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// ClassCheck compares what one ticket class ended up with against what it can
+// hold.
+type ClassCheck struct {
+	Name             string
+	Capacity         int
+	AvailableBefore  int
+	AvailableAfter   int
+	HeldByRun        int
+	ReservedOrBooked int
+}
+
+// Oversold reports whether more seats were handed out than the class has.
+func (c ClassCheck) Oversold() bool {
+	return c.AvailableAfter < 0 || c.ReservedOrBooked > c.Capacity || c.HeldByRun > c.AvailableBefore
+}
+
+// Drift reports whether the seat counter moved by something other than what
+// this run holds, either from other traffic or from the counter losing track.
+func (c ClassCheck) Drift() int {
+	return (c.AvailableBefore - c.AvailableAfter) - c.HeldByRun
+}
+
+func checkClasses(before, after models.ConcertResponse, seats []models.SeatResponse, held map[uint]int) []ClassCheck {
+	availableBefore := make(map[uint]int)
+	for _, tc := range before.TicketClasses {
+		availableBefore[tc.ID] = tc.AvailableSeatsInClass
+	}
+	taken := make(map[uint]int)
+	for _, seat := range seats {
+		if seat.Status == models.SeatStatusReserved || seat.Status == models.SeatStatusBooked {
+			taken[seat.TicketClassID]++
+		}
+	}
+
+	var checks []ClassCheck
+	for _, tc := range after.TicketClasses {
+		checks = append(checks, ClassCheck{
+			Name:             tc.Name,
+			Capacity:         tc.TotalSeatsInClass,
+			AvailableBefore:  availableBefore[tc.ID],
+			AvailableAfter:   tc.AvailableSeatsInClass,
+			HeldByRun:        held[tc.ID],
+			ReservedOrBooked: taken[tc.ID],
+		})
+	}
+	return checks
+}
+
+// WriteReport prints throughput, latency percentiles, the error breakdown and
+// the oversell check. It returns whether any class was oversold.
+func (r *Recorder) WriteReport(w io.Writer, buyers int, elapsed time.Duration, checks []ClassCheck) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byOp := make(map[string][]Call)
+	for _, call := range r.calls {
+		byOp[call.Op] = append(byOp[call.Op], call)
+	}
+	seconds := elapsed.Seconds()
+
+	fmt.Fprintf(w, "Flash sale: %d buyers, %d requests in %s (%.1f req/s)\n\n", buyers, len(r.calls), elapsed.Round(time.Millisecond), float64(len(r.calls))/seconds)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\trequests\tok\tok/s\tp50\tp90\tp95\tp99\tmax\t")
+	for _, op := range []string{OpBook, OpPay, OpCancel} {
+		calls := byOp[op]
+		if len(calls) == 0 {
+			continue
+		}
+		latencies := make([]time.Duration, len(calls))
+		ok := 0
+		for i, call := range calls {
+			latencies[i] = call.Latency
+			if call.OK() {
+				ok++
+			}
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t%s\t\n", op, len(calls), ok, float64(ok)/seconds,
+			roundLatency(percentile(latencies, 50)), roundLatency(percentile(latencies, 90)),
+			roundLatency(percentile(latencies, 95)), roundLatency(percentile(latencies, 99)),
+			roundLatency(latencies[len(latencies)-1]))
+	}
+	tw.Flush()
+
+	errorCounts := make(map[string]int)
+	for _, call := range r.calls {
+		if call.OK() {
+			continue
+		}
+		errorCounts[describeFailure(call)]++
+	}
+	fmt.Fprintln(w, "\nErrors:")
+	if len(errorCounts) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	keys := make([]string, 0, len(errorCounts))
+	for key := range errorCounts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if errorCounts[keys[i]] != errorCounts[keys[j]] {
+			return errorCounts[keys[i]] > errorCounts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %6d  %s\n", errorCounts[key], key)
+	}
+
+	fmt.Fprintln(w, "\nSeats:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "class\tcapacity\tavailable before\tavailable after\theld by run\treserved+booked\tdrift\t\t")
+	oversold := false
+	for _, check := range checks {
+		verdict := "ok"
+		if check.Oversold() {
+			verdict = "OVERSOLD"
+			oversold = true
+		} else if check.Drift() != 0 {
+			verdict = "counter drift"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t\n", check.Name, check.Capacity, check.AvailableBefore,
+			check.AvailableAfter, check.HeldByRun, check.ReservedOrBooked, check.Drift(), verdict)
+	}
+	tw.Flush()
+	return oversold
+}
+
+func describeFailure(call Call) string {
+	switch {
+	case call.TimedOut:
+		return call.Op + " timeout"
+	case call.Err != nil && call.Status == 0:
+		return call.Op + " transport error: " + firstLine(call.Err.Error())
+	case call.Err != nil:
+		return fmt.Sprintf("%s %d %s", call.Op, call.Status, firstLine(call.Err.Error()))
+	case call.Code == "":
+		return fmt.Sprintf("%s %d", call.Op, call.Status)
+	}
+	return fmt.Sprintf("%s %d %s", call.Op, call.Status, call.Code)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func roundLatency(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/services"
+	"backend/booking-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	availabilityHeartbeatInterval = 15 * time.Second
+	availabilityWriteTimeout      = 10 * time.Second
+)
+
+type AvailabilityController struct {
+	ConcertService *services.ConcertService
+	Hub            *services.AvailabilityHub
+	Upgrader       websocket.Upgrader
+}
+
+func NewAvailabilityController(cs *services.ConcertService, hub *services.AvailabilityHub, allowedOrigin string) *AvailabilityController {
+	return &AvailabilityController{
+		ConcertService: cs,
+		Hub:            hub,
+		Upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || origin == allowedOrigin
+			},
+		},
+	}
+}
+
+// openAvailabilityStream subscribes before taking the snapshot so no change made
+// in between is missed.
+func (ctrl *AvailabilityController) openAvailabilityStream(c *gin.Context) (*models.AvailabilityEvent, <-chan models.AvailabilityEvent, func(), bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid concert ID format")
+		return nil, nil, nil, false
+	}
+	concertID := uint(id)
+
+	events, unsubscribe := ctrl.Hub.Subscribe(concertID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	concert, err := ctrl.ConcertService.GetConcertByID(ctx, concertID)
+	if err != nil {
+		unsubscribe()
+		utils.LogErrorContext(ctx, "Failed to load availability snapshot for concert %d: %v", concertID, err)
+		respondError(c, err)
+		return nil, nil, nil, false
+	}
+
+	snapshot := &models.AvailabilityEvent{
+		Type:       models.AvailabilityEventSnapshot,
+		ConcertID:  concertID,
+		Concert:    concert,
+		OccurredAt: time.Now(),
+	}
+	return snapshot, events, unsubscribe, true
+}
+
+// @Summary Stream concert availability (SSE)
+// @Description Server-sent event stream of seat and ticket class availability changes for a concert. The first event is a snapshot of the concert.
+// @Tags Concerts
+// @Produce text/event-stream
+// @Param id path int true "Concert ID"
+// @Success 200 {object} models.AvailabilityEvent
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid concert ID"
+// @Failure 404 {object} models.ProblemDetails "Not Found - Concert not found"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to retrieve concert"
+// @Router /concerts/{id}/availability/stream [get]
+func (ctrl *AvailabilityController) StreamAvailabilitySSE(c *gin.Context) {
+	snapshot, events, unsubscribe, ok := ctrl.openAvailabilityStream(c)
+	if !ok {
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent(snapshot.Type, snapshot)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(availabilityHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ctrl.Hub.Done():
+			return false
+		case event := <-events:
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"time": time.Now()})
+			return true
+		}
+	})
+}
+
+// @Summary Stream concert availability (WebSocket)
+// @Description WebSocket stream of seat and ticket class availability changes for a concert. Each message is a JSON availability event, starting with a snapshot.
+// @Tags Concerts
+// @Param id path int true "Concert ID"
+// @Success 101 {object} models.AvailabilityEvent
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid concert ID"
+// @Failure 404 {object} models.ProblemDetails "Not Found - Concert not found"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to retrieve concert"
+// @Router /concerts/{id}/availability/ws [get]
+func (ctrl *AvailabilityController) StreamAvailabilityWS(c *gin.Context) {
+	snapshot, events, unsubscribe, ok := ctrl.openAvailabilityStream(c)
+	if !ok {
+		return
+	}
+	defer unsubscribe()
+
+	conn, err := ctrl.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		utils.LogWarningContext(c.Request.Context(), "Failed to upgrade availability stream to WebSocket for concert %d: %v", snapshot.ConcertID, err)
+		return
+	}
+	defer conn.Close()
+
+	// The client never sends anything meaningful; reading only detects a close.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(event *models.AvailabilityEvent) bool {
+		conn.SetWriteDeadline(time.Now().Add(availabilityWriteTimeout))
+		if err := conn.WriteJSON(event); err != nil {
+			utils.LogWarningContext(c.Request.Context(), "Closing availability WebSocket for concert %d: %v", event.ConcertID, err)
+			return false
+		}
+		return true
+	}
+
+	if !write(snapshot) {
+		return
+	}
+
+	heartbeat := time.NewTicker(availabilityHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-c.Request.Context().Done():
+			return
+		case <-ctrl.Hub.Done():
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(availabilityWriteTimeout))
+			return
+		case event := <-events:
+			if !write(&event) {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(availabilityWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
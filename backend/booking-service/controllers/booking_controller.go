@@ -3,7 +3,7 @@ package controllers
 import (
 	"context"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"backend/booking-service/models"
@@ -14,10 +14,6 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
 type SuccessResponse struct {
 	Message string `json:"message"`
 }
@@ -40,32 +36,34 @@ func NewBookingController(bs *services.BookingService) *BookingController {
 // @Accept json
 // @Produce json
 // @Param createBookingRequest body models.CreateBookingRequest true "Booking request details"
+// @Param Idempotency-Key header string false "Makes retries safe: a repeated request with the same key returns the original response"
 // @Security ApiKeyAuth
 // @Success 201 {object} models.BookingResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 401 {object} ErrorResponse
-// @Failure 409 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} models.ProblemDetails
+// @Failure 401 {object} models.ProblemDetails
+// @Failure 409 {object} models.ProblemDetails
+// @Failure 422 {object} models.ProblemDetails "Unprocessable Entity - Idempotency-Key reused with a different payload"
+// @Failure 500 {object} models.ProblemDetails
 // @Router /bookings [post]
 func (ctrl *BookingController) CreateBooking(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.LogError("UserID not found in context for CreateBooking")
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
+		utils.LogErrorContext(c.Request.Context(), "UserID not found in context for CreateBooking")
+		utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	var req models.CreateBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogWarning("Invalid request body for CreateBooking: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+		utils.LogWarningContext(c.Request.Context(), "Invalid request body for CreateBooking: %v", err)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body")
 		return
 	}
 
 	if err := ctrl.Validate.Struct(req); err != nil {
 		validationErrors := err.(validator.ValidationErrors)
-		utils.LogError("Validation error for CreateBooking: %v", validationErrors)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: utils.FormatValidationErrors(validationErrors)})
+		utils.LogErrorContext(c.Request.Context(), "Validation error for CreateBooking: %v", validationErrors)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeValidationFailed, utils.FormatValidationErrors(validationErrors))
 		return
 	}
 
@@ -74,20 +72,14 @@ func (ctrl *BookingController) CreateBooking(c *gin.Context) {
 
 	bookingResp, err := ctrl.BookingService.CreateBooking(ctx, userID.(uint), &req)
 	if err != nil {
-		utils.LogError("Failed to create booking for user %d: %v", userID.(uint), err)
-		if strings.Contains(err.Error(), "not enough overall seats available") ||
-			strings.Contains(err.Error(), "ticket class ID not found") ||
-			strings.Contains(err.Error(), "failed to reserve tickets for class") ||
-			strings.Contains(err.Error(), "concert is not active for booking") ||
-			strings.Contains(err.Error(), "invalid total number of tickets requested") ||
-			strings.Contains(err.Error(), "you already have an active (pending or confirmed) booking for this concert") {
-			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		utils.LogErrorContext(ctx, "Failed to create booking for user %d: %v", userID.(uint), err)
+		respondError(c, err)
 		return
 	}
 
+	if !canViewPII(c) {
+		bookingResp.MaskPII()
+	}
 	c.JSON(http.StatusCreated, bookingResp)
 }
 
@@ -99,24 +91,24 @@ func (ctrl *BookingController) CreateBooking(c *gin.Context) {
 // @Param id path string true "Booking ID (UUID)"
 // @Security ApiKeyAuth
 // @Success 200 {object} models.BookingResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 401 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} models.ProblemDetails
+// @Failure 401 {object} models.ProblemDetails
+// @Failure 404 {object} models.ProblemDetails
+// @Failure 500 {object} models.ProblemDetails
 // @Router /bookings/{id} [get]
 func (ctrl *BookingController) GetBookingByID(c *gin.Context) {
 
 	bookingID := c.Param("id")
 	if bookingID == "" {
-		utils.LogWarning("Invalid booking ID format: empty ID")
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid booking ID"})
+		utils.LogWarningContext(c.Request.Context(), "Invalid booking ID format: empty ID")
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid booking ID")
 		return
 	}
 
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.LogError("UserID not found in context for GetBookingDetails")
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
+		utils.LogErrorContext(c.Request.Context(), "UserID not found in context for GetBookingDetails")
+		utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -125,19 +117,14 @@ func (ctrl *BookingController) GetBookingByID(c *gin.Context) {
 
 	bookingResp, err := ctrl.BookingService.GetBookingDetails(ctx, bookingID, userID.(uint))
 	if err != nil {
-		utils.LogError("Failed to get booking %s details for user %d: %v", bookingID, userID.(uint), err)
-		if err.Error() == "booking not found" {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
-			return
-		}
-		if err.Error() == "unauthorized: you can only view your own bookings" {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		utils.LogErrorContext(ctx, "Failed to get booking %s details for user %d: %v", bookingID, userID.(uint), err)
+		respondError(c, err)
 		return
 	}
 
+	if !canViewPII(c) {
+		bookingResp.MaskPII()
+	}
 	c.JSON(http.StatusOK, bookingResp)
 }
 
@@ -148,14 +135,14 @@ func (ctrl *BookingController) GetBookingByID(c *gin.Context) {
 // @Produce json
 // @Security ApiKeyAuth
 // @Success 200 {array} models.BookingResponse
-// @Failure 401 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} models.ProblemDetails
+// @Failure 500 {object} models.ProblemDetails
 // @Router /my-bookings [get]
 func (ctrl *BookingController) GetMyBookings(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.LogError("UserID not found in context for GetMyBookings")
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
+		utils.LogErrorContext(c.Request.Context(), "UserID not found in context for GetMyBookings")
+		utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -164,45 +151,160 @@ func (ctrl *BookingController) GetMyBookings(c *gin.Context) {
 
 	bookingsResp, err := ctrl.BookingService.GetBookingsByUserID(ctx, userID.(uint))
 	if err != nil {
-		utils.LogError("Failed to get bookings for user %d: %v", userID.(uint), err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		utils.LogErrorContext(ctx, "Failed to get bookings for user %d: %v", userID.(uint), err)
+		respondError(c, err)
 		return
 	}
 
+	if !canViewPII(c) {
+		for i := range bookingsResp {
+			bookingsResp[i].MaskPII()
+		}
+	}
 	c.JSON(http.StatusOK, bookingsResp)
 }
 
+// @Summary Look up bookings by KTP number (Admin)
+// @Description Finds the bookings whose buyer or ticket holder has the KTP number, with their details unmasked.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param ktpLookupRequest body models.KTPLookupRequest true "KTP number to look up"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.BookingResponse
+// @Failure 400 {object} models.ProblemDetails
+// @Failure 401 {object} models.ProblemDetails
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Failure 500 {object} models.ProblemDetails
+// @Router /admin/bookings/lookup [post]
+func (ctrl *BookingController) LookupBookingsByKTP(c *gin.Context) {
+	var req models.KTPLookupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.LogWarningContext(c.Request.Context(), "Invalid request body for LookupBookingsByKTP: %v", err)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body")
+		return
+	}
+
+	if err := ctrl.Validate.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.LogWarningContext(c.Request.Context(), "Validation error for LookupBookingsByKTP: %v", validationErrors)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeValidationFailed, utils.FormatValidationErrors(validationErrors))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	bookingsResp, err := ctrl.BookingService.FindBookingsByKTP(ctx, req.KTPNumber)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bookingsResp)
+}
+
+// @Summary Check in a booking (Admin)
+// @Description Admits a confirmed booking at the venue and shows the scanner the holder's age band. A booking that was already checked in is reported with already_checked_in set.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Booking ID (UUID)"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.CheckInResponse
+// @Failure 401 {object} models.ProblemDetails
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Failure 404 {object} models.ProblemDetails
+// @Failure 409 {object} models.ProblemDetails "Booking is not confirmed"
+// @Failure 500 {object} models.ProblemDetails
+// @Router /admin/bookings/{id}/check-in [post]
+func (ctrl *BookingController) CheckInBooking(c *gin.Context) {
+	bookingID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	checkIn, err := ctrl.BookingService.CheckInBooking(ctx, bookingID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, checkIn)
+}
+
+// @Summary Review accounts that share identifiers (Admin)
+// @Description Groups the accounts whose bookings share a KTP number, phone number or payment card, linked through any chain of shared identifiers, largest groups first.
+// @Tags Admin
+// @Produce json
+// @Param concert_id query int false "Only include bookings for this concert"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.IdentityCluster
+// @Failure 400 {object} models.ProblemDetails
+// @Failure 401 {object} models.ProblemDetails
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Failure 500 {object} models.ProblemDetails
+// @Router /admin/bookings/identity-clusters [get]
+func (ctrl *BookingController) GetIdentityClusters(c *gin.Context) {
+	var concertID uint
+	if concertIDParam := c.Query("concert_id"); concertIDParam != "" {
+		id, err := strconv.ParseUint(concertIDParam, 10, 32)
+		if err != nil {
+			utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid concert ID format")
+			return
+		}
+		concertID = uint(id)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	clusters, err := ctrl.BookingService.GetIdentityClusters(ctx, concertID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, clusters)
+}
+
 // @Summary Update booking status (Internal)
-// @Description Internal endpoint for payment service to update booking status.
+// @Description Internal endpoint for payment service to update booking status. Requests must be signed by the payment service.
 // @Tags Bookings (Internal)
 // @Accept json
 // @Produce json
 // @Param id path string true "Booking ID (UUID)"
 // @Param updateBookingStatusRequest body models.UpdateBookingStatusRequest true "Update booking status request"
+// @Param X-Service-Name header string true "Calling service, e.g. payment-service"
+// @Param X-Service-Key-ID header string true "Id of the key that signed the request"
+// @Param X-Service-Timestamp header integer true "Unix seconds when the request was signed"
+// @Param X-Service-Nonce header string true "Random value used once"
+// @Param X-Service-Signature header string true "HMAC-SHA256 of the request"
 // @Success 200 {object} SuccessResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} models.ProblemDetails
+// @Failure 401 {object} models.ProblemDetails "Missing, invalid, stale or replayed signature"
+// @Failure 403 {object} models.ProblemDetails "Service may not call this route"
+// @Failure 500 {object} models.ProblemDetails
 // @Router /internal/bookings/{id}/status [put]
 func (ctrl *BookingController) UpdateBookingStatusInternal(c *gin.Context) {
 
 	bookingID := c.Param("id")
 	if bookingID == "" {
-		utils.LogWarning("Invalid booking ID format for internal status update: empty ID")
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid booking ID"})
+		utils.LogWarningContext(c.Request.Context(), "Invalid booking ID format for internal status update: empty ID")
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid booking ID")
 		return
 	}
 
 	var req models.UpdateBookingStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogWarning("Invalid request body for internal status update: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+		utils.LogWarningContext(c.Request.Context(), "Invalid request body for internal status update: %v", err)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body")
 		return
 	}
 
 	if err := ctrl.Validate.Struct(req); err != nil {
 		validationErrors := err.(validator.ValidationErrors)
-		utils.LogError("Validation error for internal status update: %v", validationErrors)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: utils.FormatValidationErrors(validationErrors)})
+		utils.LogErrorContext(c.Request.Context(), "Validation error for internal status update: %v", validationErrors)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeValidationFailed, utils.FormatValidationErrors(validationErrors))
 		return
 	}
 
@@ -211,18 +313,62 @@ func (ctrl *BookingController) UpdateBookingStatusInternal(c *gin.Context) {
 
 	err := ctrl.BookingService.UpdateBookingStatusFromPayment(ctx, bookingID, req.Status, req.PaymentID)
 	if err != nil {
-		utils.LogError("Failed to update booking %s status internally: %v", bookingID, err)
-		if err.Error() == "booking not found" {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		utils.LogErrorContext(ctx, "Failed to update booking %s status internally: %v", bookingID, err)
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, SuccessResponse{Message: "Booking status updated successfully"})
 }
 
+// @Summary Claim the payment card of a booking (Internal)
+// @Description Internal endpoint for the payment service to count a booking's tickets against the card it is about to charge, before charging it. Requests must be signed by the payment service.
+// @Tags Bookings (Internal)
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID (UUID)"
+// @Param claimPaymentCardRequest body models.ClaimPaymentCardRequest true "Card fingerprint"
+// @Param X-Service-Name header string true "Calling service, e.g. payment-service"
+// @Param X-Service-Key-ID header string true "Id of the key that signed the request"
+// @Param X-Service-Timestamp header integer true "Unix seconds when the request was signed"
+// @Param X-Service-Nonce header string true "Random value used once"
+// @Param X-Service-Signature header string true "HMAC-SHA256 of the request"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} models.ProblemDetails
+// @Failure 401 {object} models.ProblemDetails "Missing, invalid, stale or replayed signature"
+// @Failure 403 {object} models.ProblemDetails "Service may not call this route"
+// @Failure 404 {object} models.ProblemDetails
+// @Failure 409 {object} models.ProblemDetails "Purchase limit reached or booking not pending"
+// @Failure 500 {object} models.ProblemDetails
+// @Router /internal/bookings/{id}/payment-card [put]
+func (ctrl *BookingController) ClaimPaymentCardInternal(c *gin.Context) {
+	bookingID := c.Param("id")
+
+	var req models.ClaimPaymentCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.LogWarningContext(c.Request.Context(), "Invalid request body for payment card claim: %v", err)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body")
+		return
+	}
+
+	if err := ctrl.Validate.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.LogErrorContext(c.Request.Context(), "Validation error for payment card claim: %v", validationErrors)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeValidationFailed, utils.FormatValidationErrors(validationErrors))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := ctrl.BookingService.ClaimPaymentCard(ctx, bookingID, req.CardFingerprint); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Payment card accepted"})
+}
+
 // @Summary Cancel a pending booking
 // @Description Allows a user to cancel their pending booking.
 // @Tags Bookings
@@ -231,24 +377,24 @@ func (ctrl *BookingController) UpdateBookingStatusInternal(c *gin.Context) {
 // @Param id path string true "Booking ID (UUID)"
 // @Security ApiKeyAuth
 // @Success 200 {object} SuccessResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 401 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} models.ProblemDetails
+// @Failure 401 {object} models.ProblemDetails
+// @Failure 404 {object} models.ProblemDetails
+// @Failure 500 {object} models.ProblemDetails
 // @Router /bookings/{id}/cancel [put]
 func (ctrl *BookingController) CancelBooking(c *gin.Context) {
 
 	bookingID := c.Param("id")
 	if bookingID == "" {
-		utils.LogWarning("Invalid booking ID format for CancelBooking: empty ID")
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid booking ID"})
+		utils.LogWarningContext(c.Request.Context(), "Invalid booking ID format for CancelBooking: empty ID")
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid booking ID")
 		return
 	}
 
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.LogError("UserID not found in context for CancelBooking")
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
+		utils.LogErrorContext(c.Request.Context(), "UserID not found in context for CancelBooking")
+		utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -257,23 +403,18 @@ func (ctrl *BookingController) CancelBooking(c *gin.Context) {
 
 	err := ctrl.BookingService.CancelBooking(ctx, bookingID, userID.(uint))
 	if err != nil {
-		utils.LogError("Failed to cancel booking %s for user %d: %v", bookingID, userID.(uint), err)
-		if err.Error() == "booking not found" {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "unauthorized") {
-			c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
-			return
-		}
-
-		if strings.Contains(err.Error(), "cannot be cancelled") {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		utils.LogErrorContext(ctx, "Failed to cancel booking %s for user %d: %v", bookingID, userID.(uint), err)
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, SuccessResponse{Message: "Booking cancelled successfully"})
 }
+
+// canViewPII reports whether the caller may see buyer and ticket holder details
+// unmasked. Only admins may; everyone else, including the buyer, gets them
+// masked.
+func canViewPII(c *gin.Context) bool {
+	role, _ := c.Get("role")
+	return role == "admin"
+}
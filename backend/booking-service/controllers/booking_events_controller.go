@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/services"
+	"backend/booking-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const bookingCountdownInterval = 1 * time.Second
+
+type BookingEventsController struct {
+	BookingService *services.BookingService
+	Hub            *services.BookingEventHub
+}
+
+func NewBookingEventsController(bs *services.BookingService, hub *services.BookingEventHub) *BookingEventsController {
+	return &BookingEventsController{
+		BookingService: bs,
+		Hub:            hub,
+	}
+}
+
+// @Summary Stream booking status events
+// @Description Server-sent event stream for the owner of a booking. Emits a snapshot, hold-expiry countdown ticks while the booking is pending, payment results and status transitions. The stream ends once the booking reaches a final status.
+// @Tags Bookings
+// @Produce text/event-stream
+// @Param id path string true "Booking ID (UUID)"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.BookingEvent
+// @Failure 400 {object} models.ProblemDetails
+// @Failure 401 {object} models.ProblemDetails
+// @Failure 403 {object} models.ProblemDetails
+// @Failure 404 {object} models.ProblemDetails
+// @Failure 500 {object} models.ProblemDetails
+// @Router /bookings/{id}/events [get]
+func (ctrl *BookingEventsController) StreamBookingEvents(c *gin.Context) {
+	bookingID := c.Param("id")
+	if bookingID == "" {
+		utils.LogWarningContext(c.Request.Context(), "Invalid booking ID format for booking event stream: empty ID")
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid booking ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.LogErrorContext(c.Request.Context(), "UserID not found in context for StreamBookingEvents")
+		utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Subscribe before reading the booking so a transition in between is not lost.
+	events, unsubscribe := ctrl.Hub.Subscribe(bookingID)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	booking, err := ctrl.BookingService.GetBookingDetails(ctx, bookingID, userID.(uint))
+	cancel()
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to open event stream for booking %s for user %d: %v", bookingID, userID.(uint), err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	status := booking.Status
+	expiresAt := booking.ExpiresAt
+	c.SSEvent(models.BookingEventSnapshot, models.BookingEvent{
+		Type:       models.BookingEventSnapshot,
+		BookingID:  booking.ID,
+		Status:     status,
+		PaymentID:  booking.PaymentID,
+		ExpiresAt:  expiresAt,
+		OccurredAt: time.Now(),
+	})
+	c.Writer.Flush()
+	if status != models.BookingStatusPending {
+		return
+	}
+
+	countdown := time.NewTicker(bookingCountdownInterval)
+	defer countdown.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ctrl.Hub.Done():
+			return false
+		case event := <-events:
+			c.SSEvent(event.Type, event)
+			status = event.Status
+			return status == models.BookingStatusPending
+		case <-countdown.C:
+			if expiresAt == nil {
+				return true
+			}
+			remaining := int64(time.Until(*expiresAt).Seconds())
+			if remaining < 0 {
+				remaining = 0
+			}
+			c.SSEvent(models.BookingEventExpiryCountdown, models.BookingEvent{
+				Type:             models.BookingEventExpiryCountdown,
+				BookingID:        bookingID,
+				Status:           status,
+				ExpiresAt:        expiresAt,
+				SecondsRemaining: &remaining,
+				OccurredAt:       time.Now(),
+			})
+			return true
+		}
+	})
+}
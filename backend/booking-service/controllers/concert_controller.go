@@ -34,23 +34,23 @@ func NewConcertController(cs *services.ConcertService) *ConcertController {
 // @Security ApiKeyAuth
 // @Param concert body models.CreateConcertRequest true "Concert creation data"
 // @Success 201 {object} models.ConcertResponse "Concert created successfully, seat creation in background"
-// @Failure 400 {object} ErrorResponse "Bad Request - Invalid input or validation errors"
-// @Failure 401 {object} ErrorResponse "Unauthorized - Missing or invalid token"
-// @Failure 403 {object} ErrorResponse "Forbidden - Requires admin role"
-// @Failure 500 {object} ErrorResponse "Internal Server Error - Failed to create concert or offload seat creation"
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid input or validation errors"
+// @Failure 401 {object} models.ProblemDetails "Unauthorized - Missing or invalid token"
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to create concert or offload seat creation"
 // @Router /admin/concerts [post]
 func (ctrl *ConcertController) CreateConcert(c *gin.Context) {
 	var req models.CreateConcertRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogError("Invalid JSON body for create concert: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+		utils.LogErrorContext(c.Request.Context(), "Invalid JSON body for create concert: %v", err)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body")
 		return
 	}
 
 	if err := ctrl.Validate.Struct(req); err != nil {
 		validationErrors := err.(validator.ValidationErrors)
-		utils.LogError("Validation error for create concert: %v", validationErrors)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: utils.FormatValidationErrors(validationErrors)})
+		utils.LogErrorContext(c.Request.Context(), "Validation error for create concert: %v", validationErrors)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeValidationFailed, utils.FormatValidationErrors(validationErrors))
 		return
 	}
 
@@ -59,8 +59,8 @@ func (ctrl *ConcertController) CreateConcert(c *gin.Context) {
 
 	resp, err := ctrl.ConcertService.CreateConcert(ctx, &req)
 	if err != nil {
-		utils.LogError("Failed to create concert: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create concert: " + err.Error()})
+		utils.LogErrorContext(ctx, "Failed to create concert: %v", err)
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -71,7 +71,7 @@ func (ctrl *ConcertController) CreateConcert(c *gin.Context) {
 // @Tags Concerts
 // @Produce json
 // @Success 200 {array} models.ConcertResponse
-// @Failure 500 {object} ErrorResponse "Internal Server Error - Failed to retrieve concerts"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to retrieve concerts"
 // @Router /concerts [get]
 func (ctrl *ConcertController) GetConcerts(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
@@ -79,8 +79,8 @@ func (ctrl *ConcertController) GetConcerts(c *gin.Context) {
 
 	concerts, err := ctrl.ConcertService.GetConcerts(ctx)
 	if err != nil {
-		utils.LogError("Failed to get concerts: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve concerts: " + err.Error()})
+		utils.LogErrorContext(ctx, "Failed to get concerts: %v", err)
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, concerts)
@@ -92,14 +92,14 @@ func (ctrl *ConcertController) GetConcerts(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Concert ID"
 // @Success 200 {object} models.ConcertResponse
-// @Failure 400 {object} ErrorResponse "Bad Request - Invalid concert ID"
-// @Failure 404 {object} ErrorResponse "Not Found - Concert not found"
-// @Failure 500 {object} ErrorResponse "Internal Server Error - Failed to retrieve concert"
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid concert ID"
+// @Failure 404 {object} models.ProblemDetails "Not Found - Concert not found"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to retrieve concert"
 // @Router /concerts/{id} [get]
 func (ctrl *ConcertController) GetConcertByID(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid concert ID format"})
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid concert ID format")
 		return
 	}
 
@@ -108,12 +108,8 @@ func (ctrl *ConcertController) GetConcertByID(c *gin.Context) {
 
 	resp, err := ctrl.ConcertService.GetConcertByID(ctx, uint(id))
 	if err != nil {
-		utils.LogError("Failed to get concert ID %d: %v", id, err)
-		if err.Error() == "concert not found" {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve concert: " + err.Error()})
+		utils.LogErrorContext(ctx, "Failed to get concert ID %d: %v", id, err)
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -125,14 +121,14 @@ func (ctrl *ConcertController) GetConcertByID(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Concert ID"
 // @Success 200 {array} models.SeatResponse
-// @Failure 400 {object} ErrorResponse "Bad Request - Invalid concert ID"
-// @Failure 404 {object} ErrorResponse "Not Found - Concert not found"
-// @Failure 500 {object} ErrorResponse "Internal Server Error - Failed to retrieve seats"
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid concert ID"
+// @Failure 404 {object} models.ProblemDetails "Not Found - Concert not found"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to retrieve seats"
 // @Router /concerts/{id}/seats [get]
 func (ctrl *ConcertController) GetConcertSeats(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid concert ID format"})
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid concert ID format")
 		return
 	}
 
@@ -141,12 +137,8 @@ func (ctrl *ConcertController) GetConcertSeats(c *gin.Context) {
 
 	seats, err := ctrl.ConcertService.GetSeatsForConcert(ctx, uint(id))
 	if err != nil {
-		utils.LogError("Failed to get seats for concert ID %d: %v", id, err)
-		if err.Error() == "concert not found" {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve seats: " + err.Error()})
+		utils.LogErrorContext(ctx, "Failed to get seats for concert ID %d: %v", id, err)
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, seats)
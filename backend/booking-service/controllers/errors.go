@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/booking-service/models"
+	"backend/booking-service/services"
+	"backend/booking-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError writes a service error as a problem response. Errors that are not
+// DomainErrors never reach the client; they are reported as internal errors.
+func respondError(c *gin.Context, err error) {
+	var domainErr *services.DomainError
+	if !errors.As(err, &domainErr) {
+		utils.AbortWithProblem(c, http.StatusInternalServerError, models.CodeInternalError, "internal server error")
+		return
+	}
+	utils.AbortWithProblem(c, statusForError(err), domainErr.Code, domainErr.Message)
+}
+
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+
+	"backend/booking-service/models"
+	"backend/booking-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	HealthService *services.HealthService
+}
+
+func NewHealthController(hs *services.HealthService) *HealthController {
+	return &HealthController{HealthService: hs}
+}
+
+// @Summary Liveness probe
+// @Description Reports that the process is up. It does not check dependencies, so a MySQL or Redis outage does not get the instance restarted.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (ctrl *HealthController) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": models.HealthStatusOK})
+}
+
+// @Summary Readiness probe
+// @Description Checks MySQL, Redis and RabbitMQ, each with a timeout. Returns 503 while any of them is down, so the instance is taken out of rotation.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} models.ReadinessReport
+// @Failure 503 {object} models.ReadinessReport "Service Unavailable - A dependency is down"
+// @Router /readyz [get]
+func (ctrl *HealthController) Readyz(c *gin.Context) {
+	report := ctrl.HealthService.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != models.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// @Summary Get instance diagnostics
+// @Description Shows dependency checks, database and Redis pool usage, RabbitMQ consumer state, leader status and build version of this instance (Admin only).
+// @Tags Health (Admin)
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Diagnostics
+// @Failure 401 {object} models.ProblemDetails "Unauthorized - Missing or invalid token"
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Router /admin/diagnostics [get]
+func (ctrl *HealthController) GetDiagnostics(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.HealthService.Diagnostics(c.Request.Context()))
+}
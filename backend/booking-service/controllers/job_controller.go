@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"backend/booking-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobController struct {
+	LeaderElector *services.LeaderElector
+}
+
+func NewJobController(le *services.LeaderElector) *JobController {
+	return &JobController{LeaderElector: le}
+}
+
+// @Summary Get background job status
+// @Description Shows whether this instance holds the leader lease for scheduled jobs, which instance currently leads, and the last run of each job on this instance (Admin only).
+// @Tags Jobs (Admin)
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.LeaderStatus
+// @Failure 401 {object} models.ProblemDetails "Unauthorized - Missing or invalid token"
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Router /admin/jobs/status [get]
+func (ctrl *JobController) GetJobStatus(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	c.JSON(http.StatusOK, ctrl.LeaderElector.Status(ctx))
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/services"
+	"backend/booking-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueueController struct {
+	QueueService *services.QueueService
+}
+
+func NewQueueController(qs *services.QueueService) *QueueController {
+	return &QueueController{QueueService: qs}
+}
+
+// @Summary List dead-lettered messages
+// @Description Shows messages that exhausted their retries on a queue without removing them (Admin only).
+// @Tags Queues (Admin)
+// @Produce json
+// @Security ApiKeyAuth
+// @Param queue path string true "Queue name, e.g. seat_creation_queue"
+// @Param limit query int false "Maximum number of messages to return" default(50)
+// @Success 200 {array} models.DeadLetterMessage
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid limit"
+// @Failure 401 {object} models.ProblemDetails "Unauthorized - Missing or invalid token"
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Failure 404 {object} models.ProblemDetails "Not Found - Unknown queue"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to read the dead-letter queue"
+// @Router /admin/queues/{queue}/dead-letters [get]
+func (ctrl *QueueController) GetDeadLetters(c *gin.Context) {
+	queueName := c.Param("queue")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid limit, expected a positive number")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	messages, err := ctrl.QueueService.GetDeadLetters(ctx, queueName, limit)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to get dead-lettered messages of queue '%s': %v", queueName, err)
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, messages)
+}
+
+// @Summary Replay dead-lettered messages
+// @Description Moves dead-lettered messages back to their queue with a fresh retry budget. Without message IDs every dead-lettered message of the queue is replayed. One call looks at no more than 1000 messages; when it stops there, more is true and the call can be repeated (Admin only).
+// @Tags Queues (Admin)
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param queue path string true "Queue name, e.g. seat_creation_queue"
+// @Param replayDeadLettersRequest body models.ReplayDeadLettersRequest false "IDs of the messages to replay"
+// @Success 200 {object} models.ReplayDeadLettersResponse
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid request body"
+// @Failure 401 {object} models.ProblemDetails "Unauthorized - Missing or invalid token"
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Failure 404 {object} models.ProblemDetails "Not Found - Unknown queue"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to replay messages"
+// @Router /admin/queues/{queue}/dead-letters/replay [post]
+func (ctrl *QueueController) ReplayDeadLetters(c *gin.Context) {
+	queueName := c.Param("queue")
+
+	var req models.ReplayDeadLettersRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.LogWarningContext(c.Request.Context(), "Invalid request body for ReplayDeadLetters: %v", err)
+			utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	replayed, more, err := ctrl.QueueService.ReplayDeadLetters(ctx, queueName, req.MessageIDs)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to replay dead-lettered messages of queue '%s': %v", queueName, err)
+		respondError(c, err)
+		return
+	}
+
+	utils.LogInfoContext(ctx, "Admin %s replayed %d dead-lettered message(s) of queue '%s'", c.GetString("username"), replayed, queueName)
+	c.JSON(http.StatusOK, models.ReplayDeadLettersResponse{Queue: queueName, Replayed: replayed, More: more})
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/services"
+	"backend/booking-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController struct {
+	ReportService *services.ReportService
+}
+
+func NewReportController(rs *services.ReportService) *ReportController {
+	return &ReportController{ReportService: rs}
+}
+
+// parseReportDate accepts either a plain date (YYYY-MM-DD) or an RFC3339 timestamp.
+// A plain date used as the upper bound covers that whole day.
+func parseReportDate(value string, endOfRange bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfRange {
+			t = t.AddDate(0, 0, 1)
+		}
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// @Summary Get sales report
+// @Description Aggregates bookings by concert, ticket class or day with revenue, ticket counts, average order value, cancellations and expiries (Admin only). Use format=csv or format=xlsx to download the report.
+// @Tags Reports
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security ApiKeyAuth
+// @Param group_by query string false "Grouping: concert, ticket_class or day" default(concert)
+// @Param concert_id query int false "Only include bookings for this concert"
+// @Param from query string false "Start of the booking creation range (YYYY-MM-DD or RFC3339, inclusive)"
+// @Param to query string false "End of the booking creation range (YYYY-MM-DD inclusive, or RFC3339 exclusive)"
+// @Param format query string false "Output format: json, csv or xlsx" default(json)
+// @Success 200 {object} models.SalesReportResponse
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid filters"
+// @Failure 401 {object} models.ProblemDetails "Unauthorized - Missing or invalid token"
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Requires admin role"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to generate report"
+// @Router /admin/reports/sales [get]
+func (ctrl *ReportController) GetSalesReport(c *gin.Context) {
+	filter := models.SalesReportFilter{
+		GroupBy: strings.ToLower(c.DefaultQuery("group_by", models.ReportGroupByConcert)),
+	}
+	switch filter.GroupBy {
+	case models.ReportGroupByConcert, models.ReportGroupByTicketClass, models.ReportGroupByDay:
+	default:
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid group_by, expected one of: concert, ticket_class, day")
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", models.ReportFormatJSON))
+	switch format {
+	case models.ReportFormatJSON, models.ReportFormatCSV, models.ReportFormatXLSX:
+	default:
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid format, expected one of: json, csv, xlsx")
+		return
+	}
+
+	if concertIDParam := c.Query("concert_id"); concertIDParam != "" {
+		id, err := strconv.ParseUint(concertIDParam, 10, 32)
+		if err != nil {
+			utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid concert ID format")
+			return
+		}
+		concertID := uint(id)
+		filter.ConcertID = &concertID
+	}
+
+	var err error
+	if filter.From, err = parseReportDate(c.Query("from"), false); err != nil {
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid 'from' date, expected YYYY-MM-DD or RFC3339")
+		return
+	}
+	if filter.To, err = parseReportDate(c.Query("to"), true); err != nil {
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid 'to' date, expected YYYY-MM-DD or RFC3339")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	report, err := ctrl.ReportService.GetSalesReport(ctx, &filter)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to generate sales report grouped by %s: %v", filter.GroupBy, err)
+		respondError(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("sales-report-%s-%s.%s", filter.GroupBy, report.GeneratedAt.Format("20060102-150405"), format)
+	var buf bytes.Buffer
+	var contentType string
+	switch format {
+	case models.ReportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+		err = ctrl.ReportService.WriteSalesReportCSV(&buf, report)
+	case models.ReportFormatXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		err = ctrl.ReportService.WriteSalesReportXLSX(&buf, report)
+	default:
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to export sales report as %s: %v", format, err)
+		utils.AbortWithProblem(c, http.StatusInternalServerError, models.CodeInternalError, "Failed to export sales report")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -6,11 +6,11 @@ import (
 	"time"
 
 	"backend/booking-service/config"
-	"backend/booking-service/models"
+	"backend/booking-service/utils"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 var DB *gorm.DB
@@ -31,7 +31,7 @@ func ConnectDB(cfg *config.Config) {
 
 	for counts <= maxRetries {
 		DB, dbErr = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info),
+			Logger: queryLogger{},
 		})
 		if dbErr != nil {
 			log.Printf("Attempt %d/%d: Failed to connect to database: %v. Retrying in %s...", counts, maxRetries, dbErr, retryDelay)
@@ -48,6 +48,10 @@ func ConnectDB(cfg *config.Config) {
 		log.Fatalf("Failed to connect to database after %d retries: %v", maxRetries, dbErr)
 	}
 
+	if err := DB.Use(tracing.NewPlugin(tracing.WithoutMetrics())); err != nil {
+		log.Fatalf("Failed to enable query tracing: %v", err)
+	}
+
 	sqlDB, err := DB.DB()
 	if err != nil {
 		log.Fatalf("Failed to get generic database object: %v", err)
@@ -57,27 +61,25 @@ func ConnectDB(cfg *config.Config) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
 
+	if err := utils.RegisterDBStats(sqlDB, cfg.DBName); err != nil {
+		utils.LogWarning("Failed to export database pool metrics: %v", err)
+	}
+
 	log.Println("Database connection established!")
+}
 
-	if !DB.Migrator().HasTable("booking_seats") {
-		log.Println("Creating booking_seats join table...")
-		err = DB.Exec(`
-			CREATE TABLE IF NOT EXISTS booking_seats (
-				booking_id VARCHAR(36) NOT NULL, -- Changed to VARCHAR(36)
-				seat_id BIGINT UNSIGNED NOT NULL,
-				PRIMARY KEY (booking_id, seat_id),
-				CONSTRAINT fk_booking_seats_booking FOREIGN KEY (booking_id) REFERENCES bookings (id) ON DELETE CASCADE,
-				CONSTRAINT fk_booking_seats_seat FOREIGN KEY (seat_id) REFERENCES seats (id) ON DELETE CASCADE
-			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
-		`).Error
-		if err != nil {
-			log.Fatalf("Failed to create booking_seats join table: %v", err)
-		}
+// CloseDB closes the connection pool once the service has stopped using it.
+func CloseDB() {
+	if DB == nil {
+		return
 	}
-
-	err = DB.AutoMigrate(&models.Buyer{}, &models.TicketHolder{})
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatalf("Failed to auto migrate Buyer/TicketHolder tables: %v", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		utils.LogWarning("Failed to close database connections: %v", err)
+		return
 	}
-	log.Println("Buyer and TicketHolder tables migrated successfully!")
+	utils.LogInfo("Database connections closed.")
 }
@@ -0,0 +1,412 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/booking-service/utils"
+
+	"gorm.io/gorm"
+)
+
+// This file is the same in the booking, payment and user services, apart from
+// the utils import. Make any fix to the runner in all three copies.
+
+// migrationFiles are the schema migrations, compiled into the binary so the
+// image does not need the .sql files.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// MigrationsFS returns the migrations shipped with the service.
+func MigrationsFS() fs.FS {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+const (
+	migrationTable       = "schema_migrations"
+	defaultMigrationLock = 5 * time.Minute
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrDirtyMigration means a migration failed part way and the schema has to
+// be repaired by hand before migrating again.
+var ErrDirtyMigration = errors.New("database schema is dirty")
+
+// Migration is one numbered schema change, read from NNN_name.up.sql and
+// NNN_name.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a known migration and whether it has been applied.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Dirty     bool
+}
+
+// Migrator applies and reverts migrations. The version of every applied
+// migration is recorded in schema_migrations; a MySQL named lock keeps
+// replicas that start at the same time from migrating concurrently.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+
+	// LockTimeout is how long to wait for another instance to finish migrating.
+	LockTimeout time.Duration
+
+	lock func(ctx context.Context, conn *sql.Conn, timeout time.Duration) (unlock func(), err error)
+}
+
+// NewMigrator reads the migrations in fsys.
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, LockTimeout: defaultMigrationLock, lock: mysqlLock}, nil
+}
+
+// LoadMigrations reads the migrations in the root of fsys, ordered by version.
+// Every version needs an up file; a missing down file makes it irreversible.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file '%s' is not named NNN_name.up.sql or NNN_name.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration file '%s': %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading migration '%s': %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both '%s' and '%s'", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, state map[int64]appliedMigration) error {
+		for _, migration := range m.migrations {
+			if _, ok := state[migration.Version]; ok {
+				continue
+			}
+			utils.LogInfoContext(ctx, "Applying migration %d_%s", migration.Version, migration.Name)
+			if err := m.run(ctx, conn, migration, migration.Up, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the ones reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, state map[int64]appliedMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := state[migration.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %d_%s cannot be reverted: it has no down file", migration.Version, migration.Name)
+			}
+			utils.LogInfoContext(ctx, "Reverting migration %d_%s", migration.Version, migration.Name)
+			if err := m.run(ctx, conn, migration, migration.Down, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists the known migrations and when each was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	state, err := readMigrationState(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if applied, ok := state[migration.Version]; ok {
+			appliedAt := applied.appliedAt
+			status.AppliedAt = &appliedAt
+			status.Dirty = applied.dirty
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+type appliedMigration struct {
+	appliedAt time.Time
+	dirty     bool
+}
+
+// locked runs fn on one connection holding the migration lock, with the
+// applied migrations read after the lock was taken.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, state map[int64]appliedMigration) error) error {
+	// The lock belongs to a session, so everything runs on one connection.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(ctx, conn, m.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := ensureMigrationTable(ctx, conn); err != nil {
+		return err
+	}
+	state, err := readMigrationState(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for version, applied := range state {
+		if applied.dirty {
+			return fmt.Errorf("%w: migration %d failed part way; repair the schema by hand, then delete its row from %s", ErrDirtyMigration, version, migrationTable)
+		}
+	}
+	return fn(conn, state)
+}
+
+// run executes the statements of one direction of a migration. MySQL commits
+// DDL implicitly, so the migration is marked dirty while it runs: if it fails
+// part way, the row stays dirty and blocks further runs.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration Migration, script string, up bool) error {
+	if up {
+		if _, err := conn.ExecContext(ctx, "INSERT INTO "+migrationTable+" (version, name, dirty, applied_at) VALUES (?, ?, ?, ?)",
+			migration.Version, migration.Name, true, time.Now().UTC()); err != nil {
+			return fmt.Errorf("recording migration %d: %w", migration.Version, err)
+		}
+	} else {
+		if _, err := conn.ExecContext(ctx, "UPDATE "+migrationTable+" SET dirty = ? WHERE version = ?", true, migration.Version); err != nil {
+			return fmt.Errorf("recording migration %d: %w", migration.Version, err)
+		}
+	}
+
+	for i, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d_%s, statement %d: %w", migration.Version, migration.Name, i+1, err)
+		}
+	}
+
+	var err error
+	if up {
+		_, err = conn.ExecContext(ctx, "UPDATE "+migrationTable+" SET dirty = ? WHERE version = ?", false, migration.Version)
+	} else {
+		_, err = conn.ExecContext(ctx, "DELETE FROM "+migrationTable+" WHERE version = ?", migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("recording migration %d: %w", migration.Version, err)
+	}
+	return nil
+}
+
+func ensureMigrationTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+migrationTable+` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		dirty BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", migrationTable, err)
+	}
+	return nil
+}
+
+func readMigrationState(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, dirty, applied_at FROM "+migrationTable)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", migrationTable, err)
+	}
+	defer rows.Close()
+
+	state := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var version int64
+		var applied appliedMigration
+		if err := rows.Scan(&version, &applied.dirty, &applied.appliedAt); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", migrationTable, err)
+		}
+		state[version] = applied
+	}
+	return state, rows.Err()
+}
+
+// mysqlLock takes a named lock scoped to the current database, so the three
+// services can migrate their databases on one server at the same time.
+func mysqlLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) (func(), error) {
+	const name = "CONCAT(DATABASE(), '." + migrationTable + "')"
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK("+name+", ?)", int(timeout.Seconds())).Scan(&acquired); err != nil {
+		return nil, fmt.Errorf("taking migration lock: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return nil, fmt.Errorf("taking migration lock: another instance has held it for more than %s", timeout)
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK("+name+")"); err != nil {
+			utils.LogWarning("Failed to release migration lock: %v", err)
+		}
+	}, nil
+}
+
+// splitStatements splits a migration into statements at semicolons that end a
+// line. Lines holding only a comment are dropped.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// CreateMigration writes empty up and down files for a new migration in dir,
+// numbered after the newest one there.
+func CreateMigration(dir, name string) ([]string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(name, "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return nil, errors.New("migration name is empty")
+	}
+
+	migrations, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	var paths []string
+	for _, direction := range []string{"up", "down"} {
+		p := filepath.Join(dir, fmt.Sprintf("%03d_%s.%s.sql", version, name, direction))
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return paths, err
+		}
+		if err := f.Close(); err != nil {
+			return paths, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// Migrate applies the pending migrations shipped with the service. main runs
+// it at startup unless MIGRATE_ON_START is false.
+func Migrate(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	migrator, err := NewMigrator(sqlDB, MigrationsFS())
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		utils.LogInfoContext(ctx, "Database schema is up to date")
+	} else {
+		utils.LogInfoContext(ctx, "Applied %d database migrations", len(applied))
+	}
+	return nil
+}
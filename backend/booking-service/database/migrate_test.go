@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func noLock(context.Context, *sql.Conn, time.Duration) (func(), error) {
+	return func() {}, nil
+}
+
+func newTestMigrator(t *testing.T, files fstest.MapFS) (*Migrator, *sql.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	m, err := NewMigrator(sqlDB, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.lock = noLock
+	return m, sqlDB
+}
+
+var testMigrations = fstest.MapFS{
+	"001_create_artists.up.sql":     {Data: []byte("CREATE TABLE artists (id INTEGER PRIMARY KEY, name TEXT NOT NULL);")},
+	"001_create_artists.down.sql":   {Data: []byte("DROP TABLE artists;")},
+	"002_add_artist_genre.up.sql":   {Data: []byte("-- Genres are free text for now.\nALTER TABLE artists ADD COLUMN genre TEXT;\nCREATE INDEX idx_artists_genre ON artists (genre);\n")},
+	"002_add_artist_genre.down.sql": {Data: []byte("DROP INDEX idx_artists_genre;\nALTER TABLE artists DROP COLUMN genre;\n")},
+}
+
+func versions(migrations []Migration) []int64 {
+	var vs []int64
+	for _, m := range migrations {
+		vs = append(vs, m.Version)
+	}
+	return vs
+}
+
+func TestMigratorUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t, testMigrations)
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Fatalf("Up applied %v, want [1 2]", got)
+	}
+	if _, err := db.Exec("INSERT INTO artists (name, genre) VALUES ('Dewa 19', 'rock')"); err != nil {
+		t.Fatalf("schema after Up: %v", err)
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up = %v, %v, want nothing to apply", versions(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(reverted); !reflect.DeepEqual(got, []int64{2}) {
+		t.Fatalf("Down reverted %v, want [2]", got)
+	}
+	if _, err := db.Exec("INSERT INTO artists (name, genre) VALUES ('Padi', 'rock')"); err == nil {
+		t.Fatal("genre column still exists after Down")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Fatalf("Status = %+v, want 1 applied and 2 pending", statuses)
+	}
+}
+
+func TestMigratorStopsAtAFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	files := fstest.MapFS{
+		"001_create_artists.up.sql": testMigrations["001_create_artists.up.sql"],
+		"002_broken.up.sql":         {Data: []byte("ALTER TABLE artists ADD COLUMN genre TEXT;\nALTER TABLE nope ADD COLUMN x TEXT;\n")},
+	}
+	m, _ := newTestMigrator(t, files)
+
+	applied, err := m.Up(ctx)
+	if err == nil {
+		t.Fatal("Up succeeded with a broken migration")
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int64{1}) {
+		t.Fatalf("Up applied %v, want [1]", got)
+	}
+
+	// The half-applied migration blocks further runs until it is repaired.
+	if _, err := m.Up(ctx); !errors.Is(err, ErrDirtyMigration) {
+		t.Fatalf("Up after failure = %v, want ErrDirtyMigration", err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[1].Dirty {
+		t.Fatalf("Status = %+v, want 2 dirty", statuses)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- comment\nCREATE TABLE a (\n  id INT -- inline\n);\n\nINSERT INTO a VALUES (1);\nSELECT 1"
+	want := []string{"CREATE TABLE a (\n  id INT -- inline\n)", "INSERT INTO a VALUES (1)", "SELECT 1"}
+	if got := splitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitStatements = %q, want %q", got, want)
+	}
+}
+
+func TestCreateMigrationNumbersAfterTheNewest(t *testing.T) {
+	dir := t.TempDir()
+	for name, file := range testMigrations {
+		if err := os.WriteFile(filepath.Join(dir, name), file.Data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths, err := CreateMigration(dir, "Add artist Country")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "003_add_artist_country.up.sql"), filepath.Join(dir, "003_add_artist_country.down.sql")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("CreateMigration = %v, want %v", paths, want)
+	}
+}
+
+func TestShippedMigrationsLoad(t *testing.T) {
+	migrations, err := LoadMigrations(MigrationsFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s: versions are not consecutive", m.Version, m.Name)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
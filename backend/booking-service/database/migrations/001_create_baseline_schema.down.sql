@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS `ticket_holders`;
+DROP TABLE IF EXISTS `buyers`;
+DROP TABLE IF EXISTS `booking_seats`;
+DROP TABLE IF EXISTS `bookings`;
+DROP TABLE IF EXISTS `seats`;
+DROP TABLE IF EXISTS `ticket_classes`;
+DROP TABLE IF EXISTS `concerts`;
@@ -0,0 +1,119 @@
+-- The schema init_db.sql created before the service had migrations. Databases
+-- created by init_db.sql already have these tables and skip them, so change
+-- the schema in a new migration rather than here.
+CREATE TABLE IF NOT EXISTS `concerts` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    `deleted_at` datetime(3) DEFAULT NULL,
+    `name` varchar(255) NOT NULL,
+    `artist` varchar(255) NOT NULL,
+    `date` datetime(3) NOT NULL,
+    `venue` varchar(255) NOT NULL,
+    `total_seats` int NOT NULL,
+    `available_seats` int NOT NULL,
+    `description` text,
+    `status` varchar(255) DEFAULT 'pending_seat_creation',
+    `image_url` varchar(255) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_concerts_deleted_at` (`deleted_at`),
+    INDEX `idx_concerts_date` (`date`),
+    INDEX `idx_concerts_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `ticket_classes` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    `deleted_at` datetime(3) DEFAULT NULL,
+    `concert_id` bigint unsigned NOT NULL,
+    `name` varchar(255) NOT NULL,
+    `price` decimal(10,2) NOT NULL,
+    `total_seats_in_class` int NOT NULL,
+    `available_seats_in_class` int NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_ticket_class_per_concert_name` (`concert_id`, `name`),
+    KEY `idx_ticket_classes_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_ticket_classes_concert` FOREIGN KEY (`concert_id`) REFERENCES `concerts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `seats` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    `deleted_at` datetime(3) DEFAULT NULL,
+    `concert_id` bigint unsigned NOT NULL,
+    `ticket_class_id` bigint unsigned NOT NULL,
+    `seat_number` varchar(255) NOT NULL,
+    `status` varchar(255) NOT NULL DEFAULT 'available',
+    `user_id` bigint unsigned DEFAULT NULL,
+    `booking_id` varchar(36) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_seat_number_per_class_per_concert` (`concert_id`, `ticket_class_id`, `seat_number`),
+    KEY `idx_seats_deleted_at` (`deleted_at`),
+    KEY `idx_seats_concert_id` (`concert_id`),
+    KEY `idx_seats_ticket_class_id` (`ticket_class_id`),
+    KEY `idx_seats_status` (`status`),
+    CONSTRAINT `fk_seats_concert` FOREIGN KEY (`concert_id`) REFERENCES `concerts` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_seats_ticket_class` FOREIGN KEY (`ticket_class_id`) REFERENCES `ticket_classes` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `bookings` (
+    `id` varchar(36) NOT NULL,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    `deleted_at` datetime(3) DEFAULT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `concert_id` bigint unsigned NOT NULL,
+    `seat_ids` text NOT NULL,
+    `total_price` decimal(10,2) NOT NULL,
+    `status` varchar(255) NOT NULL DEFAULT 'pending',
+    `payment_id` bigint unsigned DEFAULT NULL,
+    `expires_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_bookings_deleted_at` (`deleted_at`),
+    KEY `idx_bookings_user_id` (`user_id`),
+    KEY `idx_bookings_concert_id` (`concert_id`),
+    KEY `idx_bookings_status` (`status`),
+    CONSTRAINT `fk_bookings_concert` FOREIGN KEY (`concert_id`) REFERENCES `concerts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `booking_seats` (
+    `booking_id` varchar(36) NOT NULL,
+    `seat_id` bigint unsigned NOT NULL,
+    PRIMARY KEY (`booking_id`, `seat_id`),
+    KEY `idx_booking_seats_booking_id` (`booking_id`),
+    KEY `idx_booking_seats_seat_id` (`seat_id`),
+    CONSTRAINT `fk_booking_seats_booking` FOREIGN KEY (`booking_id`) REFERENCES `bookings` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_booking_seats_seat` FOREIGN KEY (`seat_id`) REFERENCES `seats` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `buyers` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    `deleted_at` datetime(3) DEFAULT NULL,
+    `booking_id` varchar(36) NOT NULL,
+    `full_name` varchar(255) NOT NULL,
+    `phone_number` varchar(255) NOT NULL,
+    `email` varchar(255) NOT NULL,
+    `ktp_number` varchar(255) NOT NULL UNIQUE,
+    PRIMARY KEY (`id`),
+    KEY `idx_buyers_deleted_at` (`deleted_at`),
+    KEY `idx_buyers_booking_id` (`booking_id`),
+    CONSTRAINT `fk_buyers_booking` FOREIGN KEY (`booking_id`) REFERENCES `bookings` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `ticket_holders` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    `deleted_at` datetime(3) DEFAULT NULL,
+    `booking_id` varchar(36) NOT NULL,
+    `full_name` varchar(255) NOT NULL,
+    `ktp_number` varchar(255) NOT NULL UNIQUE,
+    PRIMARY KEY (`id`),
+    KEY `idx_ticket_holders_deleted_at` (`deleted_at`),
+    KEY `idx_ticket_holders_booking_id` (`booking_id`),
+    CONSTRAINT `fk_ticket_holders_booking` FOREIGN KEY (`booking_id`) REFERENCES `bookings` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
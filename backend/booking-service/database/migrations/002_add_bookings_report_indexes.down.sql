@@ -0,0 +1,3 @@
+ALTER TABLE `bookings`
+    DROP INDEX `idx_bookings_created_at`,
+    DROP INDEX `idx_bookings_concert_created`;
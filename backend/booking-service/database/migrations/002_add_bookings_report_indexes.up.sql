@@ -0,0 +1,4 @@
+-- The sales report groups by concert and day.
+ALTER TABLE `bookings`
+    ADD KEY `idx_bookings_concert_created` (`concert_id`, `created_at`),
+    ADD KEY `idx_bookings_created_at` (`created_at`);
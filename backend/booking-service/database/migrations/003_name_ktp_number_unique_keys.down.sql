@@ -0,0 +1,5 @@
+ALTER TABLE `ticket_holders`
+    RENAME INDEX `idx_ticket_holders_ktp_number` TO `ktp_number`;
+
+ALTER TABLE `buyers`
+    RENAME INDEX `idx_buyers_ktp_number` TO `ktp_number`;
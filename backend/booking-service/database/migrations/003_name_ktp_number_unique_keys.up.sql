@@ -0,0 +1,7 @@
+-- The baseline declared the KTP numbers UNIQUE inline, which names each key
+-- after its column. Give them the idx_<table>_<column> names the other keys use.
+ALTER TABLE `buyers`
+    RENAME INDEX `ktp_number` TO `idx_buyers_ktp_number`;
+
+ALTER TABLE `ticket_holders`
+    RENAME INDEX `ktp_number` TO `idx_ticket_holders_ktp_number`;
@@ -0,0 +1,15 @@
+-- Encrypted values do not fit the old columns. Revert only a database whose
+-- buyers and ticket holders hold plaintext, e.g. one that never served bookings.
+ALTER TABLE `ticket_holders`
+    DROP INDEX `idx_ticket_holders_ktp_index`,
+    DROP COLUMN `ktp_index`,
+    MODIFY `ktp_number` varchar(255) NOT NULL,
+    ADD UNIQUE KEY `idx_ticket_holders_ktp_number` (`ktp_number`);
+
+ALTER TABLE `buyers`
+    DROP INDEX `idx_buyers_ktp_index`,
+    DROP COLUMN `ktp_index`,
+    MODIFY `phone_number` varchar(255) NOT NULL,
+    MODIFY `email` varchar(255) NOT NULL,
+    MODIFY `ktp_number` varchar(255) NOT NULL,
+    ADD UNIQUE KEY `idx_buyers_ktp_number` (`ktp_number`);
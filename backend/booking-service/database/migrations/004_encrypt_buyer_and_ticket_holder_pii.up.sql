@@ -0,0 +1,17 @@
+-- Phone numbers, emails and KTP numbers are stored encrypted from now on, which
+-- makes them longer and random, so KTP uniqueness moves to a keyed hash of the
+-- number. Rows written before this migration keep their plaintext (and a NULL
+-- ktp_index) until `booking-service pii rotate` encrypts them.
+ALTER TABLE `buyers`
+    DROP INDEX `idx_buyers_ktp_number`,
+    MODIFY `phone_number` varchar(1024) NOT NULL,
+    MODIFY `email` varchar(1024) NOT NULL,
+    MODIFY `ktp_number` varchar(1024) NOT NULL,
+    ADD COLUMN `ktp_index` char(64) DEFAULT NULL AFTER `ktp_number`,
+    ADD UNIQUE KEY `idx_buyers_ktp_index` (`ktp_index`);
+
+ALTER TABLE `ticket_holders`
+    DROP INDEX `idx_ticket_holders_ktp_number`,
+    MODIFY `ktp_number` varchar(1024) NOT NULL,
+    ADD COLUMN `ktp_index` char(64) DEFAULT NULL AFTER `ktp_number`,
+    ADD UNIQUE KEY `idx_ticket_holders_ktp_index` (`ktp_index`);
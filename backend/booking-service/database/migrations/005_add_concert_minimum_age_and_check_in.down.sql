@@ -0,0 +1,5 @@
+ALTER TABLE `bookings`
+    DROP COLUMN `checked_in_at`;
+
+ALTER TABLE `concerts`
+    DROP COLUMN `minimum_age`;
@@ -0,0 +1,6 @@
+-- 0 means the concert has no age restriction.
+ALTER TABLE `concerts`
+    ADD COLUMN `minimum_age` tinyint unsigned NOT NULL DEFAULT 0 AFTER `venue`;
+
+ALTER TABLE `bookings`
+    ADD COLUMN `checked_in_at` datetime(3) DEFAULT NULL AFTER `expires_at`;
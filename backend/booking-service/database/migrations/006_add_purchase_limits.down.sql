@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `purchase_limit_counters`;
+DROP TABLE IF EXISTS `booking_identities`;
+
+ALTER TABLE `concerts`
+    DROP COLUMN `max_tickets_per_card`,
+    DROP COLUMN `max_tickets_per_phone`,
+    DROP COLUMN `max_tickets_per_holder_ktp`,
+    DROP COLUMN `max_tickets_per_buyer_ktp`;
@@ -0,0 +1,32 @@
+-- Tickets per identifier across all accounts; 0 means no limit.
+ALTER TABLE `concerts`
+    ADD COLUMN `max_tickets_per_buyer_ktp` int NOT NULL DEFAULT 0 AFTER `minimum_age`,
+    ADD COLUMN `max_tickets_per_holder_ktp` int NOT NULL DEFAULT 0 AFTER `max_tickets_per_buyer_ktp`,
+    ADD COLUMN `max_tickets_per_phone` int NOT NULL DEFAULT 0 AFTER `max_tickets_per_holder_ktp`,
+    ADD COLUMN `max_tickets_per_card` int NOT NULL DEFAULT 0 AFTER `max_tickets_per_phone`;
+
+CREATE TABLE IF NOT EXISTS `booking_identities` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` datetime(3) DEFAULT NULL,
+    `booking_id` varchar(36) NOT NULL,
+    `concert_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `kind` varchar(32) NOT NULL,
+    `value_index` char(64) NOT NULL,
+    `tickets` int NOT NULL,
+    `released_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_booking_identities_booking_id` (`booking_id`),
+    -- The review of shared identifiers reads a concert's identities at once.
+    KEY `idx_booking_identities_concert_id` (`concert_id`),
+    CONSTRAINT `fk_booking_identities_booking` FOREIGN KEY (`booking_id`) REFERENCES `bookings` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `purchase_limit_counters` (
+    `concert_id` bigint unsigned NOT NULL,
+    `kind` varchar(32) NOT NULL,
+    `value_index` char(64) NOT NULL,
+    `tickets` int NOT NULL DEFAULT 0,
+    PRIMARY KEY (`concert_id`, `kind`, `value_index`),
+    CONSTRAINT `fk_purchase_limit_counters_concert` FOREIGN KEY (`concert_id`) REFERENCES `concerts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,4 @@
+-- Why a cancelled booking was cancelled, one of the reasons in
+-- models/booking_model.go. Bookings cancelled before this migration keep NULL.
+ALTER TABLE `bookings`
+    ADD COLUMN `cancellation_reason` varchar(32) DEFAULT NULL AFTER `status`;
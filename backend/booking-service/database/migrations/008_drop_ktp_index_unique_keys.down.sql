@@ -0,0 +1,8 @@
+-- Fails while any KTP number is on more than one booking.
+ALTER TABLE `ticket_holders`
+    DROP INDEX `idx_ticket_holders_ktp_index`,
+    ADD UNIQUE KEY `idx_ticket_holders_ktp_index` (`ktp_index`);
+
+ALTER TABLE `buyers`
+    DROP INDEX `idx_buyers_ktp_index`,
+    ADD UNIQUE KEY `idx_buyers_ktp_index` (`ktp_index`);
@@ -0,0 +1,10 @@
+-- A KTP number may be on any number of bookings, e.g. a buyer's second order
+-- for the same concert; purchase_limit_counters caps how many tickets it gets.
+-- ktp_index stays indexed for the admin lookup by KTP.
+ALTER TABLE `buyers`
+    DROP INDEX `idx_buyers_ktp_index`,
+    ADD KEY `idx_buyers_ktp_index` (`ktp_index`);
+
+ALTER TABLE `ticket_holders`
+    DROP INDEX `idx_ticket_holders_ktp_index`,
+    ADD KEY `idx_ticket_holders_ktp_index` (`ktp_index`);
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"backend/booking-service/utils"
+
+	"gorm.io/gorm"
+)
+
+// piiTables lists the encrypted columns of each table and the column that
+// holds the KTP number its ktp_index is computed from.
+var piiTables = []struct {
+	table     string
+	columns   []string
+	ktpColumn string
+}{
+	{table: "buyers", columns: []string{"phone_number", "email", "ktp_number"}, ktpColumn: "ktp_number"},
+	{table: "ticket_holders", columns: []string{"ktp_number"}, ktpColumn: "ktp_number"},
+}
+
+const piiRotateBatchSize = 500
+
+// PIIRotationResult counts the rows RotatePIIKeys rewrote per table.
+type PIIRotationResult struct {
+	Table   string
+	Scanned int
+	Updated int
+}
+
+// RotatePIIKeys re-seals every encrypted value whose data key is sealed with
+// an older master key under the current one, and encrypts and indexes rows
+// that still hold plaintext from before encryption. It can run while the
+// service is up and be repeated; rows that are up to date are left alone. The
+// old key can be removed from PII_ENCRYPTION_KEYS once it has finished.
+func RotatePIIKeys(ctx context.Context, db *gorm.DB) ([]PIIRotationResult, error) {
+	var results []PIIRotationResult
+	for _, t := range piiTables {
+		result := PIIRotationResult{Table: t.table}
+		var lastID uint
+		for {
+			rows, err := db.WithContext(ctx).Table(t.table).
+				Select(append([]string{"id", "ktp_index"}, t.columns...)).
+				Where("id > ?", lastID).Order("id").Limit(piiRotateBatchSize).Rows()
+			if err != nil {
+				return results, fmt.Errorf("failed to read %s: %w", t.table, err)
+			}
+			batch, err := scanPIIRows(rows, len(t.columns))
+			if err != nil {
+				return results, fmt.Errorf("failed to read %s: %w", t.table, err)
+			}
+			if len(batch) == 0 {
+				break
+			}
+
+			for _, row := range batch {
+				lastID = row.id
+				result.Scanned++
+				updates := map[string]interface{}{}
+				for i, column := range t.columns {
+					encryptionContext := t.table + "." + column
+					rewrapped, changed, err := utils.RewrapPII(row.values[i], encryptionContext)
+					if err != nil {
+						return results, fmt.Errorf("%s %d, %s: %w", t.table, row.id, column, err)
+					}
+					if changed {
+						updates[column] = rewrapped
+					}
+					if column == t.ktpColumn && !row.ktpIndex.Valid {
+						ktpNumber, err := utils.DecryptPII(row.values[i], encryptionContext)
+						if err != nil {
+							return results, fmt.Errorf("%s %d, %s: %w", t.table, row.id, column, err)
+						}
+						index, err := utils.BlindIndex(ktpNumber)
+						if err != nil {
+							return results, err
+						}
+						updates["ktp_index"] = index
+					}
+				}
+				if len(updates) == 0 {
+					continue
+				}
+				if err := db.WithContext(ctx).Table(t.table).Where("id = ?", row.id).Updates(updates).Error; err != nil {
+					return results, fmt.Errorf("failed to update %s %d: %w", t.table, row.id, err)
+				}
+				result.Updated++
+			}
+		}
+		utils.LogInfo("PII key rotation: %d of %d %s rows updated.", result.Updated, result.Scanned, t.table)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+type piiRow struct {
+	id       uint
+	ktpIndex sql.NullString
+	values   []string
+}
+
+func scanPIIRows(rows *sql.Rows, columns int) ([]piiRow, error) {
+	defer rows.Close()
+	var batch []piiRow
+	for rows.Next() {
+		row := piiRow{values: make([]string, columns)}
+		dest := []interface{}{&row.id, &row.ktpIndex}
+		for i := range row.values {
+			dest = append(dest, &row.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	return batch, rows.Err()
+}
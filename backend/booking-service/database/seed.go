@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// seedFiles is sample data for local development. It is kept out of the
+// migrations so that it never reaches a production database; load it with
+// `booking-service migrate seed`.
+//
+//go:embed seeds/*.sql
+var seedFiles embed.FS
+
+// Seed loads the sample data into db, one file at a time in name order, and
+// returns the files loaded. The files insert with INSERT IGNORE, so seeding
+// again leaves existing rows alone.
+func Seed(ctx context.Context, db *sql.DB) ([]string, error) {
+	names, err := fs.Glob(seedFiles, "seeds/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var loaded []string
+	for _, name := range names {
+		script, err := fs.ReadFile(seedFiles, name)
+		if err != nil {
+			return loaded, fmt.Errorf("reading seed '%s': %w", name, err)
+		}
+		for i, statement := range splitStatements(string(script)) {
+			if _, err := db.ExecContext(ctx, statement); err != nil {
+				return loaded, fmt.Errorf("seed %s, statement %d: %w", name, i+1, err)
+			}
+		}
+		loaded = append(loaded, name)
+	}
+	return loaded, nil
+}
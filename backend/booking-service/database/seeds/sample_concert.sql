@@ -0,0 +1,8 @@
+-- A sample concert for local development. Its seats are created by the
+-- republish-pending-seat-creation job, as it is still pending_seat_creation.
+INSERT IGNORE INTO `concerts` (`id`, `name`, `artist`, `date`, `venue`, `total_seats`, `available_seats`, `description`, `status`, `image_url`, `created_at`, `updated_at`) VALUES
+(1, 'Konser Rock Legendaris', 'Band Idola', '2025-08-15 20:00:00', 'Stadion Utama', 600, 600, 'Konser paling ditunggu tahun ini!', 'pending_seat_creation', 'https://example.com/concert_image.jpg', NOW(), NOW());
+
+INSERT IGNORE INTO `ticket_classes` (`id`, `concert_id`, `name`, `price`, `total_seats_in_class`, `available_seats_in_class`, `created_at`, `updated_at`) VALUES
+(1, 1, 'Festival', 500000.00, 500, 500, NOW(), NOW()),
+(2, 1, 'VIP', 1500000.00, 100, 100, NOW(), NOW());
@@ -0,0 +1,93 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"backend/booking-service/models"
+)
+
+// EventPublisher records the events the services publish.
+type EventPublisher struct {
+	mu                 sync.Mutex
+	availabilityEvents []models.AvailabilityEvent
+	bookingEvents      []models.BookingEvent
+}
+
+func NewEventPublisher() *EventPublisher {
+	return &EventPublisher{}
+}
+
+func (p *EventPublisher) PublishAvailabilityEvent(ctx context.Context, event models.AvailabilityEvent) {
+	p.mu.Lock()
+	p.availabilityEvents = append(p.availabilityEvents, event)
+	p.mu.Unlock()
+}
+
+func (p *EventPublisher) PublishBookingEvent(ctx context.Context, event models.BookingEvent) {
+	p.mu.Lock()
+	p.bookingEvents = append(p.bookingEvents, event)
+	p.mu.Unlock()
+}
+
+func (p *EventPublisher) AvailabilityEvents() []models.AvailabilityEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]models.AvailabilityEvent(nil), p.availabilityEvents...)
+}
+
+func (p *EventPublisher) BookingEvents() []models.BookingEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]models.BookingEvent(nil), p.bookingEvents...)
+}
+
+// Bus is an in-memory message bus. Published messages are recorded per queue
+// and, when a handler is subscribed to the queue, delivered to it right away.
+type Bus struct {
+	failures
+
+	mu       sync.Mutex
+	messages map[string][][]byte
+	handlers map[string]func(ctx context.Context, body []byte) error
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		messages: make(map[string][][]byte),
+		handlers: make(map[string]func(ctx context.Context, body []byte) error),
+	}
+}
+
+// Subscribe delivers every message published to queue from now on to handler.
+// A handler error is returned to the publisher.
+func (b *Bus) Subscribe(queue string, handler func(ctx context.Context, body []byte) error) {
+	b.mu.Lock()
+	b.handlers[queue] = handler
+	b.mu.Unlock()
+}
+
+func (b *Bus) Publish(ctx context.Context, queue string, body []byte) error {
+	if err := b.fail("Publish"); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.messages[queue] = append(b.messages[queue], append([]byte(nil), body...))
+	handler := b.handlers[queue]
+	b.mu.Unlock()
+
+	if handler != nil {
+		return handler(ctx, body)
+	}
+	return nil
+}
+
+func (b *Bus) Ping(ctx context.Context) error {
+	return b.fail("Ping")
+}
+
+func (b *Bus) Messages(queue string) [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([][]byte(nil), b.messages[queue]...)
+}
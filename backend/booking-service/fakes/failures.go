@@ -0,0 +1,32 @@
+// Package fakes provides in-memory implementations of the repository, cache,
+// event, message bus and clock interfaces the services depend on, for tests.
+package fakes
+
+import "sync"
+
+// failures lets a test make a named operation fail until it is cleared.
+type failures struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+// FailOn makes every call of op (the method name, e.g. "CreateBooking") return
+// err. Passing a nil err makes op succeed again.
+func (f *failures) FailOn(op string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs == nil {
+		f.errs = make(map[string]error)
+	}
+	if err == nil {
+		delete(f.errs, op)
+		return
+	}
+	f.errs[op] = err
+}
+
+func (f *failures) fail(op string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.errs[op]
+}
@@ -0,0 +1,150 @@
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/booking-service/utils"
+)
+
+// SeatCache keeps the seat counters and the expiry schedule in maps. Like the
+// Redis keys, a counter that was never set reads as zero seats.
+type SeatCache struct {
+	failures
+
+	mu           sync.Mutex
+	concertSeats map[uint]int
+	classSeats   map[uint]int
+	expiries     map[string]time.Time
+}
+
+func NewSeatCache() *SeatCache {
+	return &SeatCache{
+		concertSeats: make(map[uint]int),
+		classSeats:   make(map[uint]int),
+		expiries:     make(map[string]time.Time),
+	}
+}
+
+func (c *SeatCache) SetAvailableSeats(ctx context.Context, concertID uint, availableSeats int) error {
+	if err := c.fail("SetAvailableSeats"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.concertSeats[concertID] = availableSeats
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *SeatCache) SetAvailableSeatsByClass(ctx context.Context, ticketClassID uint, availableSeats int) error {
+	if err := c.fail("SetAvailableSeatsByClass"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.classSeats[ticketClassID] = availableSeats
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *SeatCache) GetAvailableSeats(ctx context.Context, concertID uint) (int, error) {
+	if err := c.fail("GetAvailableSeats"); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.concertSeats[concertID], nil
+}
+
+func (c *SeatCache) GetAvailableSeatsByClass(ctx context.Context, ticketClassID uint) (int, error) {
+	if err := c.fail("GetAvailableSeatsByClass"); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.classSeats[ticketClassID], nil
+}
+
+func (c *SeatCache) DecreaseAvailableSeats(ctx context.Context, concertID, ticketClassID uint, numSeats int) (int64, error) {
+	if err := c.fail("DecreaseAvailableSeats"); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.classSeats[ticketClassID]
+	if n < numSeats {
+		return 0, fmt.Errorf("%w for class. Current: %d, Requested: %d", utils.ErrNotEnoughSeats, n, numSeats)
+	}
+	c.classSeats[ticketClassID] = n - numSeats
+	return int64(n - numSeats), nil
+}
+
+func (c *SeatCache) IncreaseAvailableSeats(ctx context.Context, concertID, ticketClassID uint, numSeats int) (int64, error) {
+	if err := c.fail("IncreaseAvailableSeats"); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.classSeats[ticketClassID] += numSeats
+	return int64(c.classSeats[ticketClassID]), nil
+}
+
+func (c *SeatCache) ScheduleBookingExpiry(ctx context.Context, bookingID string, expiresAt time.Time) error {
+	if err := c.fail("ScheduleBookingExpiry"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.expiries[bookingID] = expiresAt
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *SeatCache) UnscheduleBookingExpiry(ctx context.Context, bookingID string) error {
+	if err := c.fail("UnscheduleBookingExpiry"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	delete(c.expiries, bookingID)
+	c.mu.Unlock()
+	return nil
+}
+
+// ClaimDueBookingExpiries behaves like the Redis script: due entries are handed
+// out in expiry order and pushed back by claimTimeout instead of being removed.
+func (c *SeatCache) ClaimDueBookingExpiries(ctx context.Context, now time.Time, claimTimeout time.Duration, limit int) ([]string, error) {
+	if err := c.fail("ClaimDueBookingExpiries"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var due []string
+	for id, expiresAt := range c.expiries {
+		if !expiresAt.After(now) {
+			due = append(due, id)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if c.expiries[due[i]].Equal(c.expiries[due[j]]) {
+			return due[i] < due[j]
+		}
+		return c.expiries[due[i]].Before(c.expiries[due[j]])
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for _, id := range due {
+		c.expiries[id] = now.Add(claimTimeout)
+	}
+	return due, nil
+}
+
+// ScheduledExpiry reports when a booking is scheduled to expire.
+func (c *SeatCache) ScheduledExpiry(bookingID string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt, ok := c.expiries[bookingID]
+	return expiresAt, ok
+}
@@ -0,0 +1,682 @@
+package fakes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/repositories"
+	"backend/booking-service/utils"
+
+	"gorm.io/gorm"
+)
+
+// Store is an in-memory implementation of every booking-service repository and
+// of repositories.Transactor. Records are stored and returned as copies, so a
+// caller only changes the store through its methods. Transactions run one at a
+// time and are rolled back by restoring a snapshot; unlike a database, work
+// outside a transaction can see its uncommitted writes.
+type Store struct {
+	failures
+
+	txMu sync.Mutex
+	mu   sync.Mutex
+	data storeData
+}
+
+type storeData struct {
+	nextID        uint
+	concerts      map[uint]models.Concert
+	ticketClasses map[uint]models.TicketClass
+	seats         map[uint]models.Seat
+	bookings      map[string]models.Booking
+	bookingSeats  map[string][]uint
+	buyers        map[string]models.Buyer
+	ticketHolders map[string]models.TicketHolder
+	identities    []models.BookingIdentity
+	counters      map[purchaseCounterKey]int
+}
+
+type purchaseCounterKey struct {
+	concertID uint
+	kind      string
+	index     string
+}
+
+func NewStore() *Store {
+	return &Store{data: storeData{
+		concerts:      make(map[uint]models.Concert),
+		ticketClasses: make(map[uint]models.TicketClass),
+		seats:         make(map[uint]models.Seat),
+		bookings:      make(map[string]models.Booking),
+		bookingSeats:  make(map[string][]uint),
+		buyers:        make(map[string]models.Buyer),
+		ticketHolders: make(map[string]models.TicketHolder),
+		counters:      make(map[purchaseCounterKey]int),
+	}}
+}
+
+var (
+	_ repositories.BookingStore      = (*Store)(nil)
+	_ repositories.ConcertStore      = (*Store)(nil)
+	_ repositories.SeatStore         = (*Store)(nil)
+	_ repositories.TicketClassStore  = (*Store)(nil)
+	_ repositories.BuyerStore        = (*Store)(nil)
+	_ repositories.TicketHolderStore = (*Store)(nil)
+	_ repositories.Transactor        = (*Store)(nil)
+)
+
+// Stores returns the store as every repository of a unit of work.
+func (s *Store) Stores() repositories.Stores {
+	return repositories.Stores{
+		Bookings:      s,
+		Concerts:      s,
+		Seats:         s,
+		TicketClasses: s,
+		Buyers:        s,
+		TicketHolders: s,
+	}
+}
+
+func (s *Store) WithinTransaction(ctx context.Context, fn func(tx repositories.Stores) error) error {
+	if err := s.fail("WithinTransaction"); err != nil {
+		return err
+	}
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.data.clone()
+	s.mu.Unlock()
+
+	if err := fn(s.Stores()); err != nil {
+		s.mu.Lock()
+		s.data = snapshot
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (d *storeData) clone() storeData {
+	c := storeData{
+		nextID:        d.nextID,
+		concerts:      make(map[uint]models.Concert, len(d.concerts)),
+		ticketClasses: make(map[uint]models.TicketClass, len(d.ticketClasses)),
+		seats:         make(map[uint]models.Seat, len(d.seats)),
+		bookings:      make(map[string]models.Booking, len(d.bookings)),
+		bookingSeats:  make(map[string][]uint, len(d.bookingSeats)),
+		buyers:        make(map[string]models.Buyer, len(d.buyers)),
+		ticketHolders: make(map[string]models.TicketHolder, len(d.ticketHolders)),
+		identities:    append([]models.BookingIdentity(nil), d.identities...),
+		counters:      make(map[purchaseCounterKey]int, len(d.counters)),
+	}
+	for k, v := range d.concerts {
+		c.concerts[k] = v
+	}
+	for k, v := range d.ticketClasses {
+		c.ticketClasses[k] = v
+	}
+	for k, v := range d.seats {
+		c.seats[k] = v
+	}
+	for k, v := range d.bookings {
+		c.bookings[k] = v
+	}
+	for k, v := range d.bookingSeats {
+		c.bookingSeats[k] = append([]uint(nil), v...)
+	}
+	for k, v := range d.buyers {
+		c.buyers[k] = v
+	}
+	for k, v := range d.ticketHolders {
+		c.ticketHolders[k] = v
+	}
+	for k, v := range d.counters {
+		c.counters[k] = v
+	}
+	return c
+}
+
+func (s *Store) newID() uint {
+	s.data.nextID++
+	return s.data.nextID
+}
+
+func touch(model *gorm.Model, id uint) {
+	now := time.Now()
+	if model.ID == 0 {
+		model.ID = id
+	}
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+	}
+	model.UpdatedAt = now
+}
+
+// Bookings
+
+func (s *Store) CreateBooking(ctx context.Context, booking *models.Booking) error {
+	if err := s.fail("CreateBooking"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.data.bookings[booking.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	touch(&booking.Model, 0)
+	seatIDs := make([]uint, 0, len(booking.Seats))
+	for _, seat := range booking.Seats {
+		if seat != nil {
+			seatIDs = append(seatIDs, seat.ID)
+		}
+	}
+	s.data.bookings[booking.ID] = bookingRecord(booking)
+	s.data.bookingSeats[booking.ID] = seatIDs
+	return nil
+}
+
+func (s *Store) GetBookingByID(ctx context.Context, id string) (*models.Booking, error) {
+	if err := s.fail("GetBookingByID"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	booking, ok := s.data.bookings[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	s.loadBooking(&booking)
+	return &booking, nil
+}
+
+func (s *Store) UpdateBooking(ctx context.Context, booking *models.Booking) error {
+	if err := s.fail("UpdateBooking"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&booking.Model, 0)
+	s.data.bookings[booking.ID] = bookingRecord(booking)
+	return nil
+}
+
+func (s *Store) TransitionBookingStatus(ctx context.Context, id, fromStatus, toStatus string) (bool, error) {
+	if err := s.fail("TransitionBookingStatus"); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	booking, ok := s.data.bookings[id]
+	if !ok || booking.Status != fromStatus {
+		return false, nil
+	}
+	booking.Status = toStatus
+	booking.UpdatedAt = time.Now()
+	s.data.bookings[id] = booking
+	return true, nil
+}
+
+func (s *Store) MarkCheckedIn(ctx context.Context, id string, at time.Time) (bool, error) {
+	if err := s.fail("MarkCheckedIn"); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	booking, ok := s.data.bookings[id]
+	if !ok || booking.Status != models.BookingStatusConfirmed || booking.CheckedInAt != nil {
+		return false, nil
+	}
+	booking.CheckedInAt = &at
+	booking.UpdatedAt = time.Now()
+	s.data.bookings[id] = booking
+	return true, nil
+}
+
+// ClaimBookingIdentity indexes values in plaintext, except that phone numbers
+// are normalized and values other than KTP numbers prefixed with their kind,
+// as with the blind index.
+func (s *Store) ClaimBookingIdentity(ctx context.Context, identity *models.BookingIdentity, limit int) (bool, error) {
+	if err := s.fail("ClaimBookingIdentity"); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch identity.Kind {
+	case models.IdentityBuyerKTP, models.IdentityHolderKTP:
+		identity.ValueIndex = identity.Value
+	case models.IdentityPhone:
+		identity.ValueIndex = identity.Kind + ":" + utils.NormalizePhoneNumber(identity.Value)
+	default:
+		identity.ValueIndex = identity.Kind + ":" + identity.Value
+	}
+	key := purchaseCounterKey{concertID: identity.ConcertID, kind: identity.Kind, index: identity.ValueIndex}
+	if limit > 0 && s.data.counters[key]+identity.Tickets > limit {
+		return false, nil
+	}
+	s.data.counters[key] += identity.Tickets
+	identity.ID = s.newID()
+	identity.CreatedAt = time.Now()
+	s.data.identities = append(s.data.identities, *identity)
+	return true, nil
+}
+
+func (s *Store) ReleaseBookingIdentities(ctx context.Context, bookingID, kind string, at time.Time) error {
+	if err := s.fail("ReleaseBookingIdentities"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, identity := range s.data.identities {
+		if identity.BookingID != bookingID || identity.ReleasedAt != nil || (kind != "" && identity.Kind != kind) {
+			continue
+		}
+		s.data.counters[purchaseCounterKey{concertID: identity.ConcertID, kind: identity.Kind, index: identity.ValueIndex}] -= identity.Tickets
+		releasedAt := at
+		s.data.identities[i].ReleasedAt = &releasedAt
+	}
+	return nil
+}
+
+func (s *Store) GetBookingIdentities(ctx context.Context, concertID uint) ([]models.BookingIdentity, error) {
+	if err := s.fail("GetBookingIdentities"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var identities []models.BookingIdentity
+	for _, identity := range s.data.identities {
+		if concertID == 0 || identity.ConcertID == concertID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (s *Store) GetBookingsByUserID(ctx context.Context, userID uint) ([]models.Booking, error) {
+	if err := s.fail("GetBookingsByUserID"); err != nil {
+		return nil, err
+	}
+	return s.findBookings(true, func(b models.Booking) bool { return b.UserID == userID }), nil
+}
+
+// GetBookingsByKTP compares the numbers directly; the store keeps no blind index.
+func (s *Store) GetBookingsByKTP(ctx context.Context, ktpNumber string) ([]models.Booking, error) {
+	if err := s.fail("GetBookingsByKTP"); err != nil {
+		return nil, err
+	}
+	return s.findBookings(true, func(b models.Booking) bool {
+		buyer, hasBuyer := s.data.buyers[b.ID]
+		ticketHolder, hasTicketHolder := s.data.ticketHolders[b.ID]
+		return (hasBuyer && buyer.KTPNumber == ktpNumber) || (hasTicketHolder && ticketHolder.KTPNumber == ktpNumber)
+	}), nil
+}
+
+func (s *Store) GetUserActiveBookingsForConcert(ctx context.Context, userID, concertID uint) ([]models.Booking, error) {
+	if err := s.fail("GetUserActiveBookingsForConcert"); err != nil {
+		return nil, err
+	}
+	return s.findBookings(false, func(b models.Booking) bool {
+		return b.UserID == userID && b.ConcertID == concertID &&
+			(b.Status == models.BookingStatusPending || b.Status == models.BookingStatusConfirmed)
+	}), nil
+}
+
+func (s *Store) GetExpiredPendingBookings(ctx context.Context, now time.Time) ([]models.Booking, error) {
+	if err := s.fail("GetExpiredPendingBookings"); err != nil {
+		return nil, err
+	}
+	bookings := s.findBookings(false, func(b models.Booking) bool {
+		return b.Status == models.BookingStatusPending && b.ExpiresAt != nil && b.ExpiresAt.Before(now)
+	})
+	s.mu.Lock()
+	for i := range bookings {
+		bookings[i].Seats = s.bookingSeatsOf(bookings[i].ID)
+	}
+	s.mu.Unlock()
+	return bookings, nil
+}
+
+func (s *Store) GetPendingBookingsWithExpiry(ctx context.Context) ([]models.Booking, error) {
+	if err := s.fail("GetPendingBookingsWithExpiry"); err != nil {
+		return nil, err
+	}
+	return s.findBookings(false, func(b models.Booking) bool {
+		return b.Status == models.BookingStatusPending && b.ExpiresAt != nil
+	}), nil
+}
+
+func (s *Store) findBookings(preload bool, match func(models.Booking) bool) []models.Booking {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var bookings []models.Booking
+	for _, booking := range s.data.bookings {
+		if match(booking) {
+			if preload {
+				s.loadBooking(&booking)
+			}
+			bookings = append(bookings, booking)
+		}
+	}
+	sort.Slice(bookings, func(i, j int) bool { return bookings[i].ID < bookings[j].ID })
+	return bookings
+}
+
+// loadBooking fills in the associations the GORM repository preloads.
+func (s *Store) loadBooking(booking *models.Booking) {
+	if concert, ok := s.data.concerts[booking.ConcertID]; ok {
+		booking.Concert = concert
+	}
+	booking.Seats = s.bookingSeatsOf(booking.ID)
+	if buyer, ok := s.data.buyers[booking.ID]; ok {
+		booking.Buyer = &buyer
+	}
+	if ticketHolder, ok := s.data.ticketHolders[booking.ID]; ok {
+		booking.TicketHolder = &ticketHolder
+	}
+}
+
+func (s *Store) bookingSeatsOf(bookingID string) []*models.Seat {
+	var seats []*models.Seat
+	for _, seatID := range s.data.bookingSeats[bookingID] {
+		if seat, ok := s.data.seats[seatID]; ok {
+			seats = append(seats, &seat)
+		}
+	}
+	return seats
+}
+
+// bookingRecord strips the associations, which are stored separately.
+func bookingRecord(booking *models.Booking) models.Booking {
+	record := *booking
+	record.Concert = models.Concert{}
+	record.Seats = nil
+	record.Buyer = nil
+	record.TicketHolder = nil
+	if booking.ExpiresAt != nil {
+		expiresAt := *booking.ExpiresAt
+		record.ExpiresAt = &expiresAt
+	}
+	if booking.PaymentID != nil {
+		paymentID := *booking.PaymentID
+		record.PaymentID = &paymentID
+	}
+	if booking.CheckedInAt != nil {
+		checkedInAt := *booking.CheckedInAt
+		record.CheckedInAt = &checkedInAt
+	}
+	return record
+}
+
+// Concerts
+
+// CreateConcert stores the concert and, like GORM, its ticket classes, filling
+// in the IDs of both.
+func (s *Store) CreateConcert(ctx context.Context, concert *models.Concert) error {
+	if err := s.fail("CreateConcert"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&concert.Model, s.newID())
+	for i := range concert.TicketClasses {
+		tc := &concert.TicketClasses[i]
+		touch(&tc.Model, s.newID())
+		tc.ConcertID = concert.ID
+		s.data.ticketClasses[tc.ID] = *tc
+	}
+	record := *concert
+	record.TicketClasses = nil
+	s.data.concerts[concert.ID] = record
+	return nil
+}
+
+func (s *Store) GetConcerts(ctx context.Context) ([]models.Concert, error) {
+	if err := s.fail("GetConcerts"); err != nil {
+		return nil, err
+	}
+	return s.findConcerts(func(models.Concert) bool { return true }), nil
+}
+
+func (s *Store) GetConcertByID(ctx context.Context, id uint) (*models.Concert, error) {
+	if err := s.fail("GetConcertByID"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	concert, ok := s.data.concerts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	concert.TicketClasses = s.ticketClassesOf(id)
+	return &concert, nil
+}
+
+func (s *Store) UpdateConcert(ctx context.Context, concert *models.Concert) error {
+	if err := s.fail("UpdateConcert"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&concert.Model, s.newID())
+	record := *concert
+	record.TicketClasses = nil
+	s.data.concerts[concert.ID] = record
+	return nil
+}
+
+func (s *Store) UpdateConcertStatus(ctx context.Context, id uint, status string) error {
+	if err := s.fail("UpdateConcertStatus"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if concert, ok := s.data.concerts[id]; ok {
+		concert.Status = status
+		s.data.concerts[id] = concert
+	}
+	return nil
+}
+
+func (s *Store) GetConcertsByStatus(ctx context.Context, status string) ([]models.Concert, error) {
+	if err := s.fail("GetConcertsByStatus"); err != nil {
+		return nil, err
+	}
+	return s.findConcerts(func(c models.Concert) bool { return c.Status == status }), nil
+}
+
+func (s *Store) findConcerts(match func(models.Concert) bool) []models.Concert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var concerts []models.Concert
+	for _, concert := range s.data.concerts {
+		if match(concert) {
+			concert.TicketClasses = s.ticketClassesOf(concert.ID)
+			concerts = append(concerts, concert)
+		}
+	}
+	sort.Slice(concerts, func(i, j int) bool { return concerts[i].ID < concerts[j].ID })
+	return concerts
+}
+
+func (s *Store) ticketClassesOf(concertID uint) []models.TicketClass {
+	var ticketClasses []models.TicketClass
+	for _, tc := range s.data.ticketClasses {
+		if tc.ConcertID == concertID {
+			ticketClasses = append(ticketClasses, tc)
+		}
+	}
+	sort.Slice(ticketClasses, func(i, j int) bool { return ticketClasses[i].ID < ticketClasses[j].ID })
+	return ticketClasses
+}
+
+// Seats
+
+func (s *Store) CreateSeats(ctx context.Context, seats []models.Seat) error {
+	if err := s.fail("CreateSeats"); err != nil {
+		return err
+	}
+	s.createSeats(seats)
+	return nil
+}
+
+func (s *Store) CreateSeatsInBatches(ctx context.Context, seats []models.Seat, batchSize int) error {
+	if err := s.fail("CreateSeatsInBatches"); err != nil {
+		return err
+	}
+	s.createSeats(seats)
+	return nil
+}
+
+func (s *Store) createSeats(seats []models.Seat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range seats {
+		touch(&seats[i].Model, s.newID())
+		s.data.seats[seats[i].ID] = seats[i]
+	}
+}
+
+func (s *Store) GetSeatsByConcertID(ctx context.Context, concertID uint) ([]models.Seat, error) {
+	if err := s.fail("GetSeatsByConcertID"); err != nil {
+		return nil, err
+	}
+	return s.findSeats(func(seat models.Seat) bool { return seat.ConcertID == concertID }), nil
+}
+
+func (s *Store) GetSeatsByTicketClassID(ctx context.Context, ticketClassID uint) ([]models.Seat, error) {
+	if err := s.fail("GetSeatsByTicketClassID"); err != nil {
+		return nil, err
+	}
+	return s.findSeats(func(seat models.Seat) bool { return seat.TicketClassID == ticketClassID }), nil
+}
+
+func (s *Store) UpdateSeats(ctx context.Context, seats []*models.Seat) error {
+	if err := s.fail("UpdateSeats"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, seat := range seats {
+		touch(&seat.Model, s.newID())
+		s.data.seats[seat.ID] = *seat
+	}
+	return nil
+}
+
+func (s *Store) findSeats(match func(models.Seat) bool) []models.Seat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var seats []models.Seat
+	for _, seat := range s.data.seats {
+		if match(seat) {
+			seats = append(seats, seat)
+		}
+	}
+	sort.Slice(seats, func(i, j int) bool { return seats[i].ID < seats[j].ID })
+	return seats
+}
+
+// Ticket classes
+
+func (s *Store) GetTicketClassByID(ctx context.Context, id uint) (*models.TicketClass, error) {
+	if err := s.fail("GetTicketClassByID"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ticketClass, ok := s.data.ticketClasses[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &ticketClass, nil
+}
+
+func (s *Store) UpdateTicketClass(ctx context.Context, ticketClass *models.TicketClass) error {
+	if err := s.fail("UpdateTicketClass"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&ticketClass.Model, s.newID())
+	s.data.ticketClasses[ticketClass.ID] = *ticketClass
+	return nil
+}
+
+// Buyers and ticket holders.
+
+func (s *Store) CreateBuyer(ctx context.Context, buyer *models.Buyer) error {
+	if err := s.fail("CreateBuyer"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&buyer.Model, s.newID())
+	s.data.buyers[buyer.BookingID] = *buyer
+	return nil
+}
+
+func (s *Store) CreateTicketHolder(ctx context.Context, ticketHolder *models.TicketHolder) error {
+	if err := s.fail("CreateTicketHolder"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&ticketHolder.Model, s.newID())
+	s.data.ticketHolders[ticketHolder.BookingID] = *ticketHolder
+	return nil
+}
+
+// Inspection helpers for tests. They ignore injected failures.
+
+func (s *Store) Booking(id string) (models.Booking, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	booking, ok := s.data.bookings[id]
+	if ok {
+		s.loadBooking(&booking)
+	}
+	return booking, ok
+}
+
+func (s *Store) Concert(id uint) (models.Concert, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	concert, ok := s.data.concerts[id]
+	if ok {
+		concert.TicketClasses = s.ticketClassesOf(id)
+	}
+	return concert, ok
+}
+
+func (s *Store) TicketClass(id uint) (models.TicketClass, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ticketClass, ok := s.data.ticketClasses[id]
+	return ticketClass, ok
+}
+
+func (s *Store) Seat(id uint) (models.Seat, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seat, ok := s.data.seats[id]
+	return seat, ok
+}
+
+// PurchaseCount reports the tickets of a concert counted against an
+// identifier, indexed as by ClaimBookingIdentity.
+func (s *Store) PurchaseCount(concertID uint, kind, index string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.counters[purchaseCounterKey{concertID: concertID, kind: kind, index: index}]
+}
+
+// BookingCount reports how many bookings are stored.
+func (s *Store) BookingCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.data.bookings)
+}
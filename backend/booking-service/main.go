@@ -2,29 +2,19 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"backend/booking-service/config"
-	"backend/booking-service/controllers"
 	"backend/booking-service/database"
-	"backend/booking-service/middlewares"
-	"backend/booking-service/models"
-
-	"backend/booking-service/repositories"
-	"backend/booking-service/services"
+	"backend/booking-service/server"
 	"backend/booking-service/utils"
 
 	_ "backend/booking-service/docs"
-
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // @title Concert Ticket Booking System - Booking Service API
@@ -47,164 +37,173 @@ import (
 // @description "Type 'Bearer' followed by a space and JWT token."
 func main() {
 	cfg := config.LoadConfig()
+	if err := utils.InitLogger(os.Stdout, cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
+	}
 	utils.InitJWT(cfg)
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "pii" {
+		if err := runPII(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("pii: %v", err)
+		}
+		return
+	}
+	if err := utils.InitPII(cfg); err != nil {
+		log.Fatalf("Failed to initialize PII encryption: %v", err)
+	}
+	if err := utils.InitNIKRegions(cfg); err != nil {
+		log.Fatalf("Failed to load NIK region table: %v", err)
+	}
+	if err := utils.InitInternalAuth(cfg); err != nil {
+		log.Fatalf("Failed to load internal service keys: %v", err)
+	}
+
+	shutdownTracing, err := utils.InitTracing(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			utils.LogWarning("Failed to flush traces: %v", err)
+		}
+	}()
+
 	database.ConnectDB(cfg)
+	defer database.CloseDB()
+	if cfg.MigrateOnStart {
+		if err := database.Migrate(context.Background(), database.DB); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
 
 	if err := utils.InitRedis(cfg.RedisAddr, cfg.RedisPass, cfg.RedisDB); err != nil {
 		log.Fatalf("Failed to initialize Redis for rate limiting: %v", err)
 	}
 	defer utils.CloseRedisConnection()
 
-	if err := utils.InitRabbitMQ(cfg.RabbitMQURL); err != nil {
+	queuePolicies := map[string]config.QueueConfig{
+		utils.SeatCreationQueueName:        cfg.QueueConfig(utils.SeatCreationQueueName),
+		utils.BookingCancellationQueueName: cfg.QueueConfig(utils.BookingCancellationQueueName),
+	}
+	if err := utils.InitRabbitMQ(cfg.RabbitMQURL, queuePolicies); err != nil {
 		log.Fatalf("Failed to initialize RabbitMQ: %v", err)
 	}
 	defer utils.CloseRabbitMQConnection()
-	concertRepo := repositories.NewConcertRepository(database.DB)
-	seatRepo := repositories.NewSeatRepository(database.DB)
-	bookingRepo := repositories.NewBookingRepository(database.DB)
-	ticketClassRepo := repositories.NewTicketClassRepository(database.DB)
-	buyerRepo := repositories.NewBuyerRepository(database.DB)
-	ticketHolderRepo := repositories.NewTicketHolderRepository(database.DB)
 
-	concertService := services.NewConcertService(concertRepo, seatRepo, ticketClassRepo)
+	srv := server.New(cfg, server.Dependencies{
+		DB:     database.DB,
+		Cache:  utils.RedisSeatCache{},
+		Events: utils.RedisEventPublisher{},
+		Bus:    utils.RabbitMQBus{},
+		Clock:  utils.SystemClock{},
+	})
+	concertService := srv.ConcertService
+	bookingService := srv.BookingService
+	leaderElector := srv.LeaderElector
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Everything below stops when ctx is cancelled. workers tracks it, so
+	// shutdown waits for in-flight requests, messages and expiries to finish.
+	var workers sync.WaitGroup
+	goWorker := func(name string, run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+			utils.LogInfo("%s stopped.", name)
+		}()
+	}
 
-	bookingService := services.NewBookingService(bookingRepo, concertRepo, seatRepo, ticketClassRepo, buyerRepo, ticketHolderRepo, cfg.PaymentServiceAPIURL)
+	goWorker("HTTP server", func(ctx context.Context) {
+		utils.LogInfo("Booking Service running on port %s", cfg.ServicePort)
+		if err := srv.ListenAndServe(ctx, ":"+cfg.ServicePort, cfg.ShutdownTimeout); err != nil {
+			utils.LogError("Booking Service HTTP server failed: %v", err)
+			stop()
+		}
+	})
 
-	go func() {
-		msgs, err := utils.ConsumeMessages(utils.SeatCreationQueue())
-		if err != nil {
+	goWorker("Availability hub", srv.AvailabilityHub.Run)
+	goWorker("Booking event hub", srv.BookingEventHub.Run)
+
+	goWorker("Seat creation consumer", func(ctx context.Context) {
+		if err := utils.ConsumeWithRetry(ctx, utils.SeatCreationQueue(), concertService.ProcessSeatCreationMessage); err != nil {
 			utils.LogError("Failed to start consuming from seat creation queue: %v", err)
-			return
-		}
-		for d := range msgs {
-			if err := concertService.ProcessSeatCreationMessage(d.Body); err != nil {
-				utils.LogError("Error processing seat creation message: %v", err)
-			}
 		}
-	}()
-
-	go func() {
-		msgs, err := utils.ConsumeMessages(utils.BookingCancellationQueue())
+	})
+
+	goWorker("Booking cancellation consumer", func(ctx context.Context) {
+		err := utils.ConsumeWithRetry(ctx, utils.BookingCancellationQueue(), func(ctx context.Context, body []byte) error {
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			return bookingService.ProcessBookingCancellationMessage(ctx, body)
+		})
 		if err != nil {
 			utils.LogError("Failed to start consuming from booking cancellation queue: %v", err)
-			return
-		}
-		for d := range msgs {
-			utils.LogInfo("Received a message on booking_cancellation_queue: %s", d.Body)
-
 		}
-	}()
-
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+	})
+
+	// Scheduled jobs only run on the replica holding the leader lease.
+	leaderElector.OnElected(func(context.Context) {
+		leaderElector.RunAsLeader("republish-pending-seat-creation", 5*time.Minute, concertService.RepublishPendingSeatCreations)
+		leaderElector.RunAsLeader("schedule-pending-booking-expiries", 5*time.Minute, bookingService.SchedulePendingBookingExpiries)
+	})
+	goWorker("Leader election", func(ctx context.Context) {
+		leaderElector.Run(ctx)
+		// Hand the lease over right away instead of letting it expire.
+		resignCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		leaderElector.Resign(resignCtx)
+	})
+
+	goWorker("Booking expiry worker", bookingService.RunExpiryWorker)
+
+	goWorker("Expired booking sweeper", func(ctx context.Context) {
+		ticker := time.NewTicker(cfg.ExpirySweepInterval)
 		defer ticker.Stop()
-		for range ticker.C {
-			utils.LogInfo("Running scheduled task: Checking for expired pending bookings")
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-			err := bookingService.CancelExpiredPendingBookings(ctx)
-			if err != nil {
-				utils.LogError("Error during automatic booking cancellation: %v", err)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
 			}
-			cancel()
-		}
-	}()
-
-	go func() {
-
-		time.Sleep(5 * time.Second)
-		utils.LogInfo("Checking for concerts with 'pending_seat_creation' status on startup...")
-
-		pendingConcerts, err := concertRepo.GetConcertsByStatus(models.ConcertStatusPendingSeatCreation)
-		if err != nil {
-			utils.LogError("Failed to get pending_seat_creation concerts on startup: %v", err)
-			return
-		}
-
-		for _, c := range pendingConcerts {
-			utils.LogInfo("Found pending_seat_creation concert (ID: %d, Name: %s). Attempting to trigger seat creation message...", c.ID, c.Name)
-
-			var tcMessages []models.TicketClassMessage
-			for _, tc := range c.TicketClasses {
-				tcMessages = append(tcMessages, models.TicketClassMessage{
-					TicketClassID:     tc.ID,
-					Name:              tc.Name,
-					TotalSeatsInClass: tc.TotalSeatsInClass,
-				})
-			}
-			msg := models.SeatCreationMessage{
-				ConcertID:     c.ID,
-				TotalSeats:    c.TotalSeats,
-				TicketClasses: tcMessages,
-			}
-			msgBody, err := json.Marshal(msg)
-			if err != nil {
-				utils.LogError("Failed to marshal seat creation message for concert %d on startup: %v", c.ID, err)
-				continue
-			}
-
-			if err := utils.PublishMessage("", utils.SeatCreationQueue(), msgBody); err != nil {
-				utils.LogError("Failed to publish seat creation message for concert %d on startup to RabbitMQ: %v", c.ID, err)
-
-				continue
-			}
-			utils.LogInfo("Successfully published seat creation message for concert %d on startup", c.ID)
-		}
-	}()
-
-	concertController := controllers.NewConcertController(concertService)
-	bookingController := controllers.NewBookingController(bookingService)
-
-	router := gin.Default()
-	router.RedirectTrailingSlash = false
-
-	router.Use(middlewares.CORSMiddleware())
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	router.Use(middlewares.RateLimitMiddleware(100, 1*time.Minute))
-
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.NewHandler()))
-
-	v1 := router.Group("/api/v1")
-	{
-
-		v1.GET("/concerts", concertController.GetConcerts)
-		v1.GET("/concerts/:id", concertController.GetConcertByID)
-		v1.GET("/concerts/:id/seats", concertController.GetConcertSeats)
-
-		adminConcerts := v1.Group("/admin/concerts")
-		adminConcerts.Use(middlewares.AuthMiddleware())
-		adminConcerts.Use(middlewares.AdminAuthMiddleware())
-		{
-			adminConcerts.POST("/", concertController.CreateConcert)
-		}
-
-		bookings := v1.Group("/bookings")
-		bookings.Use(middlewares.AuthMiddleware())
-		{
-			bookings.POST("/", bookingController.CreateBooking)
-			bookings.GET("/my", bookingController.GetMyBookings)
-			bookings.GET("/:id", bookingController.GetBookingByID)
-			bookings.PUT("/:id/cancel", bookingController.CancelBooking)
+			leaderElector.RunAsLeader("cancel-expired-bookings", 5*time.Minute, func(ctx context.Context) error {
+				utils.LogInfo("Running scheduled task: Sweeping for expired pending bookings")
+				return bookingService.CancelExpiredPendingBookings(ctx)
+			})
 		}
+	})
 
-		v1.PUT("/internal/bookings/:id/status", bookingController.UpdateBookingStatusInternal)
+	<-ctx.Done()
+	utils.LogInfo("Shutting down Booking Service gracefully (deadline %s)...", cfg.ShutdownTimeout)
+	if !waitWithTimeout(&workers, cfg.ShutdownTimeout) {
+		utils.LogWarning("Shutdown deadline of %s passed with work still running; stopping anyway.", cfg.ShutdownTimeout)
 	}
+	// The deferred calls close RabbitMQ (requeueing any unacknowledged
+	// delivery), Redis and the database, and flush traces.
+	utils.LogInfo("Booking Service stopped.")
+}
 
+// waitWithTimeout reports whether wg finished within timeout.
+func waitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
 	go func() {
-		port := os.Getenv("PORT")
-		if port == "" {
-			port = "8081"
-		}
-		log.Printf("Booking Service running on port %s", port)
-		if err := router.Run(fmt.Sprintf(":%s", port)); err != nil {
-			log.Fatalf("Booking Service failed to start: %v", err)
-		}
+		wg.Wait()
+		close(done)
 	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutting down Booking Service gracefully...")
-	log.Println("Booking Service stopped.")
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
 }
@@ -0,0 +1,266 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// This file is the same in the booking and payment services, apart from the
+// imports and the Redis client. Make any fix in both copies.
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// idempotencyLockTTL is how long the in-progress marker outlives a request
+	// that died holding it. A running request renews it every third of that.
+	idempotencyLockTTL        = 30 * time.Second
+	idempotencyWaitTimeout    = 30 * time.Second
+	idempotencyPollInterval   = 100 * time.Millisecond
+	idempotencyStatusProgress = "in_progress"
+	idempotencyStatusDone     = "completed"
+)
+
+type idempotencyRecord struct {
+	Fingerprint string    `json:"fingerprint"`
+	Status      string    `json:"status"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes a POST safe to retry when the client sends an
+// Idempotency-Key header. The first request with a key stores its response for
+// ttl; a retry with the same payload gets that response back, a retry with a
+// different payload is rejected with 422, and a retry that arrives while the
+// first request is still running waits for it. Server errors are not stored, so
+// a request that failed on our side can be retried with the same key.
+// Keys are scoped per user and route and must run after AuthMiddleware. The
+// payload is compared as canonical JSON, so a retry that orders or spaces its
+// fields differently still matches.
+func IdempotencyMiddleware(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(c.Request.Method, c.FullPath(), body)
+		redisKey := fmt.Sprintf("idempotency:%s:%s:%d:%s", c.Request.Method, c.FullPath(), c.GetUint("userID"), key)
+		marker, err := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      idempotencyStatusProgress,
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			utils.LogError("Failed to marshal idempotency marker for key %s: %v", key, err)
+			utils.AbortWithProblem(c, http.StatusInternalServerError, models.CodeInternalError, "internal server error")
+			return
+		}
+
+		deadline := time.Now().Add(idempotencyWaitTimeout)
+		for {
+			acquired, record, err := acquireIdempotencyKey(c.Request.Context(), redisKey, marker)
+			if err != nil {
+				utils.LogError("Idempotency store error for key %s: %v", key, err)
+				utils.AbortWithProblem(c, http.StatusInternalServerError, models.CodeInternalError, "internal server error")
+				return
+			}
+			if acquired {
+				break
+			}
+			if record != nil {
+				if record.Fingerprint != fingerprint {
+					utils.LogWarning("Idempotency-Key %s reused with a different payload on %s", key, c.FullPath())
+					utils.AbortWithProblem(c, http.StatusUnprocessableEntity, models.CodeIdempotencyKeyReuse, "Idempotency-Key was already used with a different request payload")
+					return
+				}
+				if record.Status == idempotencyStatusDone {
+					c.Header(IdempotentReplayedHeader, "true")
+					c.Data(record.StatusCode, record.ContentType, record.Body)
+					c.Abort()
+					return
+				}
+			}
+			if time.Now().After(deadline) {
+				utils.AbortWithProblem(c, http.StatusConflict, models.CodeIdempotencyInFlight, "A request with this Idempotency-Key is still being processed")
+				return
+			}
+			select {
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		stopRenewing := keepIdempotencyLock(redisKey, key, marker)
+		c.Next()
+		stopRenewing()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), 2*time.Second)
+		defer cancel()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := utils.RedisClient.Del(ctx, redisKey).Err(); err != nil {
+				utils.LogErrorContext(ctx, "Failed to release Idempotency-Key %s after a server error: %v", key, err)
+			}
+			return
+		}
+
+		record := idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      idempotencyStatusDone,
+			StatusCode:  status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+			CreatedAt:   time.Now(),
+		}
+		payload, err := json.Marshal(record)
+		if err != nil {
+			utils.LogErrorContext(ctx, "Failed to marshal idempotency record for key %s: %v", key, err)
+			return
+		}
+		if err := utils.RedisClient.Set(ctx, redisKey, payload, ttl).Err(); err != nil {
+			utils.LogErrorContext(ctx, "Failed to store response for Idempotency-Key %s: %v", key, err)
+		}
+	}
+}
+
+// requestFingerprint hashes the method, route and body of a request. A JSON
+// body is hashed in canonical form, with object keys sorted and no whitespace;
+// anything else is hashed as sent.
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(canonicalJSON(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalJSON re-encodes a JSON document with sorted object keys and no
+// insignificant whitespace. Numbers keep their literal text. A body that is
+// not a single JSON value is returned unchanged.
+func canonicalJSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return canonical
+}
+
+// renewIdempotencyLockScript extends the in-progress marker KEYS[1] only while
+// it is still ARGV[1], so it never extends a stored response or the marker of
+// another request.
+var renewIdempotencyLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// keepIdempotencyLock renews the in-progress marker every third of its TTL
+// until the returned stop function is called, so a slow request keeps its key
+// however long it runs.
+func keepIdempotencyLock(redisKey, key string, marker []byte) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(idempotencyLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := renewIdempotencyLockScript.Run(ctx, utils.RedisClient, []string{redisKey}, marker, idempotencyLockTTL.Milliseconds()).Err()
+				if err != nil && ctx.Err() == nil {
+					utils.LogWarning("Failed to renew the lock of Idempotency-Key %s: %v", key, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// acquireIdempotencyKey claims the key with an in-progress marker. When somebody
+// else holds it, the stored record is returned instead. The marker expires on its
+// own if the request holding it dies.
+func acquireIdempotencyKey(ctx context.Context, redisKey string, marker []byte) (bool, *idempotencyRecord, error) {
+	acquired, err := utils.RedisClient.SetNX(ctx, redisKey, marker, idempotencyLockTTL).Result()
+	if err != nil {
+		return false, nil, err
+	}
+	if acquired {
+		return true, nil, nil
+	}
+
+	stored, err := utils.RedisClient.Get(ctx, redisKey).Bytes()
+	if err == redis.Nil {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	var record idempotencyRecord
+	if err := json.Unmarshal(stored, &record); err != nil {
+		return false, nil, err
+	}
+	return false, &record, nil
+}
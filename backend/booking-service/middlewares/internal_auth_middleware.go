@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxInternalBodyBytes   = 1 << 20
+	minInternalNonceLength = 16
+	maxInternalNonceLength = 64
+)
+
+// InternalAuthMiddleware lets through only requests signed by one of
+// services, as described in utils/internal_auth.go. A request must be signed
+// within maxSkew of now, and each nonce is accepted once: it is remembered in
+// Redis for twice maxSkew, past which its timestamp is refused anyway. The
+// calling service is set as "service" in the context.
+func InternalAuthMiddleware(maxSkew time.Duration, services ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		service := c.GetHeader(utils.ServiceNameHeader)
+		keyID := c.GetHeader(utils.ServiceKeyIDHeader)
+		timestamp := c.GetHeader(utils.ServiceTimestampHeader)
+		nonce := c.GetHeader(utils.ServiceNonceHeader)
+		signature := c.GetHeader(utils.ServiceSignatureHeader)
+		if service == "" || keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+			utils.LogWarningContext(ctx, "Unsigned request to internal route %s from %s", c.FullPath(), c.ClientIP())
+			utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized: internal routes require a service signature")
+			return
+		}
+
+		key, ok := utils.InternalServiceKey(service, keyID)
+		if !ok {
+			utils.LogWarningContext(ctx, "Request to internal route %s signed with unknown key %s:%s", c.FullPath(), service, keyID)
+			utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized: unknown service key")
+			return
+		}
+		signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized: invalid request timestamp")
+			return
+		}
+		if skew := time.Since(time.Unix(signedAt, 0)); skew > maxSkew || skew < -maxSkew {
+			utils.LogWarningContext(ctx, "Request to internal route %s from %s signed %s ago, outside the allowed %s", c.FullPath(), service, skew.Round(time.Second), maxSkew)
+			utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized: request timestamp is too old or in the future")
+			return
+		}
+		if len(nonce) < minInternalNonceLength || len(nonce) > maxInternalNonceLength {
+			utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized: invalid request nonce")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxInternalBodyBytes))
+		if err != nil {
+			utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		expected := utils.InternalSignature(key, c.Request.Method, c.Request.URL.RequestURI(), timestamp, nonce, body)
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			utils.LogWarningContext(ctx, "Request to internal route %s with an invalid signature from key %s:%s", c.FullPath(), service, keyID)
+			utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized: invalid service signature")
+			return
+		}
+
+		if !slices.Contains(services, service) {
+			utils.LogWarningContext(ctx, "Service %s is not allowed to call internal route %s", service, c.FullPath())
+			utils.AbortWithProblem(c, http.StatusForbidden, models.CodeForbidden, "Forbidden: service may not call this route")
+			return
+		}
+
+		// Only checked once the signature holds, so unsigned requests cannot
+		// use up nonces.
+		nonceCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		fresh, err := utils.RedisClient.SetNX(nonceCtx, "internal_nonce:"+service+":"+nonce, 1, 2*maxSkew).Result()
+		if err != nil {
+			utils.LogErrorContext(ctx, "Redis error checking the nonce of a request from %s: %v", service, err)
+			c.Header("Retry-After", "1")
+			utils.AbortWithProblem(c, http.StatusServiceUnavailable, models.CodeInternalError, "Cannot check the request for replays, please retry")
+			return
+		}
+		if !fresh {
+			utils.LogWarningContext(ctx, "Replayed request to internal route %s from %s with nonce %s", c.FullPath(), service, nonce)
+			utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized: request was already received")
+			return
+		}
+
+		c.Set("service", service)
+		c.Next()
+	}
+}
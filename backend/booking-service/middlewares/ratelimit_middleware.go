@@ -4,52 +4,144 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"backend/booking-service/models"
 	"backend/booking-service/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
-func RateLimitMiddleware(maxRequests int, window time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-		key := fmt.Sprintf("rate_limit:%s", clientIP)
+const (
+	rateLimitTimeout      = time.Second
+	rateLimitRemainingKey = "rateLimitRemaining"
+)
 
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
+// RateLimitPolicy limits a group of routes with a token bucket per client: a
+// bucket of Requests tokens that refills evenly over Per. Policies with
+// different names have separate buckets. FailOpen lets requests through when
+// Redis cannot be reached instead of refusing them with 503.
+type RateLimitPolicy struct {
+	Name     string
+	Requests int
+	Per      time.Duration
+	FailOpen bool
+}
 
-		count, err := utils.RedisClient.Get(ctx, key).Int()
-		if err != nil && err != redis.Nil {
-			utils.LogError("Redis error in rate limit middleware for IP %s: %v", clientIP, err)
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
-			return
+// rateLimitScript takes a token from each of the buckets in KEYS, which hold
+// ARGV[1] tokens and gain one every ARGV[2] milliseconds, or from none of them
+// if any is empty. The time is Redis's own, so servers with skewed clocks
+// share buckets fairly. It returns whether the tokens were taken, the whole
+// tokens left in the emptiest bucket, and the milliseconds until every bucket
+// has a token again and until they are all full. Reading and updating the
+// buckets in one script keeps concurrent requests from spending the same
+// token.
+var rateLimitScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local buckets = {}
+local allowed = 1
+for i, key in ipairs(KEYS) do
+	local bucket = redis.call('HMGET', key, 'tokens', 'at')
+	local tokens = tonumber(bucket[1])
+	local at = tonumber(bucket[2])
+	if tokens == nil or at == nil then
+		tokens = capacity
+		at = now
+	elseif now > at then
+		tokens = math.min(capacity, tokens + (now - at) / interval)
+		at = now
+	end
+	if tokens < 1 then
+		allowed = 0
+	end
+	buckets[i] = {tokens, at}
+end
+local remaining = capacity
+local retry = 0
+local full = 0
+for i, key in ipairs(KEYS) do
+	local tokens, at = buckets[i][1], buckets[i][2]
+	if allowed == 1 then
+		tokens = tokens - 1
+	elseif tokens < 1 then
+		retry = math.max(retry, math.ceil((1 - tokens) * interval))
+	end
+	local refill = math.ceil((capacity - tokens) * interval)
+	redis.call('HSET', key, 'tokens', tostring(tokens), 'at', tostring(at))
+	redis.call('PEXPIRE', key, math.max(refill, 1))
+	remaining = math.min(remaining, math.floor(tokens))
+	full = math.max(full, refill)
+end
+return {allowed, remaining, retry, full}
+`)
+
+// RateLimitMiddleware applies policy to each request with a bucket per client
+// IP and, when AuthMiddleware has run before it, a bucket per user as well; a
+// request is refused if either is empty, so neither many accounts behind one
+// address nor one account behind many addresses gets past it. Responses carry
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers for the
+// policy with the fewest requests left, and refused requests a Retry-After.
+func RateLimitMiddleware(policy RateLimitPolicy) gin.HandlerFunc {
+	if policy.Requests <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	interval := float64(policy.Per.Milliseconds()) / float64(policy.Requests)
+	limitPolicy := fmt.Sprintf("%d;w=%d", policy.Requests, int64(policy.Per.Seconds()))
+
+	return func(c *gin.Context) {
+		clients := []string{"ip:" + c.ClientIP()}
+		if userID, ok := c.Get("userID"); ok {
+			clients = append([]string{fmt.Sprintf("user:%v", userID)}, clients...)
 		}
+		keys := make([]string, len(clients))
+		for i, client := range clients {
+			keys[i] = "rate_limit:" + policy.Name + ":" + client
+		}
+		client := strings.Join(clients, " ")
 
-		if err == redis.Nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), rateLimitTimeout)
+		defer cancel()
 
-			_, err = utils.RedisClient.Set(ctx, key, 1, window).Result()
-			if err != nil {
-				utils.LogError("Redis error setting rate limit for IP %s: %v", clientIP, err)
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		result, err := rateLimitScript.Run(ctx, utils.RedisClient, keys, policy.Requests, interval).Int64Slice()
+		if err != nil {
+			if policy.FailOpen {
+				utils.LogWarningContext(ctx, "Rate limiter unavailable, letting %s through the %s limit: %v", client, policy.Name, err)
+				c.Next()
 				return
 			}
-		} else {
+			utils.LogErrorContext(ctx, "Rate limiter unavailable, refusing %s under the %s limit: %v", client, policy.Name, err)
+			c.Header("Retry-After", "1")
+			utils.AbortWithProblem(c, http.StatusServiceUnavailable, models.CodeRateLimiterDown, "Rate limiting is unavailable, please try again later")
+			return
+		}
+		allowed, remaining, retryAfter, reset := result[0] == 1, result[1], result[2], result[3]
 
-			_, err = utils.RedisClient.Incr(ctx, key).Result()
-			if err != nil {
-				utils.LogError("Redis error incrementing rate limit for IP %s: %v", clientIP, err)
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
-				return
-			}
+		if previous, ok := c.Get(rateLimitRemainingKey); !ok || remaining < previous.(int64) || !allowed {
+			c.Set(rateLimitRemainingKey, remaining)
+			c.Header("RateLimit-Limit", strconv.Itoa(policy.Requests))
+			c.Header("RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+			c.Header("RateLimit-Reset", strconv.FormatInt(ceilSeconds(reset), 10))
+			c.Header("RateLimit-Policy", limitPolicy)
 		}
 
-		if count >= maxRequests {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+		if !allowed {
+			utils.LogWarningContext(ctx, "Rate limit exceeded for %s: %s limit of %d requests per %s", client, policy.Name, policy.Requests, policy.Per)
+			c.Header("Retry-After", strconv.FormatInt(ceilSeconds(retryAfter), 10))
+			utils.AbortWithProblem(c, http.StatusTooManyRequests, models.CodeRateLimited, "Too many requests")
 			return
 		}
 
 		c.Next()
 	}
 }
+
+// ceilSeconds rounds milliseconds up to whole seconds.
+func ceilSeconds(ms int64) int64 {
+	return (ms + 999) / 1000
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"backend/booking-service/config"
+	"backend/booking-service/database"
+)
+
+const migrateUsage = `usage: booking-service migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [N]       revert the last N applied migrations (default 1)
+  status         list migrations and when they were applied
+  create NAME    add empty up and down files for a new migration
+  seed           load the sample data for local development
+`
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := flags.String("dir", "database/migrations", "directory create writes new migrations to")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), migrateUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing migrate command")
+	}
+
+	command, rest := flags.Arg(0), flags.Args()[1:]
+	if command == "create" {
+		if len(rest) != 1 {
+			return fmt.Errorf("create takes one migration name")
+		}
+		paths, err := database.CreateMigration(*dir, rest[0])
+		for _, p := range paths {
+			fmt.Println("created", p)
+		}
+		return err
+	}
+
+	database.ConnectDB(cfg)
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return err
+	}
+	migrator, err := database.NewMigrator(sqlDB, database.MigrationsFS())
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %03d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(rest) > 0 {
+			if steps, err = strconv.Atoi(rest[0]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations to revert: %s", rest[0])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %03d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "seed":
+		loaded, err := database.Seed(ctx, sqlDB)
+		for _, name := range loaded {
+			fmt.Println("loaded", name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format(time.RFC3339)
+			}
+			if s.Dirty {
+				applied += " (dirty)"
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command '%s'", command)
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	AvailabilityEventSnapshot    = "snapshot"
+	AvailabilityEventTicketClass = "ticket_class_availability"
+	AvailabilityEventSeatStatus  = "seat_status"
+)
+
+// AvailabilityEvent is broadcast over Redis pub/sub to every booking-service
+// replica whenever seat or ticket class availability of a concert changes.
+type AvailabilityEvent struct {
+	Type           string             `json:"type"`
+	ConcertID      uint               `json:"concert_id"`
+	TicketClassID  uint               `json:"ticket_class_id,omitempty"`
+	AvailableSeats *int64             `json:"available_seats,omitempty"`
+	Delta          int                `json:"delta,omitempty"`
+	Seats          []SeatStatusChange `json:"seats,omitempty"`
+	Concert        *ConcertResponse   `json:"concert,omitempty"`
+	OccurredAt     time.Time          `json:"occurred_at"`
+}
+
+type SeatStatusChange struct {
+	SeatID        uint   `json:"seat_id"`
+	SeatNumber    string `json:"seat_number"`
+	TicketClassID uint   `json:"ticket_class_id"`
+	Status        string `json:"status"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	BookingEventSnapshot        = "snapshot"
+	BookingEventStatusChanged   = "status_changed"
+	BookingEventPaymentResult   = "payment_result"
+	BookingEventExpiryCountdown = "expiry_countdown"
+)
+
+// BookingEvent is broadcast over Redis pub/sub so that a checkout page connected
+// to any booking-service replica learns about changes to its booking.
+type BookingEvent struct {
+	Type             string     `json:"type"`
+	BookingID        string     `json:"booking_id"`
+	Status           string     `json:"status"`
+	PreviousStatus   string     `json:"previous_status,omitempty"`
+	PaymentID        *uint      `json:"payment_id,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	SecondsRemaining *int64     `json:"seconds_remaining,omitempty"`
+	Reason           string     `json:"reason,omitempty"`
+	OccurredAt       time.Time  `json:"occurred_at"`
+}
@@ -13,6 +13,25 @@ const (
 	BookingStatusFailed    = "failed"
 )
 
+// Reasons recorded when a pending booking is cancelled.
+const (
+	CancellationReasonUserRequest    = "cancelled_by_user"
+	CancellationReasonHoldExpired    = "hold_expired"
+	CancellationReasonPaymentTimeout = "payment_timeout"
+	CancellationReasonFraudCheck     = "fraud_check"
+	CancellationReasonAdmin          = "admin"
+)
+
+// IsCancellationReason reports whether reason is one of the cancellation reasons.
+func IsCancellationReason(reason string) bool {
+	switch reason {
+	case CancellationReasonUserRequest, CancellationReasonHoldExpired, CancellationReasonPaymentTimeout,
+		CancellationReasonFraudCheck, CancellationReasonAdmin:
+		return true
+	}
+	return false
+}
+
 type Booking struct {
 	gorm.Model
 	ID         string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
@@ -23,8 +42,12 @@ type Booking struct {
 	Status     string     `gorm:"not null;default:'pending'" json:"status"`
 	PaymentID  *uint      `json:"payment_id"`
 	ExpiresAt  *time.Time `json:"expires_at"`
-	Concert    Concert    `gorm:"foreignKey:ConcertID" json:"-"`
-	Seats      []*Seat    `gorm:"many2many:booking_seats;foreignKey:ID;joinForeignKey:booking_id;References:ID;joinReferences:seat_id" json:"-"`
+	// CancellationReason is why a cancelled booking was cancelled.
+	CancellationReason *string `gorm:"type:varchar(32)" json:"cancellation_reason"`
+	// CheckedInAt is set when the ticket is scanned at the venue.
+	CheckedInAt *time.Time `json:"checked_in_at"`
+	Concert     Concert    `gorm:"foreignKey:ConcertID" json:"-"`
+	Seats       []*Seat    `gorm:"many2many:booking_seats;foreignKey:ID;joinForeignKey:booking_id;References:ID;joinReferences:seat_id" json:"-"`
 
 	Buyer        *Buyer        `gorm:"foreignKey:BookingID;references:ID" json:"-"`
 	TicketHolder *TicketHolder `gorm:"foreignKey:BookingID;references:ID" json:"-"`
@@ -43,20 +66,53 @@ type TicketQuantityByClass struct {
 }
 
 type BookingResponse struct {
-	ID               string                `json:"id"`
-	UserID           uint                  `json:"user_id"`
-	ConcertID        uint                  `json:"concert_id"`
-	TotalPrice       float64               `json:"total_price"`
-	Status           string                `json:"status"`
-	PaymentID        *uint                 `json:"payment_id"`
-	ExpiresAt        *time.Time            `json:"expires_at"`
-	BookedSeats      []SeatResponse        `json:"booked_seats"`
-	ConcertName      string                `json:"concert_name"`
-	ConcertDate      time.Time             `json:"concert_date"`
-	BuyerInfo        *BuyerResponse        `json:"buyer_info"`
-	TicketHolderInfo *TicketHolderResponse `json:"ticket_holder_info"`
-	CreatedAt        time.Time             `json:"created_at"`
-	UpdatedAt        time.Time             `json:"updated_at"`
+	ID                 string                `json:"id"`
+	UserID             uint                  `json:"user_id"`
+	ConcertID          uint                  `json:"concert_id"`
+	TotalPrice         float64               `json:"total_price"`
+	Status             string                `json:"status"`
+	CancellationReason *string               `json:"cancellation_reason,omitempty"`
+	PaymentID          *uint                 `json:"payment_id"`
+	ExpiresAt          *time.Time            `json:"expires_at"`
+	CheckedInAt        *time.Time            `json:"checked_in_at"`
+	BookedSeats        []SeatResponse        `json:"booked_seats"`
+	ConcertName        string                `json:"concert_name"`
+	ConcertDate        time.Time             `json:"concert_date"`
+	BuyerInfo          *BuyerResponse        `json:"buyer_info"`
+	TicketHolderInfo   *TicketHolderResponse `json:"ticket_holder_info"`
+	CreatedAt          time.Time             `json:"created_at"`
+	UpdatedAt          time.Time             `json:"updated_at"`
+}
+
+// MaskPII masks the buyer and ticket holder details of the booking.
+func (r *BookingResponse) MaskPII() {
+	if r.BuyerInfo != nil {
+		r.BuyerInfo.MaskPII()
+	}
+	if r.TicketHolderInfo != nil {
+		r.TicketHolderInfo.MaskPII()
+	}
+}
+
+// CheckInResponse is what the scanner at the venue shows for a ticket. The
+// holder is the ticket holder, or the buyer when the booking names none.
+type CheckInResponse struct {
+	BookingID        string    `json:"booking_id"`
+	ConcertID        uint      `json:"concert_id"`
+	ConcertName      string    `json:"concert_name"`
+	HolderName       string    `json:"holder_name"`
+	Tickets          int       `json:"tickets"`
+	AgeBand          string    `json:"age_band"`
+	MinimumAge       int       `json:"minimum_age"`
+	MeetsMinimumAge  bool      `json:"meets_minimum_age"`
+	CheckedInAt      time.Time `json:"checked_in_at"`
+	AlreadyCheckedIn bool      `json:"already_checked_in"`
+}
+
+// KTPLookupRequest looks bookings up by the KTP number of their buyer or
+// ticket holder. The number travels in the body so it stays out of access logs.
+type KTPLookupRequest struct {
+	KTPNumber string `json:"ktp_number" validate:"required,numeric,len=16"`
 }
 
 type ErrorResponse struct {
@@ -71,3 +127,13 @@ type UpdateBookingStatusRequest struct {
 	Status    string `json:"status" validate:"required"`
 	PaymentID uint   `json:"payment_id" validate:"required"`
 }
+
+// BookingCancellationMessage is the payload of booking_cancellation_queue. Other
+// services publish it to cancel a pending booking asynchronously, e.g. on a payment
+// timeout or a failed fraud check.
+type BookingCancellationMessage struct {
+	BookingID   string    `json:"booking_id"`
+	Reason      string    `json:"reason"`
+	Actor       string    `json:"actor"`
+	RequestedAt time.Time `json:"requested_at"`
+}
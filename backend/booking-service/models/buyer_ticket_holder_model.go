@@ -1,14 +1,24 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Phone numbers, emails and KTP numbers are encrypted in the database by the
+// "pii" serializer (utils.PIISerializer). KTPIndex is a keyed hash of the KTP
+// number, set by the repositories, for lookups. One person may be on several
+// bookings; purchase limits cap how many tickets they get.
 
 type Buyer struct {
 	gorm.Model
 	BookingID   string `gorm:"not null;type:varchar(36)" json:"booking_id"`
 	FullName    string `gorm:"not null" json:"full_name"`
-	PhoneNumber string `gorm:"not null" json:"phone_number"`
-	Email       string `gorm:"not null" json:"email"`
-	KTPNumber   string `gorm:"not null;unique" json:"ktp_number"`
+	PhoneNumber string `gorm:"not null;serializer:pii" json:"phone_number"`
+	Email       string `gorm:"not null;serializer:pii" json:"email"`
+	KTPNumber   string `gorm:"not null;serializer:pii" json:"ktp_number"`
+	KTPIndex    string `gorm:"type:char(64);index" json:"-"`
 }
 
 func (b *Buyer) ToBuyerResponse() BuyerResponse {
@@ -25,7 +35,8 @@ type TicketHolder struct {
 	gorm.Model
 	BookingID string `gorm:"not null;type:varchar(36)" json:"booking_id"`
 	FullName  string `gorm:"not null" json:"full_name"`
-	KTPNumber string `gorm:"not null;unique" json:"ktp_number"`
+	KTPNumber string `gorm:"not null;serializer:pii" json:"ktp_number"`
+	KTPIndex  string `gorm:"type:char(64);index" json:"-"`
 }
 
 func (th *TicketHolder) ToTicketHolderResponse() TicketHolderResponse {
@@ -61,3 +72,38 @@ type TicketHolderResponse struct {
 	FullName  string `json:"full_name"`
 	KTPNumber string `json:"ktp_number"`
 }
+
+// MaskPII hides the middle of the buyer's contact details and KTP number, e.g.
+// 3201********0001, for callers that may not see them in full.
+func (r *BuyerResponse) MaskPII() {
+	r.PhoneNumber = maskMiddle(r.PhoneNumber, 4, 3)
+	r.Email = maskEmail(r.Email)
+	r.KTPNumber = maskMiddle(r.KTPNumber, 4, 4)
+}
+
+func (r *TicketHolderResponse) MaskPII() {
+	r.KTPNumber = maskMiddle(r.KTPNumber, 4, 4)
+}
+
+// maskMiddle replaces all but the first keepStart and last keepEnd characters
+// with asterisks. Values too short to keep both ends are masked completely.
+func maskMiddle(value string, keepStart, keepEnd int) string {
+	runes := []rune(value)
+	if len(runes) <= keepStart+keepEnd {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keepStart]) + strings.Repeat("*", len(runes)-keepStart-keepEnd) + string(runes[len(runes)-keepEnd:])
+}
+
+// maskEmail keeps the first character of the local part and the domain:
+// budi@example.com becomes b***@example.com.
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return maskMiddle(email, 0, 0)
+	}
+	if local == "" {
+		return "***@" + domain
+	}
+	return string([]rune(local)[:1]) + "***@" + domain
+}
@@ -14,26 +14,30 @@ const (
 
 type Concert struct {
 	gorm.Model
-	Name           string        `gorm:"not null" json:"name" validate:"required,min=3"`
-	Artist         string        `json:"artist" validate:"required"`
-	Date           time.Time     `gorm:"not null" json:"date" validate:"required"`
-	Venue          string        `gorm:"not null" json:"venue" validate:"required"`
-	TotalSeats     int           `gorm:"not null" json:"total_seats" validate:"required,min=1"`
-	AvailableSeats int           `gorm:"not null" json:"available_seats"`
-	Description    string        `json:"description"`
-	Status         string        `gorm:"default:'pending_seat_creation'" json:"status"`
-	ImageUrl       string        `json:"image_url"`
-	TicketClasses  []TicketClass `gorm:"foreignKey:ConcertID" json:"-"`
+	Name           string         `gorm:"not null" json:"name" validate:"required,min=3"`
+	Artist         string         `json:"artist" validate:"required"`
+	Date           time.Time      `gorm:"not null" json:"date" validate:"required"`
+	Venue          string         `gorm:"not null" json:"venue" validate:"required"`
+	MinimumAge     int            `gorm:"not null;default:0" json:"minimum_age"`
+	PurchaseLimits PurchaseLimits `gorm:"embedded;embeddedPrefix:max_tickets_per_" json:"purchase_limits"`
+	TotalSeats     int            `gorm:"not null" json:"total_seats" validate:"required,min=1"`
+	AvailableSeats int            `gorm:"not null" json:"available_seats"`
+	Description    string         `json:"description"`
+	Status         string         `gorm:"default:'pending_seat_creation'" json:"status"`
+	ImageUrl       string         `json:"image_url"`
+	TicketClasses  []TicketClass  `gorm:"foreignKey:ConcertID" json:"-"`
 }
 
 type CreateConcertRequest struct {
-	Name          string                     `json:"name" validate:"required,min=3"`
-	Artist        string                     `json:"artist" validate:"required"`
-	Date          time.Time                  `json:"date" validate:"required"`
-	Venue         string                     `json:"venue" validate:"required"`
-	Description   string                     `json:"description"`
-	ImageUrl      string                     `json:"image_url" validate:"url"`
-	TicketClasses []CreateTicketClassRequest `json:"ticket_classes" validate:"required,min=1,dive"`
+	Name           string                     `json:"name" validate:"required,min=3"`
+	Artist         string                     `json:"artist" validate:"required"`
+	Date           time.Time                  `json:"date" validate:"required"`
+	Venue          string                     `json:"venue" validate:"required"`
+	MinimumAge     int                        `json:"minimum_age" validate:"min=0,max=99"`
+	PurchaseLimits PurchaseLimits             `json:"purchase_limits"`
+	Description    string                     `json:"description"`
+	ImageUrl       string                     `json:"image_url" validate:"url"`
+	TicketClasses  []CreateTicketClassRequest `json:"ticket_classes" validate:"required,min=1,dive"`
 }
 
 type ConcertResponse struct {
@@ -43,6 +47,8 @@ type ConcertResponse struct {
 	Date           time.Time             `json:"date"`
 	SetDateISO     string                `json:"date_iso"`
 	Venue          string                `json:"venue"`
+	MinimumAge     int                   `json:"minimum_age"`
+	PurchaseLimits PurchaseLimits        `json:"purchase_limits"`
 	TotalSeats     int                   `json:"total_seats"`
 	AvailableSeats int                   `json:"available_seats"`
 	Description    string                `json:"description"`
@@ -65,6 +71,8 @@ func (c *Concert) ToConcertResponse() ConcertResponse {
 		Date:           c.Date,
 		SetDateISO:     c.Date.Format(time.RFC3339),
 		Venue:          c.Venue,
+		MinimumAge:     c.MinimumAge,
+		PurchaseLimits: c.PurchaseLimits,
 		TotalSeats:     c.TotalSeats,
 		AvailableSeats: c.AvailableSeats,
 		Description:    c.Description,
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type JobStatus struct {
+	Name         string     `json:"name"`
+	Runs         int64      `json:"runs"`
+	Skipped      int64      `json:"skipped"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+type LeaderStatus struct {
+	InstanceID    string      `json:"instance_id"`
+	IsLeader      bool        `json:"is_leader"`
+	CurrentLeader string      `json:"current_leader,omitempty"`
+	Term          int64       `json:"term,omitempty"`
+	LeaderSince   *time.Time  `json:"leader_since,omitempty"`
+	LeaseTTL      string      `json:"lease_ttl"`
+	Jobs          []JobStatus `json:"jobs"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+)
+
+// Age bands shown to the scanner at check-in. They follow the common 18+ and
+// 21+ limits instead of giving the exact age.
+const (
+	AgeBandUnder18 = "under_18"
+	AgeBand18To20  = "18_20"
+	AgeBand21Plus  = "21_plus"
+	AgeBandUnknown = "unknown"
+)
+
+// NIKInfo is what a NIK (the 16-digit number on a KTP) encodes: where it was
+// issued, its holder's birth date and gender, and a serial number. Names are
+// empty for regions the bundled region table does not list.
+type NIKInfo struct {
+	ProvinceCode string    `json:"province_code"`
+	Province     string    `json:"province"`
+	RegencyCode  string    `json:"regency_code"`
+	Regency      string    `json:"regency,omitempty"`
+	DistrictCode string    `json:"district_code"`
+	District     string    `json:"district,omitempty"`
+	BirthDate    time.Time `json:"birth_date"`
+	Gender       string    `json:"gender"`
+	Serial       string    `json:"serial"`
+}
+
+// AgeOn returns the holder's age in whole years on the given day.
+func (n NIKInfo) AgeOn(day time.Time) int {
+	age := day.Year() - n.BirthDate.Year()
+	if day.Month() < n.BirthDate.Month() || (day.Month() == n.BirthDate.Month() && day.Day() < n.BirthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+// AgeBand returns the band an age falls in.
+func AgeBand(age int) string {
+	switch {
+	case age < 18:
+		return AgeBandUnder18
+	case age < 21:
+		return AgeBand18To20
+	default:
+		return AgeBand21Plus
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+// ProblemDetails is the RFC 7807 (application/problem+json) body of every error
+// response. Code is stable and meant for clients to branch on or localize; Error
+// repeats Detail for clients that still read the old {"error": ...} body.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Error codes are part of the API contract. Add new codes instead of changing
+// existing ones.
+const (
+	CodeInternalError       = "INTERNAL_ERROR"
+	CodeInvalidRequestBody  = "INVALID_REQUEST_BODY"
+	CodeValidationFailed    = "VALIDATION_FAILED"
+	CodeInvalidParameter    = "INVALID_PARAMETER"
+	CodeUnauthorized        = "UNAUTHORIZED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeRateLimited         = "RATE_LIMITED"
+	CodeRateLimiterDown     = "RATE_LIMITER_UNAVAILABLE"
+	CodeIdempotencyKeyReuse = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyInFlight = "IDEMPOTENCY_KEY_IN_PROGRESS"
+
+	CodeConcertNotFound          = "CONCERT_NOT_FOUND"
+	CodeConcertNotActive         = "CONCERT_NOT_ACTIVE"
+	CodeInvalidTicketClasses     = "INVALID_TICKET_CLASSES"
+	CodeTicketClassNotFound      = "TICKET_CLASS_NOT_FOUND"
+	CodeInvalidTicketQuantity    = "INVALID_TICKET_QUANTITY"
+	CodeInvalidKTPNumber         = "INVALID_KTP_NUMBER"
+	CodeAgeRestricted            = "AGE_RESTRICTED"
+	CodeNotEnoughSeats           = "NOT_ENOUGH_SEATS"
+	CodeSeatReservationContended = "SEAT_RESERVATION_CONTENDED"
+	CodeActiveBookingExists      = "ACTIVE_BOOKING_EXISTS"
+	CodePurchaseLimitExceeded    = "PURCHASE_LIMIT_EXCEEDED"
+	CodeBookingNotFound          = "BOOKING_NOT_FOUND"
+	CodeBookingAccessDenied      = "BOOKING_ACCESS_DENIED"
+	CodeBookingNotCancellable    = "BOOKING_NOT_CANCELLABLE"
+	CodeBookingNotConfirmed      = "BOOKING_NOT_CONFIRMED"
+	CodeInvalidStatusTransition  = "INVALID_STATUS_TRANSITION"
+	CodeUnsupportedBookingStatus = "UNSUPPORTED_BOOKING_STATUS"
+	CodeInvalidCancellation      = "INVALID_CANCELLATION_MESSAGE"
+	CodeQueueNotFound            = "QUEUE_NOT_FOUND"
+	CodeInvalidDateRange         = "INVALID_DATE_RANGE"
+	CodeUnsupportedReportGroup   = "UNSUPPORTED_REPORT_GROUPING"
+)
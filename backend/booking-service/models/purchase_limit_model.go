@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// Kinds of identifier the tickets of a concert are limited by. A person can
+// register any number of accounts, but not get another KTP, phone number or
+// card as easily.
+const (
+	IdentityBuyerKTP  = "buyer_ktp"
+	IdentityHolderKTP = "holder_ktp"
+	IdentityPhone     = "phone"
+	IdentityCard      = "card"
+)
+
+// PurchaseLimits caps the tickets of a concert that the bookings sharing an
+// identifier can hold at once, whichever accounts made them. Zero means no
+// limit.
+type PurchaseLimits struct {
+	BuyerKTP  int `gorm:"not null;default:0" json:"buyer_ktp" validate:"min=0"`
+	HolderKTP int `gorm:"not null;default:0" json:"holder_ktp" validate:"min=0"`
+	Phone     int `gorm:"not null;default:0" json:"phone" validate:"min=0"`
+	Card      int `gorm:"not null;default:0" json:"card" validate:"min=0"`
+}
+
+// For returns the limit for a kind of identifier.
+func (l PurchaseLimits) For(kind string) int {
+	switch kind {
+	case IdentityBuyerKTP:
+		return l.BuyerKTP
+	case IdentityHolderKTP:
+		return l.HolderKTP
+	case IdentityPhone:
+		return l.Phone
+	case IdentityCard:
+		return l.Card
+	}
+	return 0
+}
+
+// BookingIdentity records an identifier a booking was made or paid with and
+// the tickets it counts against it. Only a keyed hash of the value is stored;
+// KTP numbers hash to the same ktp_index as in buyers and ticket_holders. The
+// tickets stop counting once ReleasedAt is set, but the row is kept for the
+// review of accounts that share identifiers.
+type BookingIdentity struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	BookingID  string `gorm:"type:varchar(36);not null;index"`
+	ConcertID  uint   `gorm:"not null"`
+	UserID     uint   `gorm:"not null"`
+	Kind       string `gorm:"not null"`
+	Value      string `gorm:"-"`
+	ValueIndex string `gorm:"type:char(64);not null"`
+	Tickets    int    `gorm:"not null"`
+	ReleasedAt *time.Time
+}
+
+// PurchaseLimitCounter is the number of tickets of a concert held under an
+// identifier. Bookings claim tickets with a guarded update of this row, which
+// serializes concurrent bookings that share the identifier.
+type PurchaseLimitCounter struct {
+	ConcertID  uint   `gorm:"primaryKey;autoIncrement:false"`
+	Kind       string `gorm:"primaryKey"`
+	ValueIndex string `gorm:"primaryKey;type:char(64)"`
+	Tickets    int    `gorm:"not null;default:0"`
+}
+
+// ClaimPaymentCardRequest is sent by the payment service before it charges a
+// card for a booking. The fingerprint is a keyed hash of the card number.
+type ClaimPaymentCardRequest struct {
+	CardFingerprint string `json:"card_fingerprint" validate:"required,hexadecimal,len=64"`
+}
+
+// IdentityCluster is a group of accounts linked by identifiers their bookings
+// share, directly or through other accounts in the group.
+type IdentityCluster struct {
+	UserIDs           []uint             `json:"user_ids"`
+	SharedIdentifiers []SharedIdentifier `json:"shared_identifiers"`
+	BookingIDs        []string           `json:"booking_ids"`
+	// ActiveTickets counts the tickets of the cluster's bookings that still
+	// count against the purchase limits.
+	ActiveTickets int `json:"active_tickets"`
+}
+
+// SharedIdentifier is an identifier used by more than one account. The
+// fingerprint is the start of its keyed hash, enough to tell identifiers apart
+// without revealing them; the bookings show the values themselves.
+type SharedIdentifier struct {
+	Kinds       []string `json:"kinds"`
+	Fingerprint string   `json:"fingerprint"`
+	UserIDs     []uint   `json:"user_ids"`
+}
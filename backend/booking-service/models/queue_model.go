@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type DeadLetterMessage struct {
+	MessageID     string     `json:"message_id"`
+	OriginalQueue string     `json:"original_queue"`
+	RetryCount    int        `json:"retry_count"`
+	LastError     string     `json:"last_error"`
+	FailedAt      string     `json:"failed_at"`
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+	Body          string     `json:"body"`
+}
+
+type ReplayDeadLettersRequest struct {
+	MessageIDs []string `json:"message_ids"`
+}
+
+type ReplayDeadLettersResponse struct {
+	Queue    string `json:"queue"`
+	Replayed int    `json:"replayed"`
+	// More is set when the replay stopped at its limit before the end of the
+	// dead-letter queue; replaying again continues.
+	More bool `json:"more"`
+}
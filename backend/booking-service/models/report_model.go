@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ReportGroupByConcert     = "concert"
+	ReportGroupByTicketClass = "ticket_class"
+	ReportGroupByDay         = "day"
+)
+
+const (
+	ReportFormatJSON = "json"
+	ReportFormatCSV  = "csv"
+	ReportFormatXLSX = "xlsx"
+)
+
+type SalesReportFilter struct {
+	GroupBy   string
+	ConcertID *uint
+	From      *time.Time
+	To        *time.Time
+}
+
+// SalesReportRow is one aggregated line of a sales report. Depending on the
+// grouping, only the matching key columns (concert, ticket class or day) are set.
+type SalesReportRow struct {
+	ConcertID         uint    `json:"concert_id,omitempty"`
+	ConcertName       string  `json:"concert_name,omitempty"`
+	TicketClassID     uint    `json:"ticket_class_id,omitempty"`
+	TicketClassName   string  `json:"ticket_class_name,omitempty"`
+	Day               string  `json:"day,omitempty"`
+	TotalBookings     int64   `json:"total_bookings"`
+	ConfirmedBookings int64   `json:"confirmed_bookings"`
+	PendingBookings   int64   `json:"pending_bookings"`
+	CancelledBookings int64   `json:"cancelled_bookings"`
+	ExpiredBookings   int64   `json:"expired_bookings"`
+	FailedBookings    int64   `json:"failed_bookings"`
+	TicketsSold       int64   `json:"tickets_sold"`
+	Revenue           float64 `json:"revenue"`
+	AverageOrderValue float64 `json:"average_order_value"`
+}
+
+type SalesReportTotals struct {
+	TotalBookings     int64   `json:"total_bookings"`
+	ConfirmedBookings int64   `json:"confirmed_bookings"`
+	PendingBookings   int64   `json:"pending_bookings"`
+	CancelledBookings int64   `json:"cancelled_bookings"`
+	ExpiredBookings   int64   `json:"expired_bookings"`
+	FailedBookings    int64   `json:"failed_bookings"`
+	TicketsSold       int64   `json:"tickets_sold"`
+	Revenue           float64 `json:"revenue"`
+	AverageOrderValue float64 `json:"average_order_value"`
+}
+
+type SalesReportResponse struct {
+	GroupBy     string            `json:"group_by"`
+	ConcertID   *uint             `json:"concert_id,omitempty"`
+	From        *time.Time        `json:"from,omitempty"`
+	To          *time.Time        `json:"to,omitempty"`
+	Rows        []SalesReportRow  `json:"rows"`
+	Totals      SalesReportTotals `json:"totals"`
+	GeneratedAt time.Time         `json:"generated_at"`
+}
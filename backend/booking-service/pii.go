@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"backend/booking-service/config"
+	"backend/booking-service/database"
+	"backend/booking-service/utils"
+)
+
+const piiUsage = `usage: booking-service pii <command>
+
+commands:
+  rotate    re-seal encrypted buyer and ticket holder details with the first
+            key of PII_ENCRYPTION_KEYS, and encrypt rows still in plaintext
+`
+
+// runPII implements the pii subcommand.
+func runPII(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("pii", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), piiUsage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || flags.Arg(0) != "rotate" {
+		flags.Usage()
+		return fmt.Errorf("unknown pii command")
+	}
+
+	if err := utils.InitPII(cfg); err != nil {
+		return err
+	}
+	database.ConnectDB(cfg)
+	defer database.CloseDB()
+
+	results, err := database.RotatePIIKeys(context.Background(), database.DB)
+	for _, r := range results {
+		fmt.Printf("%s: %d of %d rows updated\n", r.Table, r.Updated, r.Scanned)
+	}
+	return err
+}
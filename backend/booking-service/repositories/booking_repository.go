@@ -1,10 +1,14 @@
 package repositories
 
 import (
-	"backend/booking-service/models"
+	"context"
 	"time"
 
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BookingRepository struct {
@@ -15,42 +19,157 @@ func NewBookingRepository(db *gorm.DB) *BookingRepository {
 	return &BookingRepository{DB: db}
 }
 
-func (r *BookingRepository) CreateBooking(booking *models.Booking) error {
-	return r.DB.Create(booking).Error
+func (r *BookingRepository) CreateBooking(ctx context.Context, booking *models.Booking) error {
+	return r.DB.WithContext(ctx).Create(booking).Error
 }
 
-func (r *BookingRepository) GetBookingByID(id string) (*models.Booking, error) {
+func (r *BookingRepository) GetBookingByID(ctx context.Context, id string) (*models.Booking, error) {
 	var booking models.Booking
-	err := r.DB.Preload("Concert").Preload("Seats").Preload("Buyer").Preload("TicketHolder").First(&booking, "id = ?", id).Error
+	err := r.DB.WithContext(ctx).Preload("Concert").Preload("Seats").Preload("Buyer").Preload("TicketHolder").First(&booking, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &booking, nil
 }
 
-func (r *BookingRepository) UpdateBooking(booking *models.Booking) error {
-	return r.DB.Save(booking).Error
+func (r *BookingRepository) UpdateBooking(ctx context.Context, booking *models.Booking) error {
+	return r.DB.WithContext(ctx).Save(booking).Error
+}
+
+// TransitionBookingStatus moves a booking to toStatus only if it is still in
+// fromStatus and reports whether it did.
+func (r *BookingRepository) TransitionBookingStatus(ctx context.Context, id, fromStatus, toStatus string) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.Booking{}).Where("id = ? AND status = ?", id, fromStatus).Update("status", toStatus)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkCheckedIn records the check-in of a confirmed booking that has not been
+// checked in yet and reports whether it did.
+func (r *BookingRepository) MarkCheckedIn(ctx context.Context, id string, at time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.Booking{}).
+		Where("id = ? AND status = ? AND checked_in_at IS NULL", id, models.BookingStatusConfirmed).
+		Update("checked_in_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ClaimBookingIdentity counts the tickets of a booking against an identifier
+// and records the identity, unless that would take the identifier over limit
+// (0 for no limit) for the concert. The count is one row updated with a
+// guard, so concurrent bookings sharing the identifier wait for each other.
+func (r *BookingRepository) ClaimBookingIdentity(ctx context.Context, identity *models.BookingIdentity, limit int) (bool, error) {
+	index, err := utils.IdentityIndex(identity.Kind, identity.Value)
+	if err != nil {
+		return false, err
+	}
+	identity.ValueIndex = index
+	db := r.DB.WithContext(ctx)
+
+	counter := models.PurchaseLimitCounter{ConcertID: identity.ConcertID, Kind: identity.Kind, ValueIndex: index}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter).Error; err != nil {
+		return false, err
+	}
+	claim := db.Model(&models.PurchaseLimitCounter{}).
+		Where("concert_id = ? AND kind = ? AND value_index = ?", identity.ConcertID, identity.Kind, index)
+	if limit > 0 {
+		claim = claim.Where("tickets + ? <= ?", identity.Tickets, limit)
+	}
+	result := claim.Update("tickets", gorm.Expr("tickets + ?", identity.Tickets))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+	return true, db.Create(identity).Error
 }
 
-func (r *BookingRepository) GetBookingsByUserID(userID uint) ([]models.Booking, error) {
+// ReleaseBookingIdentities gives back the tickets a booking counts against
+// its identifiers, only those of kind unless it is empty.
+func (r *BookingRepository) ReleaseBookingIdentities(ctx context.Context, bookingID, kind string, at time.Time) error {
+	db := r.DB.WithContext(ctx)
+	query := db.Where("booking_id = ? AND released_at IS NULL", bookingID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var identities []models.BookingIdentity
+	if err := query.Find(&identities).Error; err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if err := db.Model(&models.PurchaseLimitCounter{}).
+			Where("concert_id = ? AND kind = ? AND value_index = ?", identity.ConcertID, identity.Kind, identity.ValueIndex).
+			Update("tickets", gorm.Expr("tickets - ?", identity.Tickets)).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&models.BookingIdentity{}).Where("id = ?", identity.ID).Update("released_at", at).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBookingIdentities returns the identities recorded for the bookings of a
+// concert, or of every concert when concertID is 0.
+func (r *BookingRepository) GetBookingIdentities(ctx context.Context, concertID uint) ([]models.BookingIdentity, error) {
+	query := r.DB.WithContext(ctx)
+	if concertID != 0 {
+		query = query.Where("concert_id = ?", concertID)
+	}
+	var identities []models.BookingIdentity
+	err := query.Order("id").Find(&identities).Error
+	return identities, err
+}
+
+func (r *BookingRepository) GetBookingsByUserID(ctx context.Context, userID uint) ([]models.Booking, error) {
 	var bookings []models.Booking
-	err := r.DB.Where("user_id = ?", userID).Preload("Concert").Preload("Seats").Preload("Buyer").Preload("TicketHolder").Find(&bookings).Error
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Preload("Concert").Preload("Seats").Preload("Buyer").Preload("TicketHolder").Find(&bookings).Error
 	return bookings, err
 }
 
-func (r *BookingRepository) GetUserActiveBookingsForConcert(userID, concertID uint) ([]models.Booking, error) {
+// GetBookingsByKTP finds the bookings whose buyer or ticket holder has the
+// KTP number, through the blind index as the numbers themselves are encrypted.
+func (r *BookingRepository) GetBookingsByKTP(ctx context.Context, ktpNumber string) ([]models.Booking, error) {
+	index, err := utils.BlindIndex(ktpNumber)
+	if err != nil {
+		return nil, err
+	}
+	db := r.DB.WithContext(ctx)
+	var bookings []models.Booking
+	err = db.Where("id IN (?) OR id IN (?)",
+		db.Model(&models.Buyer{}).Select("booking_id").Where("ktp_index = ?", index),
+		db.Model(&models.TicketHolder{}).Select("booking_id").Where("ktp_index = ?", index),
+	).Preload("Concert").Preload("Seats").Preload("Buyer").Preload("TicketHolder").Order("created_at").Find(&bookings).Error
+	return bookings, err
+}
+
+func (r *BookingRepository) GetUserActiveBookingsForConcert(ctx context.Context, userID, concertID uint) ([]models.Booking, error) {
 	var bookings []models.Booking
 
-	err := r.DB.Where("user_id = ? AND concert_id = ? AND (status = ? OR status = ?)",
+	err := r.DB.WithContext(ctx).Where("user_id = ? AND concert_id = ? AND (status = ? OR status = ?)",
 		userID, concertID, models.BookingStatusPending, models.BookingStatusConfirmed).Find(&bookings).Error
 	return bookings, err
 }
 
-func (r *BookingRepository) GetExpiredPendingBookings() ([]models.Booking, error) {
+func (r *BookingRepository) GetExpiredPendingBookings(ctx context.Context, now time.Time) ([]models.Booking, error) {
 	var bookings []models.Booking
 
-	err := r.DB.Where("status = ? AND expires_at < ?", models.BookingStatusPending, time.Now()).
+	err := r.DB.WithContext(ctx).Where("status = ? AND expires_at < ?", models.BookingStatusPending, now).
 		Preload("Seats").
 		Find(&bookings).Error
 	return bookings, err
 }
+
+func (r *BookingRepository) GetPendingBookingsWithExpiry(ctx context.Context) ([]models.Booking, error) {
+	var bookings []models.Booking
+
+	err := r.DB.WithContext(ctx).Select("id", "expires_at").
+		Where("status = ? AND expires_at IS NOT NULL", models.BookingStatusPending).
+		Find(&bookings).Error
+	return bookings, err
+}
@@ -1,7 +1,10 @@
 package repositories
 
 import (
+	"context"
+
 	"backend/booking-service/models"
+	"backend/booking-service/utils"
 
 	"gorm.io/gorm"
 )
@@ -14,13 +17,18 @@ func NewBuyerRepository(db *gorm.DB) *BuyerRepository {
 	return &BuyerRepository{DB: db}
 }
 
-func (r *BuyerRepository) CreateBuyer(db *gorm.DB, buyer *models.Buyer) error {
-	return db.Create(buyer).Error
+func (r *BuyerRepository) CreateBuyer(ctx context.Context, buyer *models.Buyer) error {
+	index, err := utils.BlindIndex(buyer.KTPNumber)
+	if err != nil {
+		return err
+	}
+	buyer.KTPIndex = index
+	return r.DB.WithContext(ctx).Create(buyer).Error
 }
 
-func (r *BuyerRepository) GetBuyerByBookingID(bookingID uint) (*models.Buyer, error) {
+func (r *BuyerRepository) GetBuyerByBookingID(ctx context.Context, bookingID uint) (*models.Buyer, error) {
 	var buyer models.Buyer
-	err := r.DB.Where("booking_id = ?", bookingID).First(&buyer).Error
+	err := r.DB.WithContext(ctx).Where("booking_id = ?", bookingID).First(&buyer).Error
 	return &buyer, err
 }
 
@@ -32,12 +40,17 @@ func NewTicketHolderRepository(db *gorm.DB) *TicketHolderRepository {
 	return &TicketHolderRepository{DB: db}
 }
 
-func (r *TicketHolderRepository) CreateTicketHolder(db *gorm.DB, ticketHolder *models.TicketHolder) error {
-	return db.Create(ticketHolder).Error
+func (r *TicketHolderRepository) CreateTicketHolder(ctx context.Context, ticketHolder *models.TicketHolder) error {
+	index, err := utils.BlindIndex(ticketHolder.KTPNumber)
+	if err != nil {
+		return err
+	}
+	ticketHolder.KTPIndex = index
+	return r.DB.WithContext(ctx).Create(ticketHolder).Error
 }
 
-func (r *TicketHolderRepository) GetTicketHolderByBookingID(bookingID uint) (*models.TicketHolder, error) {
+func (r *TicketHolderRepository) GetTicketHolderByBookingID(ctx context.Context, bookingID uint) (*models.TicketHolder, error) {
 	var ticketHolder models.TicketHolder
-	err := r.DB.Where("booking_id = ?", bookingID).First(&ticketHolder).Error
+	err := r.DB.WithContext(ctx).Where("booking_id = ?", bookingID).First(&ticketHolder).Error
 	return &ticketHolder, err
 }
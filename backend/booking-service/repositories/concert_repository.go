@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+
 	"backend/booking-service/models"
 
 	"gorm.io/gorm"
@@ -14,29 +16,32 @@ func NewConcertRepository(db *gorm.DB) *ConcertRepository {
 	return &ConcertRepository{DB: db}
 }
 
-func (r *ConcertRepository) CreateConcert(db *gorm.DB, concert *models.Concert) error {
-
-	return db.Create(concert).Error
+func (r *ConcertRepository) CreateConcert(ctx context.Context, concert *models.Concert) error {
+	return r.DB.WithContext(ctx).Create(concert).Error
 }
 
-func (r *ConcertRepository) GetConcerts() ([]models.Concert, error) {
+func (r *ConcertRepository) GetConcerts(ctx context.Context) ([]models.Concert, error) {
 	var concerts []models.Concert
-	err := r.DB.Preload("TicketClasses").Find(&concerts).Error
+	err := r.DB.WithContext(ctx).Preload("TicketClasses").Find(&concerts).Error
 	return concerts, err
 }
 
-func (r *ConcertRepository) GetConcertByID(id uint) (*models.Concert, error) {
+func (r *ConcertRepository) GetConcertByID(ctx context.Context, id uint) (*models.Concert, error) {
 	var concert models.Concert
-	err := r.DB.Preload("TicketClasses").First(&concert, id).Error
+	err := r.DB.WithContext(ctx).Preload("TicketClasses").First(&concert, id).Error
 	return &concert, err
 }
 
-func (r *ConcertRepository) UpdateConcert(db *gorm.DB, concert *models.Concert) error {
-	return db.Save(concert).Error
+func (r *ConcertRepository) UpdateConcert(ctx context.Context, concert *models.Concert) error {
+	return r.DB.WithContext(ctx).Save(concert).Error
+}
+
+func (r *ConcertRepository) UpdateConcertStatus(ctx context.Context, id uint, status string) error {
+	return r.DB.WithContext(ctx).Model(&models.Concert{}).Where("id = ?", id).Update("status", status).Error
 }
 
-func (r *ConcertRepository) GetConcertsByStatus(status string) ([]models.Concert, error) {
+func (r *ConcertRepository) GetConcertsByStatus(ctx context.Context, status string) ([]models.Concert, error) {
 	var concerts []models.Concert
-	err := r.DB.Where("status = ?", status).Preload("TicketClasses").Find(&concerts).Error
+	err := r.DB.WithContext(ctx).Where("status = ?", status).Preload("TicketClasses").Find(&concerts).Error
 	return concerts, err
 }
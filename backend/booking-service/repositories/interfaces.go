@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+// The services depend on these interfaces instead of the GORM repositories, so
+// they can also run on the in-memory store in package fakes. Lookups of missing
+// records return gorm.ErrRecordNotFound in every implementation.
+
+type BookingStore interface {
+	CreateBooking(ctx context.Context, booking *models.Booking) error
+	GetBookingByID(ctx context.Context, id string) (*models.Booking, error)
+	UpdateBooking(ctx context.Context, booking *models.Booking) error
+	TransitionBookingStatus(ctx context.Context, id, fromStatus, toStatus string) (bool, error)
+	MarkCheckedIn(ctx context.Context, id string, at time.Time) (bool, error)
+	ClaimBookingIdentity(ctx context.Context, identity *models.BookingIdentity, limit int) (bool, error)
+	ReleaseBookingIdentities(ctx context.Context, bookingID, kind string, at time.Time) error
+	GetBookingIdentities(ctx context.Context, concertID uint) ([]models.BookingIdentity, error)
+	GetBookingsByUserID(ctx context.Context, userID uint) ([]models.Booking, error)
+	GetBookingsByKTP(ctx context.Context, ktpNumber string) ([]models.Booking, error)
+	GetUserActiveBookingsForConcert(ctx context.Context, userID, concertID uint) ([]models.Booking, error)
+	GetExpiredPendingBookings(ctx context.Context, now time.Time) ([]models.Booking, error)
+	GetPendingBookingsWithExpiry(ctx context.Context) ([]models.Booking, error)
+}
+
+type ConcertStore interface {
+	CreateConcert(ctx context.Context, concert *models.Concert) error
+	GetConcerts(ctx context.Context) ([]models.Concert, error)
+	GetConcertByID(ctx context.Context, id uint) (*models.Concert, error)
+	UpdateConcert(ctx context.Context, concert *models.Concert) error
+	UpdateConcertStatus(ctx context.Context, id uint, status string) error
+	GetConcertsByStatus(ctx context.Context, status string) ([]models.Concert, error)
+}
+
+type SeatStore interface {
+	CreateSeats(ctx context.Context, seats []models.Seat) error
+	CreateSeatsInBatches(ctx context.Context, seats []models.Seat, batchSize int) error
+	GetSeatsByConcertID(ctx context.Context, concertID uint) ([]models.Seat, error)
+	GetSeatsByTicketClassID(ctx context.Context, ticketClassID uint) ([]models.Seat, error)
+	UpdateSeats(ctx context.Context, seats []*models.Seat) error
+}
+
+type TicketClassStore interface {
+	GetTicketClassByID(ctx context.Context, id uint) (*models.TicketClass, error)
+	UpdateTicketClass(ctx context.Context, ticketClass *models.TicketClass) error
+}
+
+type BuyerStore interface {
+	CreateBuyer(ctx context.Context, buyer *models.Buyer) error
+}
+
+type TicketHolderStore interface {
+	CreateTicketHolder(ctx context.Context, ticketHolder *models.TicketHolder) error
+}
+
+// Stores is the set of repositories a unit of work operates on.
+type Stores struct {
+	Bookings      BookingStore
+	Concerts      ConcertStore
+	Seats         SeatStore
+	TicketClasses TicketClassStore
+	Buyers        BuyerStore
+	TicketHolders TicketHolderStore
+}
+
+// Transactor runs fn in a transaction over all repositories. The transaction is
+// committed when fn returns nil and rolled back when it returns an error.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(tx Stores) error) error
+}
+
+var (
+	_ BookingStore      = (*BookingRepository)(nil)
+	_ ConcertStore      = (*ConcertRepository)(nil)
+	_ SeatStore         = (*SeatRepository)(nil)
+	_ TicketClassStore  = (*TicketClassRepository)(nil)
+	_ BuyerStore        = (*BuyerRepository)(nil)
+	_ TicketHolderStore = (*TicketHolderRepository)(nil)
+	_ Transactor        = (*GormTransactor)(nil)
+)
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"backend/booking-service/models"
+
+	"gorm.io/gorm"
+)
+
+// expiredBookingCondition picks the cancelled bookings whose hold expired, from
+// the reason recorded when they were cancelled. Bookings cancelled before the
+// reason was recorded have none and count as cancelled.
+var expiredBookingCondition = fmt.Sprintf("b.status = '%s' AND b.cancellation_reason = '%s'",
+	models.BookingStatusCancelled, models.CancellationReasonHoldExpired)
+
+type ReportRepository struct {
+	DB *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) *ReportRepository {
+	return &ReportRepository{DB: db}
+}
+
+func bookingStatusAggregates(bookingKey string) string {
+	return fmt.Sprintf(`COUNT(DISTINCT %[1]s) AS total_bookings,
+		COUNT(DISTINCT CASE WHEN b.status = '%[2]s' THEN %[1]s END) AS confirmed_bookings,
+		COUNT(DISTINCT CASE WHEN b.status = '%[3]s' THEN %[1]s END) AS pending_bookings,
+		COUNT(DISTINCT CASE WHEN b.status = '%[4]s' AND NOT (%[6]s) THEN %[1]s END) AS cancelled_bookings,
+		COUNT(DISTINCT CASE WHEN %[6]s THEN %[1]s END) AS expired_bookings,
+		COUNT(DISTINCT CASE WHEN b.status = '%[5]s' THEN %[1]s END) AS failed_bookings`,
+		bookingKey,
+		models.BookingStatusConfirmed,
+		models.BookingStatusPending,
+		models.BookingStatusCancelled,
+		models.BookingStatusFailed,
+		expiredBookingCondition,
+	)
+}
+
+func applySalesReportFilter(q *gorm.DB, filter *models.SalesReportFilter) *gorm.DB {
+	q = q.Where("b.deleted_at IS NULL")
+	if filter.ConcertID != nil {
+		q = q.Where("b.concert_id = ?", *filter.ConcertID)
+	}
+	if filter.From != nil {
+		q = q.Where("b.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("b.created_at < ?", *filter.To)
+	}
+	return q
+}
+
+// bookingsWithTicketCounts joins every booking with the number of seats linked to it.
+func (r *ReportRepository) bookingsWithTicketCounts(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Table("bookings b").
+		Joins("LEFT JOIN (SELECT booking_id, COUNT(*) AS ticket_count FROM booking_seats GROUP BY booking_id) bs ON bs.booking_id = b.id")
+}
+
+func bookingRevenueAggregates() string {
+	return fmt.Sprintf(`COALESCE(SUM(CASE WHEN b.status = '%[1]s' THEN bs.ticket_count END), 0) AS tickets_sold,
+		COALESCE(SUM(CASE WHEN b.status = '%[1]s' THEN b.total_price END), 0) AS revenue,
+		COALESCE(SUM(CASE WHEN b.status = '%[1]s' THEN b.total_price END) / NULLIF(COUNT(CASE WHEN b.status = '%[1]s' THEN b.id END), 0), 0) AS average_order_value`,
+		models.BookingStatusConfirmed,
+	)
+}
+
+func (r *ReportRepository) GetSalesByConcert(ctx context.Context, filter *models.SalesReportFilter) ([]models.SalesReportRow, error) {
+	var rows []models.SalesReportRow
+	q := r.bookingsWithTicketCounts(ctx).
+		Select("b.concert_id AS concert_id, c.name AS concert_name, " + bookingStatusAggregates("b.id") + ", " + bookingRevenueAggregates()).
+		Joins("JOIN concerts c ON c.id = b.concert_id")
+	err := applySalesReportFilter(q, filter).
+		Group("b.concert_id, c.name").
+		Order("revenue DESC, b.concert_id").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *ReportRepository) GetSalesByDay(ctx context.Context, filter *models.SalesReportFilter) ([]models.SalesReportRow, error) {
+	var rows []models.SalesReportRow
+	q := r.bookingsWithTicketCounts(ctx).
+		Select("DATE_FORMAT(b.created_at, '%Y-%m-%d') AS day, " + bookingStatusAggregates("b.id") + ", " + bookingRevenueAggregates())
+	err := applySalesReportFilter(q, filter).
+		Group("day").
+		Order("day").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetSalesByTicketClass aggregates per seat, so revenue is the sum of the class
+// prices of the seats sold and a booking spanning several classes is counted once
+// in each of them.
+func (r *ReportRepository) GetSalesByTicketClass(ctx context.Context, filter *models.SalesReportFilter) ([]models.SalesReportRow, error) {
+	var rows []models.SalesReportRow
+	revenue := fmt.Sprintf(`COALESCE(SUM(CASE WHEN b.status = '%[1]s' THEN 1 ELSE 0 END), 0) AS tickets_sold,
+		COALESCE(SUM(CASE WHEN b.status = '%[1]s' THEN tc.price END), 0) AS revenue,
+		COALESCE(SUM(CASE WHEN b.status = '%[1]s' THEN tc.price END) / NULLIF(COUNT(DISTINCT CASE WHEN b.status = '%[1]s' THEN b.id END), 0), 0) AS average_order_value`,
+		models.BookingStatusConfirmed,
+	)
+	q := r.DB.WithContext(ctx).Table("booking_seats bs").
+		Select("tc.concert_id AS concert_id, c.name AS concert_name, tc.id AS ticket_class_id, tc.name AS ticket_class_name, " + bookingStatusAggregates("b.id") + ", " + revenue).
+		Joins("JOIN bookings b ON b.id = bs.booking_id").
+		Joins("JOIN seats s ON s.id = bs.seat_id").
+		Joins("JOIN ticket_classes tc ON tc.id = s.ticket_class_id").
+		Joins("JOIN concerts c ON c.id = tc.concert_id")
+	err := applySalesReportFilter(q, filter).
+		Group("tc.concert_id, c.name, tc.id, tc.name").
+		Order("tc.concert_id, revenue DESC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *ReportRepository) GetSalesTotals(ctx context.Context, filter *models.SalesReportFilter) (*models.SalesReportTotals, error) {
+	var totals models.SalesReportTotals
+	q := r.bookingsWithTicketCounts(ctx).
+		Select(bookingStatusAggregates("b.id") + ", " + bookingRevenueAggregates())
+	err := applySalesReportFilter(q, filter).Scan(&totals).Error
+	return &totals, err
+}
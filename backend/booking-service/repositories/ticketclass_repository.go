@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+
 	"backend/booking-service/models"
 
 	"gorm.io/gorm"
@@ -14,22 +16,22 @@ func NewTicketClassRepository(db *gorm.DB) *TicketClassRepository {
 	return &TicketClassRepository{DB: db}
 }
 
-func (r *TicketClassRepository) CreateTicketClass(db *gorm.DB, ticketClass *models.TicketClass) error {
-	return db.Create(ticketClass).Error
+func (r *TicketClassRepository) CreateTicketClass(ctx context.Context, ticketClass *models.TicketClass) error {
+	return r.DB.WithContext(ctx).Create(ticketClass).Error
 }
 
-func (r *TicketClassRepository) GetTicketClassesByConcertID(concertID uint) ([]models.TicketClass, error) {
+func (r *TicketClassRepository) GetTicketClassesByConcertID(ctx context.Context, concertID uint) ([]models.TicketClass, error) {
 	var ticketClasses []models.TicketClass
-	err := r.DB.Where("concert_id = ?", concertID).Find(&ticketClasses).Error
+	err := r.DB.WithContext(ctx).Where("concert_id = ?", concertID).Find(&ticketClasses).Error
 	return ticketClasses, err
 }
 
-func (r *TicketClassRepository) GetTicketClassByID(id uint) (*models.TicketClass, error) {
+func (r *TicketClassRepository) GetTicketClassByID(ctx context.Context, id uint) (*models.TicketClass, error) {
 	var ticketClass models.TicketClass
-	err := r.DB.First(&ticketClass, id).Error
+	err := r.DB.WithContext(ctx).First(&ticketClass, id).Error
 	return &ticketClass, err
 }
 
-func (r *TicketClassRepository) UpdateTicketClass(db *gorm.DB, ticketClass *models.TicketClass) error {
-	return db.Save(ticketClass).Error
+func (r *TicketClassRepository) UpdateTicketClass(ctx context.Context, ticketClass *models.TicketClass) error {
+	return r.DB.WithContext(ctx).Save(ticketClass).Error
 }
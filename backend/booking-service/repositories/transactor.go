@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type GormTransactor struct {
+	DB *gorm.DB
+}
+
+func NewGormTransactor(db *gorm.DB) *GormTransactor {
+	return &GormTransactor{DB: db}
+}
+
+// NewStores returns the GORM repositories bound to db, which may be a transaction.
+func NewStores(db *gorm.DB) Stores {
+	return Stores{
+		Bookings:      NewBookingRepository(db),
+		Concerts:      NewConcertRepository(db),
+		Seats:         NewSeatRepository(db),
+		TicketClasses: NewTicketClassRepository(db),
+		Buyers:        NewBuyerRepository(db),
+		TicketHolders: NewTicketHolderRepository(db),
+	}
+}
+
+func (t *GormTransactor) WithinTransaction(ctx context.Context, fn func(tx Stores) error) error {
+	return t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewStores(tx))
+	})
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend/booking-service/utils"
+)
+
+// ListenAndServe serves the router on addr until ctx is cancelled. It then
+// stops accepting connections and waits up to timeout for in-flight requests
+// to finish, so a deploy does not cut them off.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, timeout time.Duration) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.Router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	utils.LogInfo("Shutting down HTTP server, waiting up to %s for in-flight requests...", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		httpServer.Close()
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	utils.LogInfo("HTTP server stopped.")
+	return nil
+}
@@ -0,0 +1,220 @@
+// Package server wires the booking service's repositories, services and
+// controllers into a Gin router. main connects it to MySQL, Redis and RabbitMQ;
+// the end-to-end tests run it on in-memory stand-ins.
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend/booking-service/config"
+	"backend/booking-service/controllers"
+	"backend/booking-service/middlewares"
+	"backend/booking-service/repositories"
+	"backend/booking-service/services"
+	"backend/booking-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"gorm.io/gorm"
+)
+
+// Dependencies is the infrastructure the service runs on. Redis-backed
+// middlewares and the event hubs use utils.RedisClient, which must be
+// initialised before New is called.
+type Dependencies struct {
+	DB     *gorm.DB
+	Cache  services.SeatCache
+	Events services.EventPublisher
+	Bus    services.MessageBus
+	Clock  services.Clock
+}
+
+type Server struct {
+	Router          *gin.Engine
+	ConcertService  *services.ConcertService
+	BookingService  *services.BookingService
+	AvailabilityHub *services.AvailabilityHub
+	BookingEventHub *services.BookingEventHub
+	LeaderElector   *services.LeaderElector
+}
+
+func New(cfg *config.Config, deps Dependencies) *Server {
+	concertRepo := repositories.NewConcertRepository(deps.DB)
+	seatRepo := repositories.NewSeatRepository(deps.DB)
+	bookingRepo := repositories.NewBookingRepository(deps.DB)
+	reportRepo := repositories.NewReportRepository(deps.DB)
+	transactor := repositories.NewGormTransactor(deps.DB)
+
+	s := &Server{
+		ConcertService:  services.NewConcertService(concertRepo, seatRepo, transactor, deps.Cache, deps.Events, deps.Bus),
+		BookingService:  services.NewBookingService(bookingRepo, concertRepo, transactor, deps.Cache, deps.Events, deps.Clock, cfg.PaymentServiceAPIURL),
+		AvailabilityHub: services.NewAvailabilityHub(),
+		BookingEventHub: services.NewBookingEventHub(),
+		LeaderElector:   services.NewLeaderElector(cfg.LeaderLeaseTTL),
+	}
+	reportService := services.NewReportService(reportRepo)
+	healthService := services.NewHealthService(healthChecks(deps), dbStats(deps.DB), s.LeaderElector)
+	queueService := services.NewQueueService()
+
+	concertController := controllers.NewConcertController(s.ConcertService)
+	bookingController := controllers.NewBookingController(s.BookingService)
+	reportController := controllers.NewReportController(reportService)
+	queueController := controllers.NewQueueController(queueService)
+	jobController := controllers.NewJobController(s.LeaderElector)
+	healthController := controllers.NewHealthController(healthService)
+	availabilityController := controllers.NewAvailabilityController(s.ConcertService, s.AvailabilityHub, middlewares.AllowedOrigin)
+	bookingEventsController := controllers.NewBookingEventsController(s.BookingService, s.BookingEventHub)
+
+	router := gin.New()
+	router.RedirectTrailingSlash = false
+
+	router.Use(otelgin.Middleware(utils.ServiceName))
+	router.Use(middlewares.RequestIDMiddleware())
+	router.Use(middlewares.RequestLoggerMiddleware())
+	router.Use(middlewares.MetricsMiddleware())
+	router.Use(middlewares.CORSMiddleware())
+	router.Use(gin.Recovery())
+
+	// The probes are registered before the rate limiter, which needs Redis, so
+	// they keep answering when Redis is down.
+	router.GET("/healthz", healthController.Healthz)
+	router.GET("/readyz", healthController.Readyz)
+
+	// Only the proxies in TRUSTED_PROXIES may name the client in
+	// X-Forwarded-For; otherwise anyone could pick the IP they are rate
+	// limited under.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		utils.LogError("Invalid TRUSTED_PROXIES, trusting no proxies: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+
+	// Only the payment service calls the internal routes, with signed
+	// requests. They are registered before the rate limiter too: every call
+	// comes from the payment service's address, and each payment makes two,
+	// so the per-IP default limit would refuse them under load.
+	internal := router.Group("/api/v1/internal")
+	internal.Use(middlewares.InternalAuthMiddleware(cfg.InternalAuthMaxSkew, "payment-service"))
+	{
+		internal.PUT("/bookings/:id/status", bookingController.UpdateBookingStatusInternal)
+		internal.PUT("/bookings/:id/payment-card", bookingController.ClaimPaymentCardInternal)
+	}
+
+	router.Use(rateLimit(cfg, "default", cfg.RateLimitDefault))
+
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.NewHandler()))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	v1 := router.Group("/api/v1")
+	{
+
+		v1.GET("/concerts", concertController.GetConcerts)
+		v1.GET("/concerts/:id", concertController.GetConcertByID)
+		v1.GET("/concerts/:id/seats", concertController.GetConcertSeats)
+		v1.GET("/concerts/:id/availability/stream", availabilityController.StreamAvailabilitySSE)
+		v1.GET("/concerts/:id/availability/ws", availabilityController.StreamAvailabilityWS)
+
+		adminConcerts := v1.Group("/admin/concerts")
+		adminConcerts.Use(middlewares.AuthMiddleware())
+		adminConcerts.Use(middlewares.AdminAuthMiddleware())
+		{
+			adminConcerts.POST("/", concertController.CreateConcert)
+		}
+
+		adminReports := v1.Group("/admin/reports")
+		adminReports.Use(middlewares.AuthMiddleware())
+		adminReports.Use(middlewares.AdminAuthMiddleware())
+		{
+			adminReports.GET("/sales", reportController.GetSalesReport)
+		}
+
+		adminQueues := v1.Group("/admin/queues")
+		adminQueues.Use(middlewares.AuthMiddleware())
+		adminQueues.Use(middlewares.AdminAuthMiddleware())
+		{
+			adminQueues.GET("/:queue/dead-letters", queueController.GetDeadLetters)
+			adminQueues.POST("/:queue/dead-letters/replay", queueController.ReplayDeadLetters)
+		}
+
+		adminJobs := v1.Group("/admin/jobs")
+		adminJobs.Use(middlewares.AuthMiddleware())
+		adminJobs.Use(middlewares.AdminAuthMiddleware())
+		{
+			adminJobs.GET("/status", jobController.GetJobStatus)
+		}
+
+		adminBookings := v1.Group("/admin/bookings")
+		adminBookings.Use(middlewares.AuthMiddleware())
+		adminBookings.Use(middlewares.AdminAuthMiddleware())
+		{
+			adminBookings.POST("/lookup", bookingController.LookupBookingsByKTP)
+			adminBookings.POST("/:id/check-in", bookingController.CheckInBooking)
+			adminBookings.GET("/identity-clusters", bookingController.GetIdentityClusters)
+		}
+
+		adminDiagnostics := v1.Group("/admin/diagnostics")
+		adminDiagnostics.Use(middlewares.AuthMiddleware())
+		adminDiagnostics.Use(middlewares.AdminAuthMiddleware())
+		{
+			adminDiagnostics.GET("", healthController.GetDiagnostics)
+		}
+
+		bookings := v1.Group("/bookings")
+		bookings.Use(middlewares.AuthMiddleware())
+		{
+			bookings.POST("/", rateLimit(cfg, "booking", cfg.RateLimitBooking), middlewares.IdempotencyMiddleware(cfg.IdempotencyKeyTTL), bookingController.CreateBooking)
+			bookings.GET("/my", bookingController.GetMyBookings)
+			bookings.GET("/:id", bookingController.GetBookingByID)
+			bookings.GET("/:id/events", bookingEventsController.StreamBookingEvents)
+			bookings.PUT("/:id/cancel", bookingController.CancelBooking)
+		}
+
+	}
+
+	s.Router = router
+	return s
+}
+
+// healthChecks lists the dependencies /readyz checks.
+func healthChecks(deps Dependencies) []services.HealthCheck {
+	return []services.HealthCheck{
+		{Name: "mysql", Check: func(ctx context.Context) error {
+			sqlDB, err := deps.DB.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}},
+		{Name: "redis", Check: func(ctx context.Context) error {
+			if utils.RedisClient == nil {
+				return errors.New("redis client not initialized")
+			}
+			return utils.RedisClient.Ping(ctx).Err()
+		}},
+		{Name: "rabbitmq", Check: deps.Bus.Ping},
+	}
+}
+
+func dbStats(db *gorm.DB) func() sql.DBStats {
+	return func() sql.DBStats {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return sql.DBStats{}
+		}
+		return sqlDB.Stats()
+	}
+}
+
+// rateLimit builds the middleware for one of the rate limits in cfg.
+func rateLimit(cfg *config.Config, name string, limit config.RateLimit) gin.HandlerFunc {
+	return middlewares.RateLimitMiddleware(middlewares.RateLimitPolicy{
+		Name:     name,
+		Requests: limit.Requests,
+		Per:      limit.Per,
+		FailOpen: cfg.RateLimitFailOpen,
+	})
+}
@@ -18,58 +18,86 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	bookingExpiryPollInterval = 200 * time.Millisecond
+	bookingExpiryClaimTimeout = 30 * time.Second
+	bookingExpiryBatchSize    = 100
+	bookingHoldDuration       = 15 * time.Minute
+)
+
 type BookingService struct {
-	BookingRepo          *repositories.BookingRepository
-	ConcertRepo          *repositories.ConcertRepository
-	SeatRepo             *repositories.SeatRepository
-	TicketClassRepo      *repositories.TicketClassRepository
-	BuyerRepo            *repositories.BuyerRepository
-	TicketHolderRepo     *repositories.TicketHolderRepository
+	BookingRepo          repositories.BookingStore
+	ConcertRepo          repositories.ConcertStore
+	Transactor           repositories.Transactor
+	Cache                SeatCache
+	Events               EventPublisher
+	Clock                Clock
 	PaymentServiceAPIURL string
 }
 
-func NewBookingService(bRepo *repositories.BookingRepository, cRepo *repositories.ConcertRepository, sRepo *repositories.SeatRepository, tcRepo *repositories.TicketClassRepository, buyerRepo *repositories.BuyerRepository, ticketHolderRepo *repositories.TicketHolderRepository, paymentServiceAPIURL string) *BookingService {
+func NewBookingService(bRepo repositories.BookingStore, cRepo repositories.ConcertStore, transactor repositories.Transactor, cache SeatCache, events EventPublisher, clock Clock, paymentServiceAPIURL string) *BookingService {
 	return &BookingService{
 		BookingRepo:          bRepo,
 		ConcertRepo:          cRepo,
-		SeatRepo:             sRepo,
-		TicketClassRepo:      tcRepo,
-		BuyerRepo:            buyerRepo,
-		TicketHolderRepo:     ticketHolderRepo,
+		Transactor:           transactor,
+		Cache:                cache,
+		Events:               events,
+		Clock:                clock,
 		PaymentServiceAPIURL: paymentServiceAPIURL,
 	}
 }
 
 func (s *BookingService) CreateBooking(ctx context.Context, userID uint, req *models.CreateBookingRequest) (*models.BookingResponse, error) {
+	ctx = utils.WithConcertID(ctx, req.ConcertID)
 
 	totalRequestedTickets := 0
 	for _, tc := range req.TicketsByClass {
 		totalRequestedTickets += tc.Quantity
 	}
 	if totalRequestedTickets == 0 || totalRequestedTickets > 5 {
-		return nil, fmt.Errorf("invalid total number of tickets requested: %d (must be between 1 and 5)", totalRequestedTickets)
+		return nil, ErrInvalidTicketQuantity.Withf("invalid total number of tickets requested: %d (must be between 1 and 5)", totalRequestedTickets)
 	}
 
-	activeBookings, err := s.BookingRepo.GetUserActiveBookingsForConcert(userID, req.ConcertID)
+	// The ticket holder attends the concert, or the buyer when no holder is
+	// named, so theirs is the age an age-restricted concert is checked against.
+	attendee := "buyer"
+	attendeeNIK, err := s.parseKTP(attendee, req.BuyerInfo.KTPNumber)
+	if err != nil {
+		return nil, err
+	}
+	if req.TicketHolderInfo != nil {
+		attendee = "ticket holder"
+		if attendeeNIK, err = s.parseKTP(attendee, req.TicketHolderInfo.KTPNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	activeBookings, err := s.BookingRepo.GetUserActiveBookingsForConcert(ctx, userID, req.ConcertID)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		utils.LogError("DB error checking active bookings for user %d, concert %d: %v", userID, req.ConcertID, err)
-		return nil, errors.New("failed to check existing bookings")
+		utils.LogErrorContext(ctx, "DB error checking active bookings for user %d, concert %d: %v", userID, req.ConcertID, err)
+		return nil, newInternalError("failed to check existing bookings", err)
 	}
 	if len(activeBookings) > 0 {
-		return nil, errors.New("you already have an active (pending or confirmed) booking for this concert. Please cancel your existing booking to proceed")
+		return nil, ErrActiveBookingExists
 	}
 
-	concert, err := s.ConcertRepo.GetConcertByID(req.ConcertID)
+	concert, err := s.ConcertRepo.GetConcertByID(ctx, req.ConcertID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("concert not found")
+			return nil, ErrConcertNotFound
 		}
-		utils.LogError("DB error getting concert %d for booking: %v", req.ConcertID, err)
-		return nil, errors.New("failed to get concert details")
+		utils.LogErrorContext(ctx, "DB error getting concert %d for booking: %v", req.ConcertID, err)
+		return nil, newInternalError("failed to get concert details", err)
 	}
 
 	if concert.Status != models.ConcertStatusActive {
-		return nil, fmt.Errorf("concert '%s' is not active for booking (status: %s)", concert.Name, concert.Status)
+		return nil, ErrConcertNotActive.Withf("concert '%s' is not active for booking (status: %s)", concert.Name, concert.Status)
+	}
+
+	if concert.MinimumAge > 0 {
+		if age := attendeeNIK.AgeOn(concert.Date); age < concert.MinimumAge {
+			return nil, ErrAgeRestricted.Withf("concert '%s' is for ages %d and over; the %s will be %d on the concert date", concert.Name, concert.MinimumAge, attendee, age)
+		}
 	}
 
 	concertTicketClassesMap := make(map[uint]models.TicketClass)
@@ -77,29 +105,42 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID uint, req *mo
 		concertTicketClassesMap[tc.ID] = tc
 	}
 
+	now := s.Clock.Now()
 	var seatsToBook []models.Seat
 	var totalPrice float64 = 0.0
+	// reserved tracks what was taken from the Redis counters so every failure
+	// below can give exactly that back.
+	reserved := make(map[uint]int)
 
 	for _, tcRequest := range req.TicketsByClass {
 		ticketClass, exists := concertTicketClassesMap[tcRequest.TicketClassID]
 		if !exists {
-			return nil, fmt.Errorf("ticket class ID %d not found for concert %d", tcRequest.TicketClassID, req.ConcertID)
+			s.releaseCachedSeats(ctx, concert.ID, reserved)
+			return nil, ErrTicketClassNotFound.Withf("ticket class ID %d not found for concert %d", tcRequest.TicketClassID, req.ConcertID)
 		}
 
 		if tcRequest.Quantity <= 0 {
 			continue
 		}
 
-		_, err = utils.DecreaseAvailableSeatsAtomically(ctx, ticketClass.ID, tcRequest.Quantity)
+		_, err = s.Cache.DecreaseAvailableSeats(ctx, concert.ID, ticketClass.ID, tcRequest.Quantity)
 		if err != nil {
-			return nil, fmt.Errorf("failed to reserve tickets for class '%s': %v", ticketClass.Name, err)
+			s.releaseCachedSeats(ctx, concert.ID, reserved)
+			switch {
+			case errors.Is(err, utils.ErrNotEnoughSeats):
+				return nil, ErrNotEnoughSeats.Withf("not enough seats available in class '%s'", ticketClass.Name).Wrap(err)
+			case errors.Is(err, utils.ErrSeatContention):
+				return nil, ErrSeatReservationContended.Wrap(err)
+			}
+			return nil, newInternalError(fmt.Sprintf("failed to reserve tickets for class '%s'", ticketClass.Name), err)
 		}
+		reserved[ticketClass.ID] += tcRequest.Quantity
 
 		for i := 0; i < tcRequest.Quantity; i++ {
 			seatsToBook = append(seatsToBook, models.Seat{
 				ConcertID:     concert.ID,
 				TicketClassID: ticketClass.ID,
-				SeatNumber:    fmt.Sprintf("%s-%d-%s", ticketClass.Name, time.Now().UnixNano()/1000000, uuid.New().String()[:6]),
+				SeatNumber:    fmt.Sprintf("%s-%d-%s", ticketClass.Name, now.UnixNano()/1000000, uuid.New().String()[:6]),
 				Status:        models.SeatStatusReserved,
 				UserID:        &userID,
 			})
@@ -107,159 +148,99 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID uint, req *mo
 		totalPrice += ticketClass.Price * float64(tcRequest.Quantity)
 	}
 
-	newUUID := uuid.New().String()
+	expiresAt := now.Add(bookingHoldDuration)
 	booking := &models.Booking{
-		ID:         newUUID,
+		ID:         uuid.New().String(),
 		UserID:     userID,
 		ConcertID:  concert.ID,
 		TotalPrice: totalPrice,
 		Status:     models.BookingStatusPending,
-		ExpiresAt:  func() *time.Time { t := time.Now().Add(15 * time.Minute); return &t }(),
-	}
-
-	tx := s.BookingRepo.DB.Begin()
-	if tx.Error != nil {
-		utils.LogError("Failed to begin DB transaction for booking: %v", tx.Error)
-		for _, tcRequest := range req.TicketsByClass {
-			utils.IncreaseAvailableSeatsAtomically(ctx, tcRequest.TicketClassID, tcRequest.Quantity)
-		}
-		return nil, errors.New("failed to initiate booking transaction")
-	}
-
-	tempSeatRepo := &repositories.SeatRepository{DB: tx}
-	tempBookingRepo := &repositories.BookingRepository{DB: tx}
-	tempTicketClassRepo := &repositories.TicketClassRepository{DB: tx}
-	tempBuyerRepo := &repositories.BuyerRepository{DB: tx}
-	tempTicketHolderRepo := &repositories.TicketHolderRepository{DB: tx}
-
-	if err := tempSeatRepo.CreateSeats(tx, seatsToBook); err != nil {
-		tx.Rollback()
-		for _, tcRequest := range req.TicketsByClass {
-			utils.IncreaseAvailableSeatsAtomically(ctx, tcRequest.TicketClassID, tcRequest.Quantity)
-		}
-		utils.LogError("Failed to create new seats for booking: %v", err)
-		return nil, errors.New("failed to create seats for booking")
+		ExpiresAt:  &expiresAt,
 	}
+	ctx = utils.WithBookingID(ctx, booking.ID)
 
-	seatIDs := make([]string, len(seatsToBook))
-	for i, seat := range seatsToBook {
-		seatIDs[i] = fmt.Sprintf("%d", seat.ID)
+	identities := map[string]string{
+		models.IdentityBuyerKTP: req.BuyerInfo.KTPNumber,
+		models.IdentityPhone:    req.BuyerInfo.PhoneNumber,
 	}
-	booking.SeatIDs = strings.Join(seatIDs, ",")
-
-	if err := tempBookingRepo.CreateBooking(booking); err != nil {
-		tx.Rollback()
-		for _, tcRequest := range req.TicketsByClass {
-			utils.IncreaseAvailableSeatsAtomically(ctx, tcRequest.TicketClassID, tcRequest.Quantity)
-		}
-		utils.LogError("Failed to create booking record in DB: %v", err)
-		return nil, errors.New("failed to create booking record")
+	if req.TicketHolderInfo != nil {
+		identities[models.IdentityHolderKTP] = req.TicketHolderInfo.KTPNumber
 	}
 
 	buyer := models.Buyer{
-		BookingID:   booking.ID,
 		FullName:    req.BuyerInfo.FullName,
 		PhoneNumber: req.BuyerInfo.PhoneNumber,
 		Email:       req.BuyerInfo.Email,
 		KTPNumber:   req.BuyerInfo.KTPNumber,
 	}
-	if err := tempBuyerRepo.CreateBuyer(tx, &buyer); err != nil {
-		tx.Rollback()
-		for _, tcRequest := range req.TicketsByClass {
-			utils.IncreaseAvailableSeatsAtomically(ctx, tcRequest.TicketClassID, tcRequest.Quantity)
-		}
-		utils.LogError("Failed to create buyer info for booking %s: %v", booking.ID, err)
-		return nil, errors.New("failed to save buyer information")
-	}
-
 	var ticketHolder models.TicketHolder
-	if req.TicketHolderInfo != nil {
-		ticketHolder = models.TicketHolder{
-			BookingID: booking.ID,
-			FullName:  req.TicketHolderInfo.FullName,
-			KTPNumber: req.TicketHolderInfo.KTPNumber,
-		}
-		if err := tempTicketHolderRepo.CreateTicketHolder(tx, &ticketHolder); err != nil {
-			tx.Rollback()
-			for _, tcRequest := range req.TicketsByClass {
-				utils.IncreaseAvailableSeatsAtomically(ctx, tcRequest.TicketClassID, tcRequest.Quantity)
-			}
-			utils.LogError("Failed to create ticket holder info for booking %s: %v", booking.ID, err)
-			return nil, errors.New("failed to save ticket holder information")
-		}
-	}
-
-	tcQuantities := make(map[uint]int)
-	for _, item := range req.TicketsByClass {
-		tcQuantities[item.TicketClassID] = item.Quantity
-	}
 
-	for tcID, qty := range tcQuantities {
-		ticketClass, exists := concertTicketClassesMap[tcID]
-		if !exists {
-			utils.LogError("TicketClass %d not found in concert for update", tcID)
-			continue
+	err = s.Transactor.WithinTransaction(ctx, func(tx repositories.Stores) error {
+		if err := tx.Seats.CreateSeats(ctx, seatsToBook); err != nil {
+			utils.LogErrorContext(ctx, "Failed to create new seats for booking: %v", err)
+			return newInternalError("failed to create seats for booking", err)
 		}
-		ticketClass.AvailableSeatsInClass -= qty
 
-		if err := tempTicketClassRepo.UpdateTicketClass(tx, &ticketClass); err != nil {
-			tx.Rollback()
-			for _, tcRequest := range req.TicketsByClass {
-				utils.IncreaseAvailableSeatsAtomically(ctx, tcRequest.TicketClassID, tcRequest.Quantity)
-			}
-			utils.LogError("Failed to update available seats for ticket class %d: %v", tcID, err)
-			return nil, errors.New("failed to update ticket class availability")
+		seatIDs := make([]string, len(seatsToBook))
+		for i, seat := range seatsToBook {
+			seatIDs[i] = fmt.Sprintf("%d", seat.ID)
 		}
-	}
-
-	tx.Commit()
-
-	go func() {
-		paymentReq := struct {
-			BookingID     string  `json:"booking_id"`
-			Amount        float64 `json:"amount"`
-			PaymentMethod string  `json:"payment_method"`
-		}{
-			BookingID:     booking.ID,
-			Amount:        booking.TotalPrice,
-			PaymentMethod: "credit_card",
+		booking.SeatIDs = strings.Join(seatIDs, ",")
+		booking.Seats = make([]*models.Seat, len(seatsToBook))
+		for i := range seatsToBook {
+			booking.Seats[i] = &seatsToBook[i]
 		}
-		jsonBody, err := json.Marshal(paymentReq)
-		if err != nil {
-			utils.LogError("Failed to marshal payment request for booking %s: %v", booking.ID, err)
-			return
+
+		if err := tx.Bookings.CreateBooking(ctx, booking); err != nil {
+			utils.LogErrorContext(ctx, "Failed to create booking record in DB: %v", err)
+			return newInternalError("failed to create booking record", err)
 		}
 
-		callCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+		if err := claimPurchaseLimits(ctx, tx, concert, booking, totalRequestedTickets, identities); err != nil {
+			return err
+		}
 
-		req, err := http.NewRequestWithContext(callCtx, "POST", s.PaymentServiceAPIURL+"/payments", bytes.NewBuffer(jsonBody))
-		if err != nil {
-			utils.LogError("Failed to create HTTP request to Payment Service for booking %s: %v", booking.ID, err)
-			return
+		buyer.BookingID = booking.ID
+		if err := tx.Buyers.CreateBuyer(ctx, &buyer); err != nil {
+			utils.LogErrorContext(ctx, "Failed to create buyer info for booking %s: %v", booking.ID, err)
+			return newInternalError("failed to save buyer information", err)
 		}
-		req.Header.Set("Content-Type", "application/json")
 
-		client := &http.Client{Timeout: 10 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
-			utils.LogError("Failed to send payment request for booking %s to Payment Service: %v", booking.ID, err)
-			return
+		if req.TicketHolderInfo != nil {
+			ticketHolder = models.TicketHolder{
+				BookingID: booking.ID,
+				FullName:  req.TicketHolderInfo.FullName,
+				KTPNumber: req.TicketHolderInfo.KTPNumber,
+			}
+			if err := tx.TicketHolders.CreateTicketHolder(ctx, &ticketHolder); err != nil {
+				utils.LogErrorContext(ctx, "Failed to create ticket holder info for booking %s: %v", booking.ID, err)
+				return newInternalError("failed to save ticket holder information", err)
+			}
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			var errorBody map[string]string
-			if err := json.NewDecoder(resp.Body).Decode(&errorBody); err != nil {
-				utils.LogError("Payment Service returned non-200 status for booking %s: %d, no readable error body", booking.ID, resp.StatusCode)
-				return
+		for tcID, qty := range reserved {
+			ticketClass := concertTicketClassesMap[tcID]
+			ticketClass.AvailableSeatsInClass -= qty
+
+			if err := tx.TicketClasses.UpdateTicketClass(ctx, &ticketClass); err != nil {
+				utils.LogErrorContext(ctx, "Failed to update available seats for ticket class %d: %v", tcID, err)
+				return newInternalError("failed to update ticket class availability", err)
 			}
-			utils.LogError("Payment Service returned non-200 status for booking %s: %d, error: %s", booking.ID, resp.StatusCode, errorBody["error"])
-			return
 		}
+		return nil
+	})
+	if err != nil {
+		s.releaseCachedSeats(ctx, concert.ID, reserved)
+		return nil, transactionError(err, "failed to save booking")
+	}
 
-		utils.LogInfo("Payment request sent to Payment Service for booking %s", booking.ID)
-	}()
+	utils.RecordBookingEvent(utils.BookingEventCreated)
+	s.publishSeatStatusChanges(ctx, concert.ID, booking.Seats)
+	if err := s.Cache.ScheduleBookingExpiry(ctx, booking.ID, *booking.ExpiresAt); err != nil {
+		utils.LogErrorContext(ctx, "Booking %s will only be released by the expiry sweep: %v", booking.ID, err)
+	}
+
+	go s.requestPayment(context.WithoutCancel(ctx), booking)
 
 	var bookedSeatResponses []models.SeatResponse
 	for _, seat := range seatsToBook {
@@ -289,364 +270,660 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID uint, req *mo
 	return &resp, nil
 }
 
+// parseKTP decodes a KTP number from a booking request, naming whose it is in
+// the error.
+func (s *BookingService) parseKTP(whose, ktpNumber string) (models.NIKInfo, error) {
+	info, err := utils.ParseNIK(ktpNumber, s.Clock.Now())
+	if err != nil {
+		reason := strings.TrimPrefix(err.Error(), utils.ErrInvalidNIK.Error()+": ")
+		return models.NIKInfo{}, ErrInvalidKTPNumber.Withf("%s KTP number is not a valid NIK: %s", whose, reason).Wrap(err)
+	}
+	return info, nil
+}
+
+// requestPayment asks the payment service to charge a new booking. The payment
+// service reports the result back through the internal status endpoint. ctx
+// carries the trace of the booking request but must not be cancelled with it.
+func (s *BookingService) requestPayment(ctx context.Context, booking *models.Booking) {
+	paymentReq := struct {
+		BookingID     string  `json:"booking_id"`
+		Amount        float64 `json:"amount"`
+		PaymentMethod string  `json:"payment_method"`
+	}{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+	}
+	jsonBody, err := json.Marshal(paymentReq)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to marshal payment request for booking %s: %v", booking.ID, err)
+		return
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(callCtx, "POST", s.PaymentServiceAPIURL+"/payments", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to create HTTP request to Payment Service for booking %s: %v", booking.ID, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "booking-payment-"+booking.ID)
+
+	client := utils.TracedHTTPClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to send payment request for booking %s to Payment Service: %v", booking.ID, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var problem models.ProblemDetails
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			utils.LogErrorContext(ctx, "Payment Service returned non-200 status for booking %s: %d, no readable error body", booking.ID, resp.StatusCode)
+			return
+		}
+		utils.LogErrorContext(ctx, "Payment Service returned non-200 status for booking %s: %d, error: %s (%s)", booking.ID, resp.StatusCode, problem.Detail, problem.Code)
+		return
+	}
+
+	utils.LogInfoContext(ctx, "Payment request sent to Payment Service for booking %s", booking.ID)
+}
+
 func (s *BookingService) GetBookingDetails(ctx context.Context, bookingID string, userID uint) (*models.BookingResponse, error) {
-	booking, err := s.BookingRepo.GetBookingByID(bookingID)
+	ctx = utils.WithBookingID(ctx, bookingID)
+	booking, err := s.BookingRepo.GetBookingByID(ctx, bookingID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("booking not found")
+			return nil, ErrBookingNotFound
 		}
-		utils.LogError("DB error getting booking %s: %v", bookingID, err)
-		return nil, errors.New("failed to retrieve booking details")
+		utils.LogErrorContext(ctx, "DB error getting booking %s: %v", bookingID, err)
+		return nil, newInternalError("failed to retrieve booking details", err)
 	}
 
 	if booking.UserID != userID {
-		utils.LogWarning("Unauthorized attempt to view booking %s by user %d. Owned by user %d.", bookingID, userID, booking.UserID)
-		return nil, errors.New("unauthorized: you can only view your own bookings")
+		utils.LogWarningContext(ctx, "Unauthorized attempt to view booking %s by user %d. Owned by user %d.", bookingID, userID, booking.UserID)
+		return nil, ErrBookingAccessDenied.Withf("unauthorized: you can only view your own bookings")
 	}
 
-	var bookedSeatResponses []models.SeatResponse
-	for _, seat := range booking.Seats {
+	resp := toBookingResponse(booking)
+	return &resp, nil
+}
 
-		if seat != nil {
-			bookedSeatResponses = append(bookedSeatResponses, seat.ToSeatResponse())
-		}
+func (s *BookingService) GetBookingsByUserID(ctx context.Context, userID uint) ([]models.BookingResponse, error) {
+	bookings, err := s.BookingRepo.GetBookingsByUserID(ctx, userID)
+	if err != nil {
+		utils.LogErrorContext(ctx, "DB error getting bookings for user %d: %v", userID, err)
+		return nil, newInternalError("failed to retrieve user bookings", err)
 	}
 
-	resp := models.BookingResponse{
-		ID:          booking.ID,
-		UserID:      booking.UserID,
-		ConcertID:   booking.ConcertID,
-		TotalPrice:  booking.TotalPrice,
-		Status:      booking.Status,
-		PaymentID:   booking.PaymentID,
-		ExpiresAt:   booking.ExpiresAt,
-		BookedSeats: bookedSeatResponses,
-		ConcertName: booking.Concert.Name,
-		ConcertDate: booking.Concert.Date,
-		CreatedAt:   booking.CreatedAt,
-		UpdatedAt:   booking.UpdatedAt,
+	var responses []models.BookingResponse
+	for i := range bookings {
+		responses = append(responses, toBookingResponse(&bookings[i]))
 	}
+	return responses, nil
+}
 
-	if booking.Buyer != nil {
-		buyerResp := booking.Buyer.ToBuyerResponse()
-		resp.BuyerInfo = &buyerResp
+// CheckInBooking admits a confirmed booking at the venue. Scanning it again
+// returns the first check-in with AlreadyCheckedIn set, so the scanner can
+// turn away a copied ticket. The holder's age is only shown as a band.
+func (s *BookingService) CheckInBooking(ctx context.Context, bookingID string) (*models.CheckInResponse, error) {
+	ctx = utils.WithBookingID(ctx, bookingID)
+	booking, err := s.BookingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBookingNotFound
+		}
+		utils.LogErrorContext(ctx, "DB error getting booking %s for check-in: %v", bookingID, err)
+		return nil, newInternalError("failed to retrieve booking details", err)
 	}
-	if booking.TicketHolder != nil {
-		ticketHolderResp := booking.TicketHolder.ToTicketHolderResponse()
-		resp.TicketHolderInfo = &ticketHolderResp
+	if booking.Status != models.BookingStatusConfirmed {
+		return nil, ErrBookingNotConfirmed.Withf("booking is %s, only confirmed bookings can be checked in", booking.Status)
 	}
 
+	now := s.Clock.Now()
+	resp := models.CheckInResponse{
+		BookingID:   booking.ID,
+		ConcertID:   booking.ConcertID,
+		ConcertName: booking.Concert.Name,
+		Tickets:     len(booking.Seats),
+		AgeBand:     models.AgeBandUnknown,
+		MinimumAge:  booking.Concert.MinimumAge,
+		CheckedInAt: now,
+	}
+	var holderKTP string
+	switch {
+	case booking.TicketHolder != nil:
+		resp.HolderName, holderKTP = booking.TicketHolder.FullName, booking.TicketHolder.KTPNumber
+	case booking.Buyer != nil:
+		resp.HolderName, holderKTP = booking.Buyer.FullName, booking.Buyer.KTPNumber
+	}
+	if nik, err := utils.ParseNIK(holderKTP, now); err == nil {
+		age := nik.AgeOn(now)
+		resp.AgeBand = models.AgeBand(age)
+		resp.MeetsMinimumAge = age >= booking.Concert.MinimumAge
+	} else {
+		// Bookings from before NIK validation may hold numbers that do not
+		// parse; the scanner then has to check the KTP card itself.
+		utils.LogWarningContext(ctx, "Holder KTP of booking %s is not a valid NIK: %v", booking.ID, err)
+		resp.MeetsMinimumAge = booking.Concert.MinimumAge == 0
+	}
+
+	checkedIn, err := s.BookingRepo.MarkCheckedIn(ctx, booking.ID, now)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to record check-in of booking %s: %v", booking.ID, err)
+		return nil, newInternalError("failed to record check-in", err)
+	}
+	if !checkedIn {
+		// Checked in before, possibly by a scan that raced this one.
+		if booking, err = s.BookingRepo.GetBookingByID(ctx, booking.ID); err != nil {
+			utils.LogErrorContext(ctx, "DB error reloading booking %s after check-in: %v", bookingID, err)
+			return nil, newInternalError("failed to retrieve booking details", err)
+		}
+		if booking.CheckedInAt == nil {
+			return nil, ErrBookingNotConfirmed.Withf("booking is %s, only confirmed bookings can be checked in", booking.Status)
+		}
+		resp.CheckedInAt = *booking.CheckedInAt
+		resp.AlreadyCheckedIn = true
+		utils.LogWarningContext(ctx, "Booking %s scanned again; checked in at %s", booking.ID, booking.CheckedInAt.Format(time.RFC3339))
+	} else {
+		utils.LogInfoContext(ctx, "Booking %s checked in (age band %s)", booking.ID, resp.AgeBand)
+	}
 	return &resp, nil
 }
 
-func (s *BookingService) GetBookingsByUserID(ctx context.Context, userID uint) ([]models.BookingResponse, error) {
-	bookings, err := s.BookingRepo.GetBookingsByUserID(userID)
+// FindBookingsByKTP returns the bookings whose buyer or ticket holder has the
+// KTP number, for support staff.
+func (s *BookingService) FindBookingsByKTP(ctx context.Context, ktpNumber string) ([]models.BookingResponse, error) {
+	bookings, err := s.BookingRepo.GetBookingsByKTP(ctx, ktpNumber)
 	if err != nil {
-		utils.LogError("DB error getting bookings for user %d: %v", userID, err)
-		return nil, errors.New("failed to retrieve user bookings")
+		utils.LogErrorContext(ctx, "DB error looking up bookings by KTP: %v", err)
+		return nil, newInternalError("failed to look up bookings", err)
 	}
 
-	var responses []models.BookingResponse
-	for _, booking := range bookings {
-		var bookedSeatResponses []models.SeatResponse
-		for _, seat := range booking.Seats {
+	responses := make([]models.BookingResponse, 0, len(bookings))
+	for i := range bookings {
+		responses = append(responses, toBookingResponse(&bookings[i]))
+	}
+	return responses, nil
+}
 
-			if seat != nil {
-				bookedSeatResponses = append(bookedSeatResponses, seat.ToSeatResponse())
-			}
+// toBookingResponse converts a booking loaded with its concert, seats, buyer
+// and ticket holder. The buyer and holder details are in full; controllers
+// mask them for callers that may not see them.
+func toBookingResponse(booking *models.Booking) models.BookingResponse {
+	var bookedSeatResponses []models.SeatResponse
+	for _, seat := range booking.Seats {
+		if seat != nil {
+			bookedSeatResponses = append(bookedSeatResponses, seat.ToSeatResponse())
 		}
+	}
 
-		var buyerResp *models.BuyerResponse
-		if booking.Buyer != nil {
-			b := booking.Buyer.ToBuyerResponse()
-			buyerResp = &b
-		}
-		var ticketHolderResp *models.TicketHolderResponse
-		if booking.TicketHolder != nil {
-			th := booking.TicketHolder.ToTicketHolderResponse()
-			ticketHolderResp = &th
-		}
-
-		responses = append(responses, models.BookingResponse{
-			ID:               booking.ID,
-			UserID:           booking.UserID,
-			ConcertID:        booking.ConcertID,
-			TotalPrice:       booking.TotalPrice,
-			Status:           booking.Status,
-			PaymentID:        booking.PaymentID,
-			ExpiresAt:        booking.ExpiresAt,
-			BookedSeats:      bookedSeatResponses,
-			ConcertName:      booking.Concert.Name,
-			ConcertDate:      booking.Concert.Date,
-			BuyerInfo:        buyerResp,
-			TicketHolderInfo: ticketHolderResp,
-			CreatedAt:        booking.CreatedAt,
-			UpdatedAt:        booking.UpdatedAt,
-		})
+	resp := models.BookingResponse{
+		ID:                 booking.ID,
+		UserID:             booking.UserID,
+		ConcertID:          booking.ConcertID,
+		TotalPrice:         booking.TotalPrice,
+		Status:             booking.Status,
+		PaymentID:          booking.PaymentID,
+		ExpiresAt:          booking.ExpiresAt,
+		CheckedInAt:        booking.CheckedInAt,
+		CancellationReason: booking.CancellationReason,
+		BookedSeats:        bookedSeatResponses,
+		ConcertName:        booking.Concert.Name,
+		ConcertDate:        booking.Concert.Date,
+		CreatedAt:          booking.CreatedAt,
+		UpdatedAt:          booking.UpdatedAt,
 	}
-	return responses, nil
+	if booking.Buyer != nil {
+		buyerResp := booking.Buyer.ToBuyerResponse()
+		resp.BuyerInfo = &buyerResp
+	}
+	if booking.TicketHolder != nil {
+		ticketHolderResp := booking.TicketHolder.ToTicketHolderResponse()
+		resp.TicketHolderInfo = &ticketHolderResp
+	}
+	return resp
 }
 
 func (s *BookingService) UpdateBookingStatusFromPayment(ctx context.Context, bookingID string, newStatus string, paymentID uint) error {
-	booking, err := s.BookingRepo.GetBookingByID(bookingID)
+	ctx = utils.WithBookingID(ctx, bookingID)
+	booking, err := s.BookingRepo.GetBookingByID(ctx, bookingID)
 	if err != nil {
-		utils.LogError("Booking %s not found for status update from payment service: %v", bookingID, err)
-		return errors.New("booking not found")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogErrorContext(ctx, "Booking %s not found for status update from payment service: %v", bookingID, err)
+			return ErrBookingNotFound
+		}
+		utils.LogErrorContext(ctx, "DB error getting booking %s for status update from payment service: %v", bookingID, err)
+		return newInternalError("failed to retrieve booking details", err)
 	}
+	previousStatus := booking.Status
+	releaseSeats := false
 
 	switch newStatus {
 	case models.BookingStatusConfirmed:
 		if booking.Status != models.BookingStatusPending {
-			return fmt.Errorf("invalid status transition: booking %s is %s, cannot confirm", bookingID, booking.Status)
+			return ErrInvalidStatusTransition.Withf("invalid status transition: booking %s is %s, cannot confirm", bookingID, booking.Status)
 		}
-		booking.Status = models.BookingStatusConfirmed
 		booking.PaymentID = &paymentID
 		booking.ExpiresAt = nil
 		for _, seat := range booking.Seats {
 			seat.Status = models.SeatStatusBooked
 		}
-		utils.LogInfo("Booking %s status updated to CONFIRMED. PaymentID: %d", bookingID, paymentID)
 
 	case models.BookingStatusFailed:
 		if booking.Status != models.BookingStatusPending {
-			return fmt.Errorf("invalid status transition: booking %s is %s, cannot fail", bookingID, booking.Status)
+			return ErrInvalidStatusTransition.Withf("invalid status transition: booking %s is %s, cannot fail", bookingID, booking.Status)
 		}
-		booking.Status = models.BookingStatusFailed
-
-		for _, seat := range booking.Seats {
-			seat.Status = models.SeatStatusAvailable
-			seat.UserID = nil
-			seat.BookingID = nil
-		}
-
-		classQuantitiesToRevert := make(map[uint]int)
-		for _, seat := range booking.Seats {
-			classQuantitiesToRevert[seat.TicketClassID]++
-		}
-		for tcID, qty := range classQuantitiesToRevert {
-			_, errRedis := utils.IncreaseAvailableSeatsAtomically(ctx, tcID, qty)
-			if errRedis != nil {
-				utils.LogError("Failed to increase available seats in Redis for class %d after payment failure of booking %s: %v", tcID, bookingID, errRedis)
-			}
-		}
-		utils.LogWarning("Booking %s status updated to FAILED. PaymentID: %d. Seats released.", bookingID, paymentID)
+		releaseSeats = true
 
 	case models.BookingStatusCancelled:
 		if booking.Status == models.BookingStatusConfirmed || booking.Status == models.BookingStatusFailed {
-			return fmt.Errorf("invalid status transition: booking %s is %s, cannot be cancelled", bookingID, booking.Status)
-		}
-		booking.Status = models.BookingStatusCancelled
-		for _, seat := range booking.Seats {
-			seat.Status = models.SeatStatusAvailable
-			seat.UserID = nil
-			seat.BookingID = nil
-		}
-
-		classQuantitiesToRevert := make(map[uint]int)
-		for _, seat := range booking.Seats {
-			classQuantitiesToRevert[seat.TicketClassID]++
+			return ErrInvalidStatusTransition.Withf("invalid status transition: booking %s is %s, cannot be cancelled", bookingID, booking.Status)
 		}
-		for tcID, qty := range classQuantitiesToRevert {
-			_, errRedis := utils.IncreaseAvailableSeatsAtomically(ctx, tcID, qty)
-			if errRedis != nil {
-				utils.LogError("Failed to increase available seats in Redis for class %d after cancellation of booking %s: %v", tcID, bookingID, errRedis)
-			}
+		if booking.Status == models.BookingStatusCancelled {
+			utils.LogInfoContext(ctx, "Booking %s is already cancelled, nothing to do.", bookingID)
+			return nil
 		}
-		utils.LogInfo("Booking %s status updated to CANCELLED.", bookingID)
+		releaseSeats = true
 
 	default:
-		return fmt.Errorf("unsupported new booking status: %s", newStatus)
-	}
-
-	tx := s.BookingRepo.DB.Begin()
-	if tx.Error != nil {
-		utils.LogError("Failed to begin DB transaction for booking status update: %v", tx.Error)
-		return errors.New("failed to initiate booking status update transaction")
+		return ErrUnsupportedBookingStatus.Withf("unsupported new booking status: %s", newStatus)
 	}
-	tempBookingRepo := &repositories.BookingRepository{DB: tx}
-	tempSeatRepo := &repositories.SeatRepository{DB: tx}
-	tempTicketClassRepo := &repositories.TicketClassRepository{DB: tx}
 
-	if err := tempSeatRepo.UpdateSeats(booking.Seats); err != nil {
-		tx.Rollback()
-		utils.LogError("Failed to update seat statuses in DB for booking %s: %v", bookingID, err)
-		return errors.New("failed to update seat statuses")
+	booking.Status = newStatus
+	if releaseSeats {
+		releaseBookingSeats(booking)
 	}
+	seatCounts := seatCountsByClass(booking.Seats)
 
-	classQuantitiesToRevert := make(map[uint]int)
-	for _, seat := range booking.Seats {
-		classQuantitiesToRevert[seat.TicketClassID]++
-	}
-	for tcID, qty := range classQuantitiesToRevert {
-		ticketClass, err := tempTicketClassRepo.GetTicketClassByID(tcID)
+	err = s.Transactor.WithinTransaction(ctx, func(tx repositories.Stores) error {
+		// Guard on the status read above: an expiry or cancellation that won the
+		// race must not be overwritten by a late payment result.
+		transitioned, err := tx.Bookings.TransitionBookingStatus(ctx, bookingID, previousStatus, newStatus)
 		if err != nil {
-			utils.LogError("Failed to get TicketClass %d for cancellation revert: %v", tcID, err)
-			continue
+			utils.LogErrorContext(ctx, "Failed to update booking %s status to %s: %v", bookingID, newStatus, err)
+			return newInternalError("failed to update booking status", err)
 		}
-		ticketClass.AvailableSeatsInClass += qty
-		if err := tempTicketClassRepo.UpdateTicketClass(tx, ticketClass); err != nil {
-			utils.LogError("Failed to update TicketClass %d for cancellation revert: %v", tcID, err)
+		if !transitioned {
+			return ErrInvalidStatusTransition.Withf("invalid status transition: booking %s is no longer %s, cannot set it to %s", bookingID, previousStatus, newStatus)
+		}
+
+		if err := tx.Seats.UpdateSeats(ctx, booking.Seats); err != nil {
+			utils.LogErrorContext(ctx, "Failed to update seat statuses in DB for booking %s: %v", bookingID, err)
+			return newInternalError("failed to update seat statuses", err)
 		}
-	}
 
-	if err := tempBookingRepo.UpdateBooking(booking); err != nil {
-		tx.Rollback()
-		utils.LogError("Failed to update booking %s status in DB: %v", bookingID, err)
-		return errors.New("failed to update booking status")
+		if releaseSeats {
+			restoreTicketClassAvailability(ctx, tx, seatCounts)
+			if err := tx.Bookings.ReleaseBookingIdentities(ctx, bookingID, "", s.Clock.Now()); err != nil {
+				utils.LogErrorContext(ctx, "Failed to release purchase limits of booking %s: %v", bookingID, err)
+				return newInternalError("failed to release purchase limits", err)
+			}
+		}
+
+		if err := tx.Bookings.UpdateBooking(ctx, booking); err != nil {
+			utils.LogErrorContext(ctx, "Failed to update booking %s status in DB: %v", bookingID, err)
+			return newInternalError("failed to update booking status", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return transactionError(err, "failed to update booking status")
 	}
 
-	tx.Commit()
+	s.publishSeatStatusChanges(ctx, booking.ConcertID, booking.Seats)
+	switch newStatus {
+	case models.BookingStatusConfirmed:
+		utils.RecordBookingEvent(utils.BookingEventConfirmed)
+		utils.LogInfoContext(ctx, "Booking %s status updated to CONFIRMED. PaymentID: %d", bookingID, paymentID)
+		if err := s.Cache.UnscheduleBookingExpiry(ctx, bookingID); err != nil {
+			utils.LogWarningContext(ctx, "%v", err)
+		}
+		s.publishBookingStatusChange(ctx, booking, previousStatus, models.BookingEventPaymentResult, "payment_"+newStatus)
+	case models.BookingStatusFailed:
+		utils.RecordBookingEvent(utils.BookingEventFailed)
+		s.releaseCachedSeats(ctx, booking.ConcertID, seatCounts)
+		utils.LogWarningContext(ctx, "Booking %s status updated to FAILED. PaymentID: %d. Seats released.", bookingID, paymentID)
+		s.publishBookingStatusChange(ctx, booking, previousStatus, models.BookingEventPaymentResult, "payment_"+newStatus)
+	case models.BookingStatusCancelled:
+		utils.RecordBookingEvent(utils.BookingEventCancelled)
+		s.releaseCachedSeats(ctx, booking.ConcertID, seatCounts)
+		utils.LogInfoContext(ctx, "Booking %s status updated to CANCELLED.", bookingID)
+		s.publishBookingStatusChange(ctx, booking, previousStatus, models.BookingEventStatusChanged, "cancelled_by_payment_service")
+	}
 	return nil
 }
 
 func (s *BookingService) CancelBooking(ctx context.Context, bookingID string, userID uint) error {
-	booking, err := s.BookingRepo.GetBookingByID(bookingID)
+	ctx = utils.WithBookingID(ctx, bookingID)
+	booking, err := s.BookingRepo.GetBookingByID(ctx, bookingID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("booking not found")
+			return ErrBookingNotFound
 		}
-		utils.LogError("DB error getting booking %s for cancellation: %v", bookingID, err)
-		return errors.New("failed to retrieve booking details for cancellation")
+		utils.LogErrorContext(ctx, "DB error getting booking %s for cancellation: %v", bookingID, err)
+		return newInternalError("failed to retrieve booking details for cancellation", err)
 	}
 
 	if booking.UserID != userID {
-		utils.LogWarning("Unauthorized attempt to cancel booking %s by user %d. Owned by user %d.", bookingID, userID, booking.UserID)
-		return errors.New("unauthorized: you can only cancel your own bookings")
+		utils.LogWarningContext(ctx, "Unauthorized attempt to cancel booking %s by user %d. Owned by user %d.", bookingID, userID, booking.UserID)
+		return ErrBookingAccessDenied.Withf("unauthorized: you can only cancel your own bookings")
 	}
 
 	if booking.Status != models.BookingStatusPending {
-		return fmt.Errorf("booking %s cannot be cancelled as its status is %s (only pending bookings can be cancelled)", bookingID, booking.Status)
+		return ErrBookingNotCancellable.Withf("booking %s cannot be cancelled as its status is %s (only pending bookings can be cancelled)", bookingID, booking.Status)
 	}
 
+	cancelled, err := s.cancelPendingBooking(ctx, booking, models.CancellationReasonUserRequest)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrBookingNotCancellable.Withf("booking %s cannot be cancelled as its status is no longer %s (only pending bookings can be cancelled)", bookingID, models.BookingStatusPending)
+	}
+
+	utils.LogInfoContext(ctx, "Booking %s successfully cancelled by user %d. Seats released.", bookingID, userID)
+	return nil
+}
+
+// cancelPendingBooking releases the seats of a pending booking and marks it
+// cancelled for reason.
+// The status change is guarded on the booking still being pending, so concurrent
+// cancellations (user, expiry job, queue consumer) release the seats only once; the
+// losers get false without an error.
+func (s *BookingService) cancelPendingBooking(ctx context.Context, booking *models.Booking, reason string) (bool, error) {
+	ctx = utils.WithConcertID(utils.WithBookingID(ctx, booking.ID), booking.ConcertID)
+	bookingID := booking.ID
+	previousStatus := booking.Status
 	booking.Status = models.BookingStatusCancelled
+	booking.CancellationReason = &reason
+	releaseBookingSeats(booking)
+	seatCounts := seatCountsByClass(booking.Seats)
+
+	transitioned := false
+	err := s.Transactor.WithinTransaction(ctx, func(tx repositories.Stores) error {
+		ok, err := tx.Bookings.TransitionBookingStatus(ctx, bookingID, models.BookingStatusPending, models.BookingStatusCancelled)
+		if err != nil {
+			utils.LogErrorContext(ctx, "Failed to update booking %s status to cancelled: %v", bookingID, err)
+			return newInternalError("failed to update booking status to cancelled", err)
+		}
+		if !ok {
+			return nil
+		}
+
+		if err := tx.Seats.UpdateSeats(ctx, booking.Seats); err != nil {
+			utils.LogErrorContext(ctx, "Failed to update seat statuses for booking %s cancellation: %v", bookingID, err)
+			return newInternalError("failed to release seats during cancellation", err)
+		}
+
+		restoreTicketClassAvailability(ctx, tx, seatCounts)
+
+		if err := tx.Bookings.ReleaseBookingIdentities(ctx, bookingID, "", s.Clock.Now()); err != nil {
+			utils.LogErrorContext(ctx, "Failed to release purchase limits of booking %s: %v", bookingID, err)
+			return newInternalError("failed to release purchase limits", err)
+		}
+
+		if err := tx.Bookings.UpdateBooking(ctx, booking); err != nil {
+			utils.LogErrorContext(ctx, "Failed to update booking %s status to cancelled: %v", bookingID, err)
+			return newInternalError("failed to update booking status to cancelled", err)
+		}
+		transitioned = true
+		return nil
+	})
+	if err != nil {
+		return false, transactionError(err, "failed to cancel booking")
+	}
+	if !transitioned {
+		utils.LogInfoContext(ctx, "Booking %s is no longer pending, skipping cancellation (reason: %s).", bookingID, reason)
+		return false, nil
+	}
+
+	if reason == models.CancellationReasonHoldExpired {
+		utils.RecordBookingEvent(utils.BookingEventExpired)
+	} else {
+		utils.RecordBookingEvent(utils.BookingEventCancelled)
+	}
+	s.publishSeatStatusChanges(ctx, booking.ConcertID, booking.Seats)
+	s.publishBookingStatusChange(ctx, booking, previousStatus, models.BookingEventStatusChanged, reason)
+	s.releaseCachedSeats(ctx, booking.ConcertID, seatCounts)
+	return true, nil
+}
+
+// releaseBookingSeats makes the seats of a booking available again.
+func releaseBookingSeats(booking *models.Booking) {
 	for _, seat := range booking.Seats {
 		seat.Status = models.SeatStatusAvailable
 		seat.UserID = nil
 		seat.BookingID = nil
 	}
+}
 
-	tx := s.BookingRepo.DB.Begin()
-	if tx.Error != nil {
-		utils.LogError("Failed to begin DB transaction for booking cancellation: %v", tx.Error)
-		return errors.New("failed to initiate cancellation transaction")
-	}
-	tempBookingRepo := &repositories.BookingRepository{DB: tx}
-	tempSeatRepo := &repositories.SeatRepository{DB: tx}
-	tempTicketClassRepo := &repositories.TicketClassRepository{DB: tx}
-
-	if err := tempSeatRepo.UpdateSeats(booking.Seats); err != nil {
-		tx.Rollback()
-		utils.LogError("Failed to update seat statuses for booking %s cancellation: %v", bookingID, err)
-		return errors.New("failed to release seats during cancellation")
+func seatCountsByClass(seats []*models.Seat) map[uint]int {
+	counts := make(map[uint]int)
+	for _, seat := range seats {
+		if seat != nil {
+			counts[seat.TicketClassID]++
+		}
 	}
+	return counts
+}
 
-	classQuantitiesToRevert := make(map[uint]int)
-	for _, seat := range booking.Seats {
-		classQuantitiesToRevert[seat.TicketClassID]++
-	}
-	for tcID, qty := range classQuantitiesToRevert {
-		ticketClass, err := tempTicketClassRepo.GetTicketClassByID(tcID)
+// restoreTicketClassAvailability gives released seats back to their ticket
+// classes. A class that cannot be updated is logged and skipped; the Redis
+// counter stays the source of truth for availability.
+func restoreTicketClassAvailability(ctx context.Context, tx repositories.Stores, seatCounts map[uint]int) {
+	for tcID, qty := range seatCounts {
+		ticketClass, err := tx.TicketClasses.GetTicketClassByID(ctx, tcID)
 		if err != nil {
-			utils.LogError("Failed to get TicketClass %d for cancellation revert: %v", tcID, err)
+			utils.LogErrorContext(ctx, "Failed to get TicketClass %d for cancellation revert: %v", tcID, err)
 			continue
 		}
 		ticketClass.AvailableSeatsInClass += qty
-		if err := tempTicketClassRepo.UpdateTicketClass(tx, ticketClass); err != nil {
-			utils.LogError("Failed to update TicketClass %d for cancellation revert: %v", tcID, err)
+		if err := tx.TicketClasses.UpdateTicketClass(ctx, ticketClass); err != nil {
+			utils.LogErrorContext(ctx, "Failed to update TicketClass %d for cancellation revert: %v", tcID, err)
 		}
 	}
+}
 
-	if err := tempBookingRepo.UpdateBooking(booking); err != nil {
-		tx.Rollback()
-		utils.LogError("Failed to update booking %s status to cancelled: %v", bookingID, err)
-		return errors.New("failed to update booking status to cancelled")
+// releaseCachedSeats returns seats to the Redis counters of their ticket classes.
+func (s *BookingService) releaseCachedSeats(ctx context.Context, concertID uint, seatCounts map[uint]int) {
+	for tcID, qty := range seatCounts {
+		if _, err := s.Cache.IncreaseAvailableSeats(ctx, concertID, tcID, qty); err != nil {
+			utils.LogErrorContext(ctx, "Failed to increase available seats in Redis for class %d of concert %d: %v", tcID, concertID, err)
+		}
 	}
+}
 
-	tx.Commit()
+// ProcessBookingCancellationMessage handles a message from booking_cancellation_queue.
+// Redelivered or duplicate messages are harmless: a booking that is already cancelled,
+// or can no longer be cancelled, is acknowledged without changes. Only malformed
+// messages and infrastructure failures return an error; malformed ones are marked
+// permanent, so they are dead-lettered without retries.
+func (s *BookingService) ProcessBookingCancellationMessage(ctx context.Context, body []byte) error {
+	var msg models.BookingCancellationMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		utils.LogErrorContext(ctx, "Failed to unmarshal booking cancellation message: %v", err)
+		return utils.Permanent(err)
+	}
+	if msg.BookingID == "" || msg.Reason == "" || msg.Actor == "" {
+		utils.LogErrorContext(ctx, "Invalid booking cancellation message, booking_id, reason and actor are required: %s", body)
+		return utils.Permanent(ErrInvalidCancellation)
+	}
+	if !models.IsCancellationReason(msg.Reason) {
+		utils.LogErrorContext(ctx, "Invalid booking cancellation message for booking %s, unknown reason %q", msg.BookingID, msg.Reason)
+		return utils.Permanent(ErrInvalidCancellation.Withf("invalid booking cancellation message: unknown reason %q", msg.Reason))
+	}
+	ctx = utils.WithBookingID(ctx, msg.BookingID)
 
-	numSeats := len(booking.Seats)
-	if numSeats > 0 {
-		classQuantitiesToRevert := make(map[uint]int)
-		for _, seat := range booking.Seats {
-			classQuantitiesToRevert[seat.TicketClassID]++
+	utils.LogInfoContext(ctx, "Processing cancellation of booking %s requested by %s (reason: %s)", msg.BookingID, msg.Actor, msg.Reason)
+
+	booking, err := s.BookingRepo.GetBookingByID(ctx, msg.BookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogWarningContext(ctx, "Booking %s from cancellation message not found, discarding message.", msg.BookingID)
+			return nil
+		}
+		utils.LogErrorContext(ctx, "DB error getting booking %s for queued cancellation: %v", msg.BookingID, err)
+		return newInternalError("failed to retrieve booking details for cancellation", err)
+	}
+
+	switch booking.Status {
+	case models.BookingStatusCancelled:
+		utils.LogInfoContext(ctx, "Booking %s is already cancelled, nothing to do.", msg.BookingID)
+		return nil
+	case models.BookingStatusPending:
+	default:
+		utils.LogWarningContext(ctx, "Booking %s cannot be cancelled by %s as its status is %s, discarding message.", msg.BookingID, msg.Actor, booking.Status)
+		return nil
+	}
+
+	cancelled, err := s.cancelPendingBooking(ctx, booking, msg.Reason)
+	if err != nil {
+		return err
+	}
+	if cancelled {
+		utils.LogInfoContext(ctx, "Booking %s successfully cancelled by %s (reason: %s). Seats released.", msg.BookingID, msg.Actor, msg.Reason)
+	}
+	return nil
+}
+
+// RunExpiryWorker releases holds as they expire, claiming due bookings from the
+// Redis expiry schedule. Claims are atomic, so every replica runs a worker.
+func (s *BookingService) RunExpiryWorker(ctx context.Context) {
+	utils.LogInfoContext(ctx, "Booking expiry worker started (poll interval %s).", bookingExpiryPollInterval)
+	ticker := time.NewTicker(bookingExpiryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.processDueExpiries(ctx)
 		}
-		for tcID, qty := range classQuantitiesToRevert {
-			_, errRedis := utils.IncreaseAvailableSeatsAtomically(ctx, tcID, qty)
-			if errRedis != nil {
-				utils.LogError("Failed to increase available seats in Redis for concert %d after cancellation of booking %s: %v", booking.ConcertID, bookingID, errRedis)
+	}
+}
+
+func (s *BookingService) processDueExpiries(ctx context.Context) {
+	for {
+		bookingIDs, err := s.Cache.ClaimDueBookingExpiries(ctx, s.Clock.Now(), bookingExpiryClaimTimeout, bookingExpiryBatchSize)
+		if err != nil {
+			utils.LogErrorContext(ctx, "Booking expiry worker: %v", err)
+			return
+		}
+		for _, bookingID := range bookingIDs {
+			// On shutdown the booking being expired is finished, as cancelling
+			// would abandon its transaction; the rest of the claimed batch is
+			// picked up again once the claim times out.
+			if ctx.Err() != nil {
+				return
 			}
+			if err := s.expireBooking(context.WithoutCancel(ctx), bookingID); err != nil {
+				utils.LogErrorContext(ctx, "Failed to expire booking %s, retrying after %s: %v", bookingID, bookingExpiryClaimTimeout, err)
+			}
+		}
+		if len(bookingIDs) < bookingExpiryBatchSize {
+			return
+		}
+	}
+}
+
+// expireBooking cancels a booking whose hold ran out and removes it from the
+// schedule. Bookings that were paid or cancelled in the meantime are only removed.
+func (s *BookingService) expireBooking(ctx context.Context, bookingID string) error {
+	ctx = utils.WithBookingID(ctx, bookingID)
+	booking, err := s.BookingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.Cache.UnscheduleBookingExpiry(ctx, bookingID)
 		}
+		return fmt.Errorf("failed to retrieve booking: %w", err)
 	}
 
-	utils.LogInfo("Booking %s successfully cancelled by user %d. Seats released.", bookingID, userID)
+	if booking.Status != models.BookingStatusPending || booking.ExpiresAt == nil {
+		return s.Cache.UnscheduleBookingExpiry(ctx, bookingID)
+	}
+	if booking.ExpiresAt.After(s.Clock.Now()) {
+		return s.Cache.ScheduleBookingExpiry(ctx, bookingID, *booking.ExpiresAt)
+	}
+
+	cancelled, err := s.cancelPendingBooking(ctx, booking, models.CancellationReasonHoldExpired)
+	if err != nil {
+		return err
+	}
+	if cancelled {
+		utils.LogInfoContext(ctx, "Booking %s expired %s after its hold ran out. Seats released.", bookingID, s.Clock.Now().Sub(*booking.ExpiresAt).Round(time.Millisecond))
+	}
+	return s.Cache.UnscheduleBookingExpiry(ctx, bookingID)
+}
+
+// SchedulePendingBookingExpiries puts every pending booking on the expiry schedule,
+// covering bookings created before the schedule existed or while Redis was down.
+func (s *BookingService) SchedulePendingBookingExpiries(ctx context.Context) error {
+	bookings, err := s.BookingRepo.GetPendingBookingsWithExpiry(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching pending bookings: %w", err)
+	}
+	for _, booking := range bookings {
+		if err := s.Cache.ScheduleBookingExpiry(ctx, booking.ID, *booking.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	utils.LogInfoContext(ctx, "Scheduled expiry of %d pending booking(s).", len(bookings))
 	return nil
 }
 
+// CancelExpiredPendingBookings is the safety net behind the expiry worker. It
+// cancels pending bookings that are past their expiry, e.g. because their
+// schedule entry was lost.
 func (s *BookingService) CancelExpiredPendingBookings(ctx context.Context) error {
-	expiredBookings, err := s.BookingRepo.GetExpiredPendingBookings()
+	expiredBookings, err := s.BookingRepo.GetExpiredPendingBookings(ctx, s.Clock.Now())
 	if err != nil {
 		return fmt.Errorf("error fetching expired pending bookings: %w", err)
 	}
 
 	if len(expiredBookings) == 0 {
-		utils.LogInfo("No expired pending bookings found.")
+		utils.LogInfoContext(ctx, "No expired pending bookings found.")
 		return nil
 	}
 
-	for _, booking := range expiredBookings {
-		utils.LogInfo("Automatically canceling expired booking %s (Concert ID: %d, User ID: %d)", booking.ID, booking.ConcertID, booking.UserID)
-
-		booking.Status = models.BookingStatusCancelled
+	for i := range expiredBookings {
+		booking := &expiredBookings[i]
+		utils.LogWarningContext(ctx, "Expiry sweep found overdue booking %s (Concert ID: %d, User ID: %d), canceling it.", booking.ID, booking.ConcertID, booking.UserID)
 
-		for _, seat := range booking.Seats {
-			seat.Status = models.SeatStatusAvailable
-			seat.UserID = nil
-			seat.BookingID = nil
-		}
-
-		tx := s.BookingRepo.DB.Begin()
-		if tx.Error != nil {
-			utils.LogError("Failed to begin DB transaction for auto-cancellation of booking %s: %v", booking.ID, tx.Error)
-			continue
-		}
-		tempBookingRepo := &repositories.BookingRepository{DB: tx}
-		tempSeatRepo := &repositories.SeatRepository{DB: tx}
-		tempTicketClassRepo := &repositories.TicketClassRepository{DB: tx}
-
-		if err := tempSeatRepo.UpdateSeats(booking.Seats); err != nil {
-			tx.Rollback()
-			utils.LogError("Failed to update seat statuses in DB for auto-cancellation of booking %s: %v", booking.ID, err)
+		cancelled, err := s.cancelPendingBooking(ctx, booking, models.CancellationReasonHoldExpired)
+		if err != nil {
+			utils.LogErrorContext(ctx, "Failed to auto-cancel booking %s: %v", booking.ID, err)
 			continue
 		}
-
-		classQuantitiesToRevert := make(map[uint]int)
-		for _, seat := range booking.Seats {
-			classQuantitiesToRevert[seat.TicketClassID]++
+		if cancelled {
+			utils.LogInfoContext(ctx, "Booking %s successfully auto-cancelled. Seats released.", booking.ID)
 		}
-		for tcID, qty := range classQuantitiesToRevert {
-			ticketClass, err := tempTicketClassRepo.GetTicketClassByID(tcID)
-			if err != nil {
-				utils.LogError("Failed to get TicketClass %d for auto-cancellation revert: %v", tcID, err)
-				continue
-			}
-			ticketClass.AvailableSeatsInClass += qty
-			if err := tempTicketClassRepo.UpdateTicketClass(tx, ticketClass); err != nil {
-				utils.LogError("Failed to update TicketClass %d for auto-cancellation revert: %v", tcID, err)
-			}
-			_, errRedis := utils.IncreaseAvailableSeatsAtomically(ctx, tcID, qty)
-			if errRedis != nil {
-				utils.LogError("Failed to increase available seats in Redis for class %d after auto-cancellation of booking %s: %v", tcID, booking.ID, errRedis)
-			}
+		if err := s.Cache.UnscheduleBookingExpiry(ctx, booking.ID); err != nil {
+			utils.LogWarningContext(ctx, "%v", err)
 		}
-
-		if err := tempBookingRepo.UpdateBooking(&booking); err != nil {
-			tx.Rollback()
-			utils.LogError("Failed to update booking %s status to cancelled during auto-cancellation: %v", booking.ID, err)
-			continue
-		}
-
-		tx.Commit()
-		utils.LogInfo("Booking %s successfully auto-cancelled. Seats released.", booking.ID)
 	}
 	return nil
 }
+
+func (s *BookingService) publishSeatStatusChanges(ctx context.Context, concertID uint, seats []*models.Seat) {
+	if len(seats) == 0 {
+		return
+	}
+	s.Events.PublishAvailabilityEvent(ctx, utils.NewSeatStatusEvent(concertID, seats))
+}
+
+func (s *BookingService) publishBookingStatusChange(ctx context.Context, booking *models.Booking, previousStatus, eventType, reason string) {
+	s.Events.PublishBookingEvent(ctx, models.BookingEvent{
+		Type:           eventType,
+		BookingID:      booking.ID,
+		Status:         booking.Status,
+		PreviousStatus: previousStatus,
+		PaymentID:      booking.PaymentID,
+		ExpiresAt:      booking.ExpiresAt,
+		Reason:         reason,
+	})
+}
@@ -0,0 +1,805 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/booking-service/fakes"
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type bookingFixture struct {
+	store    *fakes.Store
+	cache    *fakes.SeatCache
+	events   *fakes.EventPublisher
+	clock    *fakes.Clock
+	service  *BookingService
+	concert  models.Concert
+	vip      models.TicketClass
+	regular  models.TicketClass
+	payments chan string
+}
+
+// newBookingFixture sets up an active concert with 2 VIP and 10 regular seats
+// and a payment service that records the bookings it is asked to charge.
+func newBookingFixture(t *testing.T) *bookingFixture {
+	t.Helper()
+	f := &bookingFixture{
+		store:    fakes.NewStore(),
+		cache:    fakes.NewSeatCache(),
+		events:   fakes.NewEventPublisher(),
+		clock:    fakes.NewClock(testNow),
+		payments: make(chan string, 10),
+	}
+
+	paymentService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			BookingID string `json:"booking_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.payments <- req.BookingID
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(paymentService.Close)
+
+	concert := &models.Concert{
+		Name:           "Java Jazz",
+		Date:           testNow.Add(30 * 24 * time.Hour),
+		Venue:          "JIExpo",
+		TotalSeats:     12,
+		AvailableSeats: 12,
+		Status:         models.ConcertStatusActive,
+		TicketClasses: []models.TicketClass{
+			{Name: "VIP", Price: 100, TotalSeatsInClass: 2, AvailableSeatsInClass: 2},
+			{Name: "Regular", Price: 50, TotalSeatsInClass: 10, AvailableSeatsInClass: 10},
+		},
+	}
+	if err := f.store.CreateConcert(context.Background(), concert); err != nil {
+		t.Fatalf("seeding concert: %v", err)
+	}
+	f.concert = *concert
+	f.vip = concert.TicketClasses[0]
+	f.regular = concert.TicketClasses[1]
+
+	ctx := context.Background()
+	f.cache.SetAvailableSeats(ctx, concert.ID, concert.AvailableSeats)
+	f.cache.SetAvailableSeatsByClass(ctx, f.vip.ID, f.vip.AvailableSeatsInClass)
+	f.cache.SetAvailableSeatsByClass(ctx, f.regular.ID, f.regular.AvailableSeatsInClass)
+
+	f.service = NewBookingService(f.store, f.store, f.store, f.cache, f.events, f.clock, paymentService.URL)
+	return f
+}
+
+func (f *bookingFixture) request(ktp string, tickets ...models.TicketQuantityByClass) *models.CreateBookingRequest {
+	return &models.CreateBookingRequest{
+		ConcertID:      f.concert.ID,
+		TicketsByClass: tickets,
+		BuyerInfo: models.BuyerRequest{
+			FullName:    "Budi Santoso",
+			PhoneNumber: "081234567890",
+			Email:       "budi@example.com",
+			KTPNumber:   ktp,
+		},
+	}
+}
+
+func tickets(ticketClassID uint, quantity int) models.TicketQuantityByClass {
+	return models.TicketQuantityByClass{TicketClassID: ticketClassID, Quantity: quantity}
+}
+
+// book creates a pending booking of one regular ticket for userID.
+func (f *bookingFixture) book(t *testing.T, userID uint) *models.BookingResponse {
+	t.Helper()
+	resp, err := f.service.CreateBooking(context.Background(), userID, f.request(fmt.Sprintf("317101100190%04d", userID), tickets(f.regular.ID, 1)))
+	if err != nil {
+		t.Fatalf("CreateBooking() error = %v", err)
+	}
+	return resp
+}
+
+func (f *bookingFixture) assertCachedSeats(t *testing.T, ticketClassID uint, want int) {
+	t.Helper()
+	got, _ := f.cache.GetAvailableSeatsByClass(context.Background(), ticketClassID)
+	if got != want {
+		t.Errorf("cached seats of class %d = %d, want %d", ticketClassID, got, want)
+	}
+}
+
+func (f *bookingFixture) assertStoredSeats(t *testing.T, ticketClassID uint, want int) {
+	t.Helper()
+	tc, _ := f.store.TicketClass(ticketClassID)
+	if tc.AvailableSeatsInClass != want {
+		t.Errorf("stored seats of class %d = %d, want %d", ticketClassID, tc.AvailableSeatsInClass, want)
+	}
+}
+
+func (f *bookingFixture) assertStatus(t *testing.T, bookingID, want string) models.Booking {
+	t.Helper()
+	booking, ok := f.store.Booking(bookingID)
+	if !ok {
+		t.Fatalf("booking %s not found", bookingID)
+	}
+	if booking.Status != want {
+		t.Errorf("booking status = %q, want %q", booking.Status, want)
+	}
+	return booking
+}
+
+func assertDomainError(t *testing.T, err error, want *DomainError) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("error = %v, want %s", err, want.Code)
+	}
+}
+
+func TestCreateBooking(t *testing.T) {
+	f := newBookingFixture(t)
+	req := f.request("3171011001900001", tickets(f.vip.ID, 1), tickets(f.regular.ID, 2))
+	req.TicketHolderInfo = &models.TicketHolderRequest{FullName: "Siti Aminah", KTPNumber: "3171011001900002"}
+
+	resp, err := f.service.CreateBooking(context.Background(), 7, req)
+	if err != nil {
+		t.Fatalf("CreateBooking() error = %v", err)
+	}
+
+	if resp.Status != models.BookingStatusPending || resp.TotalPrice != 200 || len(resp.BookedSeats) != 3 {
+		t.Errorf("response = status %q, total %v, %d seats; want pending, 200, 3 seats", resp.Status, resp.TotalPrice, len(resp.BookedSeats))
+	}
+	if resp.BuyerInfo == nil || resp.TicketHolderInfo == nil {
+		t.Errorf("response is missing buyer or ticket holder info")
+	}
+	wantExpiry := testNow.Add(bookingHoldDuration)
+	if resp.ExpiresAt == nil || !resp.ExpiresAt.Equal(wantExpiry) {
+		t.Errorf("ExpiresAt = %v, want %v", resp.ExpiresAt, wantExpiry)
+	}
+
+	booking := f.assertStatus(t, resp.ID, models.BookingStatusPending)
+	if len(booking.Seats) != 3 || booking.Buyer == nil || booking.TicketHolder == nil {
+		t.Errorf("stored booking has %d seats, buyer %v, ticket holder %v", len(booking.Seats), booking.Buyer, booking.TicketHolder)
+	}
+	for _, seat := range booking.Seats {
+		if seat.Status != models.SeatStatusReserved {
+			t.Errorf("seat %d status = %q, want %q", seat.ID, seat.Status, models.SeatStatusReserved)
+		}
+	}
+
+	f.assertCachedSeats(t, f.vip.ID, 1)
+	f.assertCachedSeats(t, f.regular.ID, 8)
+	f.assertStoredSeats(t, f.vip.ID, 1)
+	f.assertStoredSeats(t, f.regular.ID, 8)
+
+	if expiresAt, ok := f.cache.ScheduledExpiry(resp.ID); !ok || !expiresAt.Equal(wantExpiry) {
+		t.Errorf("scheduled expiry = %v (scheduled %v), want %v", expiresAt, ok, wantExpiry)
+	}
+	if events := f.events.AvailabilityEvents(); len(events) != 1 || events[0].Type != models.AvailabilityEventSeatStatus {
+		t.Errorf("availability events = %+v, want one seat status event", events)
+	}
+
+	select {
+	case bookingID := <-f.payments:
+		if bookingID != resp.ID {
+			t.Errorf("payment requested for booking %s, want %s", bookingID, resp.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("payment was not requested")
+	}
+}
+
+func TestCreateBookingRejectsInvalidQuantity(t *testing.T) {
+	f := newBookingFixture(t)
+	for _, quantity := range []int{0, 6} {
+		_, err := f.service.CreateBooking(context.Background(), 1, f.request("3171011001900001", tickets(f.regular.ID, quantity)))
+		assertDomainError(t, err, ErrInvalidTicketQuantity)
+	}
+	f.assertCachedSeats(t, f.regular.ID, 10)
+}
+
+func TestCreateBookingRejectsInvalidKTPNumbers(t *testing.T) {
+	f := newBookingFixture(t)
+	for _, ktp := range []string{"9971011001900001", "3171991001900001", "3171013102900001", "3171010000000001"} {
+		_, err := f.service.CreateBooking(context.Background(), 1, f.request(ktp, tickets(f.regular.ID, 1)))
+		assertDomainError(t, err, ErrInvalidKTPNumber)
+	}
+
+	req := f.request("3171011001900001", tickets(f.regular.ID, 1))
+	req.TicketHolderInfo = &models.TicketHolderRequest{FullName: "Siti Aminah", KTPNumber: "3171017102900002"}
+	_, err := f.service.CreateBooking(context.Background(), 1, req)
+	assertDomainError(t, err, ErrInvalidKTPNumber)
+	f.assertCachedSeats(t, f.regular.ID, 10)
+}
+
+func TestCreateBookingRejectsSecondActiveBooking(t *testing.T) {
+	f := newBookingFixture(t)
+	f.book(t, 1)
+
+	_, err := f.service.CreateBooking(context.Background(), 1, f.request("3171011001900099", tickets(f.vip.ID, 1)))
+	assertDomainError(t, err, ErrActiveBookingExists)
+	f.assertCachedSeats(t, f.vip.ID, 2)
+}
+
+func TestCreateBookingConcertChecks(t *testing.T) {
+	f := newBookingFixture(t)
+
+	req := f.request("3171011001900001", tickets(f.regular.ID, 1))
+	req.ConcertID = 999
+	_, err := f.service.CreateBooking(context.Background(), 1, req)
+	assertDomainError(t, err, ErrConcertNotFound)
+
+	f.store.UpdateConcertStatus(context.Background(), f.concert.ID, models.ConcertStatusPendingSeatCreation)
+	_, err = f.service.CreateBooking(context.Background(), 1, f.request("3171011001900001", tickets(f.regular.ID, 1)))
+	assertDomainError(t, err, ErrConcertNotActive)
+}
+
+func TestCreateBookingChecksMinimumAge(t *testing.T) {
+	f := newBookingFixture(t)
+	f.concert.MinimumAge = 18
+	f.store.UpdateConcert(context.Background(), &f.concert)
+
+	// The concert is on 31 March 2026; the holder turns 18 the day after.
+	req := f.request("3171011001900001", tickets(f.regular.ID, 1))
+	req.TicketHolderInfo = &models.TicketHolderRequest{FullName: "Siti Aminah", KTPNumber: utils.FormatNIK("317101", time.Date(2008, 4, 1, 0, 0, 0, 0, time.UTC), models.GenderFemale, 2)}
+	_, err := f.service.CreateBooking(context.Background(), 1, req)
+	assertDomainError(t, err, ErrAgeRestricted)
+
+	// Without a named holder the buyer attends.
+	_, err = f.service.CreateBooking(context.Background(), 1, f.request(utils.FormatNIK("317101", time.Date(2008, 4, 1, 0, 0, 0, 0, time.UTC), models.GenderMale, 3), tickets(f.regular.ID, 1)))
+	assertDomainError(t, err, ErrAgeRestricted)
+	f.assertCachedSeats(t, f.regular.ID, 10)
+
+	req.TicketHolderInfo.KTPNumber = utils.FormatNIK("317101", time.Date(2008, 3, 31, 0, 0, 0, 0, time.UTC), models.GenderFemale, 2)
+	if _, err := f.service.CreateBooking(context.Background(), 1, req); err != nil {
+		t.Fatalf("CreateBooking() for a holder who is 18 on the concert date: error = %v", err)
+	}
+}
+
+func TestCreateBookingReleasesEarlierReservationsOnFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		second func(f *bookingFixture) models.TicketQuantityByClass
+		want   *DomainError
+	}{
+		{
+			name:   "unknown ticket class",
+			second: func(f *bookingFixture) models.TicketQuantityByClass { return tickets(999, 1) },
+			want:   ErrTicketClassNotFound,
+		},
+		{
+			name:   "not enough seats",
+			second: func(f *bookingFixture) models.TicketQuantityByClass { return tickets(f.vip.ID, 3) },
+			want:   ErrNotEnoughSeats,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newBookingFixture(t)
+			req := f.request("3171011001900001", tickets(f.regular.ID, 2), tt.second(f))
+
+			_, err := f.service.CreateBooking(context.Background(), 1, req)
+			assertDomainError(t, err, tt.want)
+
+			f.assertCachedSeats(t, f.regular.ID, 10)
+			f.assertCachedSeats(t, f.vip.ID, 2)
+			if n := f.store.BookingCount(); n != 0 {
+				t.Errorf("%d bookings stored, want none", n)
+			}
+		})
+	}
+}
+
+func TestCreateBookingSeatContention(t *testing.T) {
+	f := newBookingFixture(t)
+	f.cache.FailOn("DecreaseAvailableSeats", fmt.Errorf("failed after retries: %w", utils.ErrSeatContention))
+
+	_, err := f.service.CreateBooking(context.Background(), 1, f.request("3171011001900001", tickets(f.regular.ID, 1)))
+	assertDomainError(t, err, ErrSeatReservationContended)
+}
+
+func TestCreateBookingRollsBackWhenSavingFails(t *testing.T) {
+	for _, op := range []string{"CreateSeats", "CreateBooking", "CreateBuyer", "UpdateTicketClass"} {
+		t.Run(op, func(t *testing.T) {
+			f := newBookingFixture(t)
+			f.store.FailOn(op, errors.New("connection reset"))
+
+			_, err := f.service.CreateBooking(context.Background(), 1, f.request("3171011001900001", tickets(f.vip.ID, 1), tickets(f.regular.ID, 2)))
+			if !errors.Is(err, ErrInternal) {
+				t.Fatalf("error = %v, want an internal error", err)
+			}
+
+			if n := f.store.BookingCount(); n != 0 {
+				t.Errorf("%d bookings stored, want none", n)
+			}
+			if seats, _ := f.store.GetSeatsByConcertID(context.Background(), f.concert.ID); len(seats) != 0 {
+				t.Errorf("%d seats stored, want none", len(seats))
+			}
+			f.assertStoredSeats(t, f.vip.ID, 2)
+			f.assertStoredSeats(t, f.regular.ID, 10)
+			f.assertCachedSeats(t, f.vip.ID, 2)
+			f.assertCachedSeats(t, f.regular.ID, 10)
+			if len(f.events.AvailabilityEvents()) != 0 {
+				t.Errorf("events were published for a booking that was rolled back")
+			}
+		})
+	}
+}
+
+func TestCancelBooking(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+
+	if err := f.service.CancelBooking(context.Background(), resp.ID, 1); err != nil {
+		t.Fatalf("CancelBooking() error = %v", err)
+	}
+
+	booking := f.assertStatus(t, resp.ID, models.BookingStatusCancelled)
+	for _, seat := range booking.Seats {
+		if seat.Status != models.SeatStatusAvailable || seat.UserID != nil {
+			t.Errorf("seat %d = status %q, user %v; want available and unowned", seat.ID, seat.Status, seat.UserID)
+		}
+	}
+	f.assertCachedSeats(t, f.regular.ID, 10)
+	f.assertStoredSeats(t, f.regular.ID, 10)
+
+	events := f.events.BookingEvents()
+	if len(events) != 1 || events[0].Reason != models.CancellationReasonUserRequest || events[0].PreviousStatus != models.BookingStatusPending {
+		t.Errorf("booking events = %+v, want one user cancellation", events)
+	}
+
+	err := f.service.CancelBooking(context.Background(), resp.ID, 1)
+	assertDomainError(t, err, ErrBookingNotCancellable)
+	f.assertCachedSeats(t, f.regular.ID, 10)
+}
+
+func TestCancelBookingRejects(t *testing.T) {
+	f := newBookingFixture(t)
+	pending := f.book(t, 1)
+	confirmed := f.book(t, 2)
+	if err := f.service.UpdateBookingStatusFromPayment(context.Background(), confirmed.ID, models.BookingStatusConfirmed, 42); err != nil {
+		t.Fatalf("confirming booking: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		bookingID string
+		userID    uint
+		want      *DomainError
+	}{
+		{"unknown booking", "does-not-exist", 1, ErrBookingNotFound},
+		{"booking of another user", pending.ID, 2, ErrBookingAccessDenied},
+		{"confirmed booking", confirmed.ID, 2, ErrBookingNotCancellable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.service.CancelBooking(context.Background(), tt.bookingID, tt.userID)
+			assertDomainError(t, err, tt.want)
+		})
+	}
+	f.assertStatus(t, pending.ID, models.BookingStatusPending)
+}
+
+func TestCancelBookingRollsBackWhenSeatUpdateFails(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+	f.store.FailOn("UpdateSeats", errors.New("deadlock"))
+
+	err := f.service.CancelBooking(context.Background(), resp.ID, 1)
+	if !errors.Is(err, ErrInternal) {
+		t.Fatalf("error = %v, want an internal error", err)
+	}
+
+	f.assertStatus(t, resp.ID, models.BookingStatusPending)
+	f.assertCachedSeats(t, f.regular.ID, 9)
+	f.assertStoredSeats(t, f.regular.ID, 9)
+	if len(f.events.BookingEvents()) != 0 {
+		t.Errorf("a booking event was published for a cancellation that was rolled back")
+	}
+}
+
+func TestUpdateBookingStatusFromPaymentConfirms(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+
+	if err := f.service.UpdateBookingStatusFromPayment(context.Background(), resp.ID, models.BookingStatusConfirmed, 42); err != nil {
+		t.Fatalf("UpdateBookingStatusFromPayment() error = %v", err)
+	}
+
+	booking := f.assertStatus(t, resp.ID, models.BookingStatusConfirmed)
+	if booking.PaymentID == nil || *booking.PaymentID != 42 || booking.ExpiresAt != nil {
+		t.Errorf("booking = payment %v, expires %v; want payment 42 and no expiry", booking.PaymentID, booking.ExpiresAt)
+	}
+	for _, seat := range booking.Seats {
+		if seat.Status != models.SeatStatusBooked {
+			t.Errorf("seat %d status = %q, want %q", seat.ID, seat.Status, models.SeatStatusBooked)
+		}
+	}
+	// Confirming keeps the seats taken.
+	f.assertCachedSeats(t, f.regular.ID, 9)
+	f.assertStoredSeats(t, f.regular.ID, 9)
+	if _, ok := f.cache.ScheduledExpiry(resp.ID); ok {
+		t.Errorf("confirmed booking is still scheduled to expire")
+	}
+}
+
+func TestCheckInBooking(t *testing.T) {
+	f := newBookingFixture(t)
+	f.concert.MinimumAge = 21
+	f.store.UpdateConcert(context.Background(), &f.concert)
+	resp := f.book(t, 1)
+
+	_, err := f.service.CheckInBooking(context.Background(), resp.ID)
+	assertDomainError(t, err, ErrBookingNotConfirmed)
+	_, err = f.service.CheckInBooking(context.Background(), "missing")
+	assertDomainError(t, err, ErrBookingNotFound)
+
+	if err := f.service.UpdateBookingStatusFromPayment(context.Background(), resp.ID, models.BookingStatusConfirmed, 42); err != nil {
+		t.Fatalf("UpdateBookingStatusFromPayment() error = %v", err)
+	}
+	first, err := f.service.CheckInBooking(context.Background(), resp.ID)
+	if err != nil {
+		t.Fatalf("CheckInBooking() error = %v", err)
+	}
+	want := models.CheckInResponse{
+		BookingID:       resp.ID,
+		ConcertID:       f.concert.ID,
+		ConcertName:     "Java Jazz",
+		HolderName:      "Budi Santoso",
+		Tickets:         1,
+		AgeBand:         models.AgeBand21Plus,
+		MinimumAge:      21,
+		MeetsMinimumAge: true,
+		CheckedInAt:     testNow,
+	}
+	if *first != want {
+		t.Errorf("CheckInBooking() = %+v, want %+v", *first, want)
+	}
+
+	f.clock.Advance(time.Minute)
+	second, err := f.service.CheckInBooking(context.Background(), resp.ID)
+	if err != nil {
+		t.Fatalf("second CheckInBooking() error = %v", err)
+	}
+	if !second.AlreadyCheckedIn || !second.CheckedInAt.Equal(testNow) {
+		t.Errorf("second CheckInBooking() = %+v, want already checked in at %s", second, testNow)
+	}
+}
+
+func TestCheckInBookingShowsHolderAgeBand(t *testing.T) {
+	tests := []struct {
+		name      string
+		ktp       string
+		wantBand  string
+		wantMeets bool
+	}{
+		{name: "18 to 20", ktp: utils.FormatNIK("317101", time.Date(2006, 5, 1, 0, 0, 0, 0, time.UTC), models.GenderFemale, 2), wantBand: models.AgeBand18To20, wantMeets: true},
+		{name: "under 18", ktp: utils.FormatNIK("317101", time.Date(2010, 5, 1, 0, 0, 0, 0, time.UTC), models.GenderFemale, 2), wantBand: models.AgeBandUnder18},
+		{name: "legacy KTP", ktp: "3171010000000002", wantBand: models.AgeBandUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newBookingFixture(t)
+			f.concert.MinimumAge = 18
+			f.store.UpdateConcert(context.Background(), &f.concert)
+
+			resp := f.book(t, 1)
+			f.service.UpdateBookingStatusFromPayment(context.Background(), resp.ID, models.BookingStatusConfirmed, 42)
+			// Named directly in the store, as for bookings made before ages
+			// were checked.
+			f.store.CreateTicketHolder(context.Background(), &models.TicketHolder{BookingID: resp.ID, FullName: "Siti Aminah", KTPNumber: tt.ktp})
+
+			checkIn, err := f.service.CheckInBooking(context.Background(), resp.ID)
+			if err != nil {
+				t.Fatalf("CheckInBooking() error = %v", err)
+			}
+			if checkIn.HolderName != "Siti Aminah" || checkIn.AgeBand != tt.wantBand || checkIn.MeetsMinimumAge != tt.wantMeets {
+				t.Errorf("CheckInBooking() = holder %q, band %q, meets %v; want Siti Aminah, %q, %v", checkIn.HolderName, checkIn.AgeBand, checkIn.MeetsMinimumAge, tt.wantBand, tt.wantMeets)
+			}
+		})
+	}
+}
+
+func TestUpdateBookingStatusFromPaymentReleasesSeats(t *testing.T) {
+	for _, status := range []string{models.BookingStatusFailed, models.BookingStatusCancelled} {
+		t.Run(status, func(t *testing.T) {
+			f := newBookingFixture(t)
+			resp := f.book(t, 1)
+
+			if err := f.service.UpdateBookingStatusFromPayment(context.Background(), resp.ID, status, 42); err != nil {
+				t.Fatalf("UpdateBookingStatusFromPayment() error = %v", err)
+			}
+
+			booking := f.assertStatus(t, resp.ID, status)
+			for _, seat := range booking.Seats {
+				if seat.Status != models.SeatStatusAvailable {
+					t.Errorf("seat %d status = %q, want %q", seat.ID, seat.Status, models.SeatStatusAvailable)
+				}
+			}
+			f.assertCachedSeats(t, f.regular.ID, 10)
+			f.assertStoredSeats(t, f.regular.ID, 10)
+		})
+	}
+}
+
+func TestUpdateBookingStatusFromPaymentIgnoresRepeatedCancellation(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+
+	for i := 0; i < 2; i++ {
+		if err := f.service.UpdateBookingStatusFromPayment(context.Background(), resp.ID, models.BookingStatusCancelled, 42); err != nil {
+			t.Fatalf("cancellation %d: error = %v", i+1, err)
+		}
+	}
+	f.assertCachedSeats(t, f.regular.ID, 10)
+	f.assertStoredSeats(t, f.regular.ID, 10)
+}
+
+func TestUpdateBookingStatusFromPaymentRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      string
+		to        string
+		want      *DomainError
+		bookingID string
+	}{
+		{name: "confirm a failed booking", from: models.BookingStatusFailed, to: models.BookingStatusConfirmed, want: ErrInvalidStatusTransition},
+		{name: "confirm twice", from: models.BookingStatusConfirmed, to: models.BookingStatusConfirmed, want: ErrInvalidStatusTransition},
+		{name: "fail a cancelled booking", from: models.BookingStatusCancelled, to: models.BookingStatusFailed, want: ErrInvalidStatusTransition},
+		{name: "cancel a confirmed booking", from: models.BookingStatusConfirmed, to: models.BookingStatusCancelled, want: ErrInvalidStatusTransition},
+		{name: "unsupported status", to: "refunded", want: ErrUnsupportedBookingStatus},
+		{name: "unknown booking", to: models.BookingStatusConfirmed, want: ErrBookingNotFound, bookingID: "does-not-exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newBookingFixture(t)
+			resp := f.book(t, 1)
+			bookingID := resp.ID
+			if tt.bookingID != "" {
+				bookingID = tt.bookingID
+			}
+			if tt.from != "" {
+				if err := f.service.UpdateBookingStatusFromPayment(context.Background(), resp.ID, tt.from, 42); err != nil {
+					t.Fatalf("moving booking to %s: %v", tt.from, err)
+				}
+			}
+			seatsBefore, _ := f.cache.GetAvailableSeatsByClass(context.Background(), f.regular.ID)
+
+			err := f.service.UpdateBookingStatusFromPayment(context.Background(), bookingID, tt.to, 42)
+			assertDomainError(t, err, tt.want)
+			f.assertCachedSeats(t, f.regular.ID, seatsBefore)
+		})
+	}
+}
+
+// racingBookingStore runs onGet right after a booking has been read, to change
+// the booking before the service writes it back.
+type racingBookingStore struct {
+	*fakes.Store
+	onGet func(id string)
+}
+
+func (r racingBookingStore) GetBookingByID(ctx context.Context, id string) (*models.Booking, error) {
+	booking, err := r.Store.GetBookingByID(ctx, id)
+	if err == nil && r.onGet != nil {
+		r.onGet(id)
+	}
+	return booking, err
+}
+
+func TestUpdateBookingStatusFromPaymentLosesRaceWithCancellation(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+	f.service.BookingRepo = racingBookingStore{Store: f.store, onGet: func(id string) {
+		f.store.TransitionBookingStatus(context.Background(), id, models.BookingStatusPending, models.BookingStatusCancelled)
+	}}
+
+	err := f.service.UpdateBookingStatusFromPayment(context.Background(), resp.ID, models.BookingStatusConfirmed, 42)
+	assertDomainError(t, err, ErrInvalidStatusTransition)
+
+	booking := f.assertStatus(t, resp.ID, models.BookingStatusCancelled)
+	for _, seat := range booking.Seats {
+		if seat.Status == models.SeatStatusBooked {
+			t.Errorf("seat %d was booked for a cancelled booking", seat.ID)
+		}
+	}
+}
+
+func TestProcessDueExpiries(t *testing.T) {
+	f := newBookingFixture(t)
+	expired := f.book(t, 1)
+	confirmed := f.book(t, 2)
+	if err := f.service.UpdateBookingStatusFromPayment(context.Background(), confirmed.ID, models.BookingStatusConfirmed, 42); err != nil {
+		t.Fatal(err)
+	}
+	// A confirmed booking whose schedule entry was left behind is only removed.
+	f.cache.ScheduleBookingExpiry(context.Background(), confirmed.ID, testNow)
+
+	f.clock.Advance(10 * time.Minute)
+	notYetDue := f.book(t, 3)
+
+	f.clock.Advance(6 * time.Minute)
+	f.service.processDueExpiries(context.Background())
+
+	f.assertStatus(t, expired.ID, models.BookingStatusCancelled)
+	f.assertStatus(t, confirmed.ID, models.BookingStatusConfirmed)
+	f.assertStatus(t, notYetDue.ID, models.BookingStatusPending)
+	if _, ok := f.cache.ScheduledExpiry(expired.ID); ok {
+		t.Errorf("expired booking is still scheduled")
+	}
+	if _, ok := f.cache.ScheduledExpiry(confirmed.ID); ok {
+		t.Errorf("confirmed booking is still scheduled")
+	}
+	if _, ok := f.cache.ScheduledExpiry(notYetDue.ID); !ok {
+		t.Errorf("booking that is not due was unscheduled")
+	}
+	// One seat of the expired booking came back, the other two are still held.
+	f.assertCachedSeats(t, f.regular.ID, 8)
+
+	var reasons []string
+	for _, event := range f.events.BookingEvents() {
+		if event.BookingID == expired.ID {
+			reasons = append(reasons, event.Reason)
+		}
+	}
+	if len(reasons) != 1 || reasons[0] != models.CancellationReasonHoldExpired {
+		t.Errorf("events of expired booking have reasons %v, want [%s]", reasons, models.CancellationReasonHoldExpired)
+	}
+}
+
+func TestProcessDueExpiriesReschedulesExtendedHold(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+	f.cache.ScheduleBookingExpiry(context.Background(), resp.ID, testNow.Add(time.Minute))
+
+	f.clock.Advance(2 * time.Minute)
+	f.service.processDueExpiries(context.Background())
+
+	f.assertStatus(t, resp.ID, models.BookingStatusPending)
+	if expiresAt, ok := f.cache.ScheduledExpiry(resp.ID); !ok || !expiresAt.Equal(*resp.ExpiresAt) {
+		t.Errorf("scheduled expiry = %v (scheduled %v), want %v", expiresAt, ok, *resp.ExpiresAt)
+	}
+}
+
+func TestProcessDueExpiriesKeepsClaimWhenCancellationFails(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+	f.store.FailOn("UpdateSeats", errors.New("deadlock"))
+
+	f.clock.Advance(bookingHoldDuration + time.Second)
+	f.service.processDueExpiries(context.Background())
+
+	f.assertStatus(t, resp.ID, models.BookingStatusPending)
+	wantRetry := f.clock.Now().Add(bookingExpiryClaimTimeout)
+	if expiresAt, ok := f.cache.ScheduledExpiry(resp.ID); !ok || !expiresAt.Equal(wantRetry) {
+		t.Errorf("scheduled expiry = %v (scheduled %v), want a retry at %v", expiresAt, ok, wantRetry)
+	}
+
+	f.store.FailOn("UpdateSeats", nil)
+	f.clock.Advance(bookingExpiryClaimTimeout)
+	f.service.processDueExpiries(context.Background())
+	f.assertStatus(t, resp.ID, models.BookingStatusCancelled)
+}
+
+func TestCancelExpiredPendingBookings(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+	// The schedule entry is lost, so only the sweep can release the hold.
+	f.cache.UnscheduleBookingExpiry(context.Background(), resp.ID)
+
+	f.clock.Advance(bookingHoldDuration - time.Second)
+	if err := f.service.CancelExpiredPendingBookings(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	f.assertStatus(t, resp.ID, models.BookingStatusPending)
+
+	f.clock.Advance(2 * time.Second)
+	if err := f.service.CancelExpiredPendingBookings(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	f.assertStatus(t, resp.ID, models.BookingStatusCancelled)
+	f.assertCachedSeats(t, f.regular.ID, 10)
+}
+
+func TestSchedulePendingBookingExpiries(t *testing.T) {
+	f := newBookingFixture(t)
+	resp := f.book(t, 1)
+	f.cache.UnscheduleBookingExpiry(context.Background(), resp.ID)
+
+	if err := f.service.SchedulePendingBookingExpiries(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if expiresAt, ok := f.cache.ScheduledExpiry(resp.ID); !ok || !expiresAt.Equal(*resp.ExpiresAt) {
+		t.Errorf("scheduled expiry = %v (scheduled %v), want %v", expiresAt, ok, *resp.ExpiresAt)
+	}
+
+	f.cache.FailOn("ScheduleBookingExpiry", errors.New("redis down"))
+	if err := f.service.SchedulePendingBookingExpiries(context.Background()); err == nil {
+		t.Errorf("SchedulePendingBookingExpiries() succeeded while Redis is down")
+	}
+}
+
+func TestProcessBookingCancellationMessage(t *testing.T) {
+	message := func(bookingID, reason, actor string) []byte {
+		body, _ := json.Marshal(models.BookingCancellationMessage{BookingID: bookingID, Reason: reason, Actor: actor, RequestedAt: testNow})
+		return body
+	}
+
+	t.Run("cancels pending booking once", func(t *testing.T) {
+		f := newBookingFixture(t)
+		resp := f.book(t, 1)
+		body := message(resp.ID, models.CancellationReasonFraudCheck, "fraud-service")
+
+		for i := 0; i < 2; i++ {
+			if err := f.service.ProcessBookingCancellationMessage(context.Background(), body); err != nil {
+				t.Fatalf("delivery %d: error = %v", i+1, err)
+			}
+		}
+		booking := f.assertStatus(t, resp.ID, models.BookingStatusCancelled)
+		if booking.CancellationReason == nil || *booking.CancellationReason != models.CancellationReasonFraudCheck {
+			t.Errorf("cancellation reason = %v, want %q", booking.CancellationReason, models.CancellationReasonFraudCheck)
+		}
+		f.assertCachedSeats(t, f.regular.ID, 10)
+		if events := f.events.BookingEvents(); len(events) != 1 || events[0].Reason != models.CancellationReasonFraudCheck {
+			t.Errorf("booking events = %+v, want one fraud check cancellation", events)
+		}
+	})
+
+	t.Run("discards message for confirmed booking", func(t *testing.T) {
+		f := newBookingFixture(t)
+		resp := f.book(t, 1)
+		if err := f.service.UpdateBookingStatusFromPayment(context.Background(), resp.ID, models.BookingStatusConfirmed, 42); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.service.ProcessBookingCancellationMessage(context.Background(), message(resp.ID, models.CancellationReasonAdmin, "admin")); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		f.assertStatus(t, resp.ID, models.BookingStatusConfirmed)
+	})
+
+	t.Run("discards message for unknown booking", func(t *testing.T) {
+		f := newBookingFixture(t)
+		if err := f.service.ProcessBookingCancellationMessage(context.Background(), message("does-not-exist", models.CancellationReasonAdmin, "admin")); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+	})
+
+	t.Run("rejects invalid messages", func(t *testing.T) {
+		f := newBookingFixture(t)
+		err := f.service.ProcessBookingCancellationMessage(context.Background(), message("some-id", "", "admin"))
+		assertDomainError(t, err, ErrInvalidCancellation)
+		if !errors.Is(err, utils.ErrPermanent) {
+			t.Errorf("error = %v, want a permanent error", err)
+		}
+
+		if err := f.service.ProcessBookingCancellationMessage(context.Background(), []byte("{not json")); !errors.Is(err, utils.ErrPermanent) {
+			t.Errorf("malformed message: error = %v, want a permanent error", err)
+		}
+
+		resp := f.book(t, 1)
+		err = f.service.ProcessBookingCancellationMessage(context.Background(), message(resp.ID, "changed_my_mind", "admin"))
+		assertDomainError(t, err, ErrInvalidCancellation)
+		if !errors.Is(err, utils.ErrPermanent) {
+			t.Errorf("unknown reason: error = %v, want a permanent error", err)
+		}
+		f.assertStatus(t, resp.ID, models.BookingStatusPending)
+	})
+
+	t.Run("returns infrastructure errors for redelivery", func(t *testing.T) {
+		f := newBookingFixture(t)
+		resp := f.book(t, 1)
+		f.store.FailOn("GetBookingByID", errors.New("connection refused"))
+
+		err := f.service.ProcessBookingCancellationMessage(context.Background(), message(resp.ID, models.CancellationReasonAdmin, "admin"))
+		if !errors.Is(err, ErrInternal) {
+			t.Fatalf("error = %v, want an internal error", err)
+		}
+	})
+}
@@ -20,13 +20,16 @@ const (
 )
 
 type ConcertService struct {
-	ConcertRepo     *repositories.ConcertRepository
-	SeatRepo        *repositories.SeatRepository
-	TicketClassRepo *repositories.TicketClassRepository
+	ConcertRepo repositories.ConcertStore
+	SeatRepo    repositories.SeatStore
+	Transactor  repositories.Transactor
+	Cache       SeatCache
+	Events      EventPublisher
+	Bus         MessageBus
 }
 
-func NewConcertService(cRepo *repositories.ConcertRepository, sRepo *repositories.SeatRepository, tcRepo *repositories.TicketClassRepository) *ConcertService {
-	return &ConcertService{ConcertRepo: cRepo, SeatRepo: sRepo, TicketClassRepo: tcRepo}
+func NewConcertService(cRepo repositories.ConcertStore, sRepo repositories.SeatStore, transactor repositories.Transactor, cache SeatCache, events EventPublisher, bus MessageBus) *ConcertService {
+	return &ConcertService{ConcertRepo: cRepo, SeatRepo: sRepo, Transactor: transactor, Cache: cache, Events: events, Bus: bus}
 }
 
 func (s *ConcertService) CreateConcert(ctx context.Context, req *models.CreateConcertRequest) (*models.ConcertResponse, error) {
@@ -35,7 +38,7 @@ func (s *ConcertService) CreateConcert(ctx context.Context, req *models.CreateCo
 		totalSeats += tcReq.TotalSeatsInClass
 	}
 	if totalSeats == 0 {
-		return nil, errors.New("total seats from ticket classes must be greater than 0")
+		return nil, ErrInvalidTicketClasses
 	}
 
 	concert := &models.Concert{
@@ -43,6 +46,8 @@ func (s *ConcertService) CreateConcert(ctx context.Context, req *models.CreateCo
 		Artist:         req.Artist,
 		Date:           req.Date,
 		Venue:          req.Venue,
+		MinimumAge:     req.MinimumAge,
+		PurchaseLimits: req.PurchaseLimits,
 		TotalSeats:     totalSeats,
 		AvailableSeats: totalSeats,
 		Description:    req.Description,
@@ -61,30 +66,22 @@ func (s *ConcertService) CreateConcert(ctx context.Context, req *models.CreateCo
 	}
 	concert.TicketClasses = ticketClasses
 
-	tx := s.ConcertRepo.DB.Begin()
-	if tx.Error != nil {
-		utils.LogError("Failed to begin DB transaction for concert creation: %v", tx.Error)
-		return nil, errors.New("failed to initiate concert creation transaction")
+	if err := s.ConcertRepo.CreateConcert(ctx, concert); err != nil {
+		utils.LogErrorContext(ctx, "Failed to create concert in DB (initial entry): %v", err)
+		return nil, newInternalError("failed to create concert initial entry", err)
 	}
-
-	if err := s.ConcertRepo.CreateConcert(tx, concert); err != nil {
-		tx.Rollback()
-		utils.LogError("Failed to create concert in DB (initial entry): %v", err)
-		return nil, errors.New("failed to create concert initial entry")
-	}
-
-	tx.Commit()
+	ctx = utils.WithConcertID(ctx, concert.ID)
 
 	for _, tc := range concert.TicketClasses {
 
-		err := utils.SetAvailableSeatsCacheByClass(context.Background(), tc.ID, tc.AvailableSeatsInClass)
+		err := s.Cache.SetAvailableSeatsByClass(ctx, tc.ID, tc.AvailableSeatsInClass)
 		if err != nil {
-			utils.LogWarning("Failed to cache available seats for ticket class %d (concert %d) in Redis: %v", tc.ID, concert.ID, err)
+			utils.LogWarningContext(ctx, "Failed to cache available seats for ticket class %d (concert %d) in Redis: %v", tc.ID, concert.ID, err)
 		}
 	}
 
-	if err := utils.SetAvailableSeatsCache(context.Background(), concert.ID, concert.AvailableSeats); err != nil {
-		utils.LogWarning("Failed to cache total available seats for concert %d in Redis: %v", concert.ID, err)
+	if err := s.Cache.SetAvailableSeats(ctx, concert.ID, concert.AvailableSeats); err != nil {
+		utils.LogWarningContext(ctx, "Failed to cache total available seats for concert %d in Redis: %v", concert.ID, err)
 	}
 
 	var tcMessages []models.TicketClassMessage
@@ -102,41 +99,87 @@ func (s *ConcertService) CreateConcert(ctx context.Context, req *models.CreateCo
 	}
 	msgBody, err := json.Marshal(msg)
 	if err != nil {
-		utils.LogError("Failed to marshal seat creation message for concert %d: %v", concert.ID, err)
-		s.ConcertRepo.DB.Model(&models.Concert{}).Where("id = ?", concert.ID).Update("status", models.ConcertStatusFailed)
-		return nil, errors.New("failed to marshal seat creation message")
+		utils.LogErrorContext(ctx, "Failed to marshal seat creation message for concert %d: %v", concert.ID, err)
+		s.markConcertFailed(ctx, concert.ID)
+		return nil, newInternalError("failed to marshal seat creation message", err)
 	}
 
-	if err := utils.PublishMessage("", utils.SeatCreationQueue(), msgBody); err != nil {
-		utils.LogError("Failed to publish seat creation message for concert %d to RabbitMQ: %v", concert.ID, err)
-		s.ConcertRepo.DB.Model(&models.Concert{}).Where("id = ?", concert.ID).Update("status", models.ConcertStatusFailed)
-		return nil, errors.New("concert created, but failed to initiate seat creation process")
+	if err := s.Bus.Publish(ctx, utils.SeatCreationQueue(), msgBody); err != nil {
+		utils.LogErrorContext(ctx, "Failed to publish seat creation message for concert %d to RabbitMQ: %v", concert.ID, err)
+		s.markConcertFailed(ctx, concert.ID)
+		return nil, newInternalError("concert created, but failed to initiate seat creation process", err)
 	}
 
-	utils.LogInfo("Concert '%s' created (ID: %d), seat creation offloaded to background worker.", concert.Name, concert.ID)
+	utils.LogInfoContext(ctx, "Concert '%s' created (ID: %d), seat creation offloaded to background worker.", concert.Name, concert.ID)
 
 	resp := concert.ToConcertResponse()
 	return &resp, nil
 }
 
+// RepublishPendingSeatCreations re-publishes the seat creation message of every
+// concert still waiting for its seats, e.g. after a crash between creating the
+// concert and publishing the message. It runs on the leader only.
+func (s *ConcertService) RepublishPendingSeatCreations(ctx context.Context) error {
+	utils.LogInfoContext(ctx, "Checking for concerts with 'pending_seat_creation' status...")
+
+	pendingConcerts, err := s.ConcertRepo.GetConcertsByStatus(ctx, models.ConcertStatusPendingSeatCreation)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to get pending_seat_creation concerts: %v", err)
+		return newInternalError("failed to retrieve pending_seat_creation concerts", err)
+	}
+
+	for _, c := range pendingConcerts {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		utils.LogInfoContext(ctx, "Found pending_seat_creation concert (ID: %d, Name: %s). Attempting to trigger seat creation message...", c.ID, c.Name)
+
+		var tcMessages []models.TicketClassMessage
+		for _, tc := range c.TicketClasses {
+			tcMessages = append(tcMessages, models.TicketClassMessage{
+				TicketClassID:     tc.ID,
+				Name:              tc.Name,
+				TotalSeatsInClass: tc.TotalSeatsInClass,
+			})
+		}
+		msg := models.SeatCreationMessage{
+			ConcertID:     c.ID,
+			TotalSeats:    c.TotalSeats,
+			TicketClasses: tcMessages,
+		}
+		msgBody, err := json.Marshal(msg)
+		if err != nil {
+			utils.LogErrorContext(ctx, "Failed to marshal seat creation message for concert %d: %v", c.ID, err)
+			continue
+		}
+
+		if err := s.Bus.Publish(ctx, utils.SeatCreationQueue(), msgBody); err != nil {
+			utils.LogErrorContext(ctx, "Failed to publish seat creation message for concert %d to RabbitMQ: %v", c.ID, err)
+			continue
+		}
+		utils.LogInfoContext(ctx, "Successfully re-published seat creation message for concert %d", c.ID)
+	}
+	return nil
+}
+
 func (s *ConcertService) GetConcerts(ctx context.Context) ([]models.ConcertResponse, error) {
-	concerts, err := s.ConcertRepo.GetConcerts()
+	concerts, err := s.ConcertRepo.GetConcerts(ctx)
 	if err != nil {
-		utils.LogError("Failed to get concerts from DB: %v", err)
-		return nil, errors.New("failed to retrieve concerts")
+		utils.LogErrorContext(ctx, "Failed to get concerts from DB: %v", err)
+		return nil, newInternalError("failed to retrieve concerts", err)
 	}
 
 	var responses []models.ConcertResponse
 	for _, c := range concerts {
 
-		availableSeats, err := utils.GetAvailableSeatsFromCache(ctx, c.ID)
+		availableSeats, err := s.Cache.GetAvailableSeats(ctx, c.ID)
 		if err == nil {
 			c.AvailableSeats = availableSeats
 		} else {
-			utils.LogWarning("Cache miss for available seats for concert %d. Error: %v. Falling back to DB count.", c.ID, err)
-			dbSeats, dbErr := s.SeatRepo.GetSeatsByConcertID(c.ID)
+			utils.LogWarningContext(ctx, "Cache miss for available seats for concert %d. Error: %v. Falling back to DB count.", c.ID, err)
+			dbSeats, dbErr := s.SeatRepo.GetSeatsByConcertID(ctx, c.ID)
 			if dbErr != nil {
-				utils.LogError("Failed to get seats from DB for concert %d during fallback: %v", c.ID, dbErr)
+				utils.LogErrorContext(ctx, "Failed to get seats from DB for concert %d during fallback: %v", c.ID, dbErr)
 				c.AvailableSeats = 0
 			} else {
 				availableCount := 0
@@ -147,23 +190,23 @@ func (s *ConcertService) GetConcerts(ctx context.Context) ([]models.ConcertRespo
 				}
 				c.AvailableSeats = availableCount
 
-				if err := utils.SetAvailableSeatsCache(ctx, c.ID, availableCount); err != nil {
-					utils.LogWarning("Failed to re-cache available seats for concert %d: %v", c.ID, err)
+				if err := s.Cache.SetAvailableSeats(ctx, c.ID, availableCount); err != nil {
+					utils.LogWarningContext(ctx, "Failed to re-cache available seats for concert %d: %v", c.ID, err)
 				}
 			}
 		}
 
 		for i := range c.TicketClasses {
 			tc := &c.TicketClasses[i]
-			availableSeatsClass, errClass := utils.GetAvailableSeatsCacheByClass(ctx, tc.ID)
+			availableSeatsClass, errClass := s.Cache.GetAvailableSeatsByClass(ctx, tc.ID)
 			if errClass == nil {
 				tc.AvailableSeatsInClass = availableSeatsClass
 			} else {
-				utils.LogWarning("Cache miss for available seats for ticket class %d. Error: %v. Falling back to DB count.", tc.ID, errClass)
+				utils.LogWarningContext(ctx, "Cache miss for available seats for ticket class %d. Error: %v. Falling back to DB count.", tc.ID, errClass)
 
-				seatsInClass, err := s.SeatRepo.GetSeatsByTicketClassID(tc.ID)
+				seatsInClass, err := s.SeatRepo.GetSeatsByTicketClassID(ctx, tc.ID)
 				if err != nil {
-					utils.LogError("Failed to get seats for ticket class %d from DB during fallback: %v", tc.ID, err)
+					utils.LogErrorContext(ctx, "Failed to get seats for ticket class %d from DB during fallback: %v", tc.ID, err)
 					tc.AvailableSeatsInClass = 0
 				} else {
 					availableCount := 0
@@ -173,8 +216,8 @@ func (s *ConcertService) GetConcerts(ctx context.Context) ([]models.ConcertRespo
 						}
 					}
 					tc.AvailableSeatsInClass = availableCount
-					if err := utils.SetAvailableSeatsCacheByClass(ctx, tc.ID, availableCount); err != nil {
-						utils.LogWarning("Failed to re-cache available seats for ticket class %d: %v", tc.ID, err)
+					if err := s.Cache.SetAvailableSeatsByClass(ctx, tc.ID, availableCount); err != nil {
+						utils.LogWarningContext(ctx, "Failed to re-cache available seats for ticket class %d: %v", tc.ID, err)
 					}
 				}
 			}
@@ -185,23 +228,24 @@ func (s *ConcertService) GetConcerts(ctx context.Context) ([]models.ConcertRespo
 }
 
 func (s *ConcertService) GetConcertByID(ctx context.Context, id uint) (*models.ConcertResponse, error) {
-	concert, err := s.ConcertRepo.GetConcertByID(id)
+	ctx = utils.WithConcertID(ctx, id)
+	concert, err := s.ConcertRepo.GetConcertByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("concert not found")
+			return nil, ErrConcertNotFound
 		}
-		utils.LogError("Failed to get concert ID %d from DB: %v", id, err)
-		return nil, errors.New("failed to retrieve concert")
+		utils.LogErrorContext(ctx, "Failed to get concert ID %d from DB: %v", id, err)
+		return nil, newInternalError("failed to retrieve concert", err)
 	}
 
-	availableSeats, errCache := utils.GetAvailableSeatsFromCache(ctx, concert.ID)
+	availableSeats, errCache := s.Cache.GetAvailableSeats(ctx, concert.ID)
 	if errCache == nil {
 		concert.AvailableSeats = availableSeats
 	} else {
-		utils.LogWarning("Cache miss for available seats for concert %d. Error: %v. Falling back to DB count.", concert.ID, errCache)
-		dbSeats, dbErr := s.SeatRepo.GetSeatsByConcertID(concert.ID)
+		utils.LogWarningContext(ctx, "Cache miss for available seats for concert %d. Error: %v. Falling back to DB count.", concert.ID, errCache)
+		dbSeats, dbErr := s.SeatRepo.GetSeatsByConcertID(ctx, concert.ID)
 		if dbErr != nil {
-			utils.LogError("Failed to get seats from DB for concert %d during fallback: %v", concert.ID, dbErr)
+			utils.LogErrorContext(ctx, "Failed to get seats from DB for concert %d during fallback: %v", concert.ID, dbErr)
 			concert.AvailableSeats = 0
 		} else {
 			availableCount := 0
@@ -212,22 +256,22 @@ func (s *ConcertService) GetConcertByID(ctx context.Context, id uint) (*models.C
 			}
 			concert.AvailableSeats = availableCount
 
-			if err := utils.SetAvailableSeatsCache(ctx, concert.ID, availableCount); err != nil {
-				utils.LogWarning("Failed to re-cache available seats for concert %d: %v", concert.ID, err)
+			if err := s.Cache.SetAvailableSeats(ctx, concert.ID, availableCount); err != nil {
+				utils.LogWarningContext(ctx, "Failed to re-cache available seats for concert %d: %v", concert.ID, err)
 			}
 		}
 	}
 
 	for i := range concert.TicketClasses {
 		tc := &concert.TicketClasses[i]
-		availableSeatsClass, errClass := utils.GetAvailableSeatsCacheByClass(ctx, tc.ID)
+		availableSeatsClass, errClass := s.Cache.GetAvailableSeatsByClass(ctx, tc.ID)
 		if errClass == nil {
 			tc.AvailableSeatsInClass = availableSeatsClass
 		} else {
-			utils.LogWarning("Cache miss for available seats for ticket class %d. Error: %v. Falling back to DB count.", tc.ID, errClass)
-			seatsInClass, err := s.SeatRepo.GetSeatsByTicketClassID(tc.ID)
+			utils.LogWarningContext(ctx, "Cache miss for available seats for ticket class %d. Error: %v. Falling back to DB count.", tc.ID, errClass)
+			seatsInClass, err := s.SeatRepo.GetSeatsByTicketClassID(ctx, tc.ID)
 			if err != nil {
-				utils.LogError("Failed to get seats for ticket class %d from DB during fallback: %v", tc.ID, err)
+				utils.LogErrorContext(ctx, "Failed to get seats for ticket class %d from DB during fallback: %v", tc.ID, err)
 				tc.AvailableSeatsInClass = 0
 			} else {
 				availableCount := 0
@@ -237,8 +281,8 @@ func (s *ConcertService) GetConcertByID(ctx context.Context, id uint) (*models.C
 					}
 				}
 				tc.AvailableSeatsInClass = availableCount
-				if err := utils.SetAvailableSeatsCacheByClass(ctx, tc.ID, availableCount); err != nil {
-					utils.LogWarning("Failed to re-cache available seats for ticket class %d: %v", tc.ID, err)
+				if err := s.Cache.SetAvailableSeatsByClass(ctx, tc.ID, availableCount); err != nil {
+					utils.LogWarningContext(ctx, "Failed to re-cache available seats for ticket class %d: %v", tc.ID, err)
 				}
 			}
 		}
@@ -249,14 +293,15 @@ func (s *ConcertService) GetConcertByID(ctx context.Context, id uint) (*models.C
 }
 
 func (s *ConcertService) GetSeatsForConcert(ctx context.Context, concertID uint) ([]models.SeatResponse, error) {
+	ctx = utils.WithConcertID(ctx, concertID)
 
-	concert, err := s.ConcertRepo.GetConcertByID(concertID)
+	concert, err := s.ConcertRepo.GetConcertByID(ctx, concertID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("concert not found")
+			return nil, ErrConcertNotFound
 		}
-		utils.LogError("Failed to verify concert existence for seat fetching: %v", err)
-		return nil, errors.New("database error checking concert existence")
+		utils.LogErrorContext(ctx, "Failed to verify concert existence for seat fetching: %v", err)
+		return nil, newInternalError("database error checking concert existence", err)
 	}
 
 	ticketClassNames := make(map[uint]string)
@@ -264,10 +309,10 @@ func (s *ConcertService) GetSeatsForConcert(ctx context.Context, concertID uint)
 		ticketClassNames[tc.ID] = tc.Name
 	}
 
-	seats, err := s.SeatRepo.GetSeatsByConcertID(concertID)
+	seats, err := s.SeatRepo.GetSeatsByConcertID(ctx, concertID)
 	if err != nil {
-		utils.LogError("Failed to get seats for concert %d from DB: %v", concertID, err)
-		return nil, errors.New("failed to retrieve seats")
+		utils.LogErrorContext(ctx, "Failed to get seats for concert %d from DB: %v", concertID, err)
+		return nil, newInternalError("failed to retrieve seats", err)
 	}
 
 	var responses []models.SeatResponse
@@ -279,33 +324,27 @@ func (s *ConcertService) GetSeatsForConcert(ctx context.Context, concertID uint)
 	return responses, nil
 }
 
-func (s *ConcertService) ProcessSeatCreationMessage(body []byte) error {
+func (s *ConcertService) ProcessSeatCreationMessage(ctx context.Context, body []byte) error {
 	var msg models.SeatCreationMessage
 	if err := json.Unmarshal(body, &msg); err != nil {
-		utils.LogError("Failed to unmarshal seat creation message: %v", err)
-		return err
+		utils.LogErrorContext(ctx, "Failed to unmarshal seat creation message: %v", err)
+		return utils.Permanent(err)
 	}
+	ctx = utils.WithConcertID(ctx, msg.ConcertID)
 
-	utils.LogInfo("Processing background seat creation for Concert ID: %d, Total Seats: %d, Classes: %v", msg.ConcertID, msg.TotalSeats, msg.TicketClasses)
+	utils.LogInfoContext(ctx, "Processing background seat creation for Concert ID: %d, Total Seats: %d, Classes: %v", msg.ConcertID, msg.TotalSeats, msg.TicketClasses)
 
-	concert, err := s.ConcertRepo.GetConcertByID(msg.ConcertID)
+	concert, err := s.ConcertRepo.GetConcertByID(ctx, msg.ConcertID)
 	if err != nil {
-		utils.LogError("Concert ID %d not found for seat creation background process: %v", msg.ConcertID, err)
-		s.ConcertRepo.DB.Model(&models.Concert{}).Where("id = ?", msg.ConcertID).Update("status", models.ConcertStatusFailed)
+		utils.LogErrorContext(ctx, "Concert ID %d not found for seat creation background process: %v", msg.ConcertID, err)
+		s.markConcertFailed(ctx, msg.ConcertID)
 		return err
 	}
 	if concert.Status != models.ConcertStatusPendingSeatCreation {
-		utils.LogWarning("Concert %d is not in 'pending_seat_creation' status. Current status: %s. Skipping seat creation.", msg.ConcertID, concert.Status)
+		utils.LogWarningContext(ctx, "Concert %d is not in 'pending_seat_creation' status. Current status: %s. Skipping seat creation.", msg.ConcertID, concert.Status)
 		return nil
 	}
 
-	tx := s.ConcertRepo.DB.Begin()
-	if tx.Error != nil {
-		utils.LogError("Failed to begin DB transaction for background seat creation for concert %d: %v", msg.ConcertID, tx.Error)
-		s.ConcertRepo.DB.Model(&models.Concert{}).Where("id = ?", msg.ConcertID).Update("status", models.ConcertStatusFailed)
-		return errors.New("failed to initiate background seat creation transaction")
-	}
-
 	ticketClassesMap := make(map[uint]models.TicketClassMessage)
 	for _, tcMsg := range msg.TicketClasses {
 		ticketClassesMap[tcMsg.TicketClassID] = tcMsg
@@ -315,7 +354,7 @@ func (s *ConcertService) ProcessSeatCreationMessage(body []byte) error {
 	for _, tc := range concert.TicketClasses {
 		tcMsg, exists := ticketClassesMap[tc.ID]
 		if !exists {
-			utils.LogError("TicketClass ID %d for concert %d not found in message. Skipping seats for this class.", tc.ID, msg.ConcertID)
+			utils.LogErrorContext(ctx, "TicketClass ID %d for concert %d not found in message. Skipping seats for this class.", tc.ID, msg.ConcertID)
 			continue
 		}
 
@@ -330,44 +369,64 @@ func (s *ConcertService) ProcessSeatCreationMessage(body []byte) error {
 		}
 	}
 
-	const seatBatchSize = 200
-	if err := s.SeatRepo.CreateSeatsInBatches(tx, allSeatsToCreate, seatBatchSize); err != nil {
-		tx.Rollback()
-		utils.LogError("Failed to create seats in background for concert %d: %v", msg.ConcertID, err)
-		s.ConcertRepo.DB.Model(&models.Concert{}).Where("id = ?", msg.ConcertID).Update("status", models.ConcertStatusFailed)
-		return errors.New("failed to create seats in background")
-	}
+	seatCreationFailed := false
+	err = s.Transactor.WithinTransaction(ctx, func(tx repositories.Stores) error {
+		const seatBatchSize = 200
+		if err := tx.Seats.CreateSeatsInBatches(ctx, allSeatsToCreate, seatBatchSize); err != nil {
+			utils.LogErrorContext(ctx, "Failed to create seats in background for concert %d: %v", msg.ConcertID, err)
+			seatCreationFailed = true
+			return newInternalError("failed to create seats in background", err)
+		}
 
-	for i := range concert.TicketClasses {
-		tc := &concert.TicketClasses[i]
-		tcMsg, exists := ticketClassesMap[tc.ID]
-		if exists {
-			tc.AvailableSeatsInClass = tcMsg.TotalSeatsInClass
-			if err := s.TicketClassRepo.UpdateTicketClass(tx, tc); err != nil {
-				utils.LogError("Failed to update TicketClass %d available seats for concert %d: %v", tc.ID, msg.ConcertID, err)
+		for i := range concert.TicketClasses {
+			tc := &concert.TicketClasses[i]
+			tcMsg, exists := ticketClassesMap[tc.ID]
+			if exists {
+				tc.AvailableSeatsInClass = tcMsg.TotalSeatsInClass
+				if err := tx.TicketClasses.UpdateTicketClass(ctx, tc); err != nil {
+					utils.LogErrorContext(ctx, "Failed to update TicketClass %d available seats for concert %d: %v", tc.ID, msg.ConcertID, err)
+				}
 			}
 		}
-	}
 
-	concert.Status = models.ConcertStatusActive
-	concert.AvailableSeats = concert.TotalSeats
-	if err := s.ConcertRepo.UpdateConcert(tx, concert); err != nil {
-		tx.Rollback()
-		utils.LogError("Failed to update concert status to 'active' after seat creation for concert %d: %v", msg.ConcertID, err)
-		return errors.New("failed to update concert status after seat creation")
+		concert.Status = models.ConcertStatusActive
+		concert.AvailableSeats = concert.TotalSeats
+		if err := tx.Concerts.UpdateConcert(ctx, concert); err != nil {
+			utils.LogErrorContext(ctx, "Failed to update concert status to 'active' after seat creation for concert %d: %v", msg.ConcertID, err)
+			return newInternalError("failed to update concert status after seat creation", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if seatCreationFailed {
+			s.markConcertFailed(ctx, msg.ConcertID)
+		}
+		return transactionError(err, "failed to commit background seat creation")
 	}
 
-	if err := utils.SetAvailableSeatsCache(context.Background(), concert.ID, concert.AvailableSeats); err != nil {
-		utils.LogWarning("Failed to cache initial available seats for concert %d in Redis: %v", concert.ID, err)
+	if err := s.Cache.SetAvailableSeats(ctx, concert.ID, concert.AvailableSeats); err != nil {
+		utils.LogWarningContext(ctx, "Failed to cache initial available seats for concert %d in Redis: %v", concert.ID, err)
 	}
 
 	for _, tc := range concert.TicketClasses {
-		if err := utils.SetAvailableSeatsCacheByClass(context.Background(), tc.ID, tc.AvailableSeatsInClass); err != nil {
-			utils.LogWarning("Failed to cache available seats for ticket class %d in Redis after seat creation: %v", tc.ID, err)
+		if err := s.Cache.SetAvailableSeatsByClass(ctx, tc.ID, tc.AvailableSeatsInClass); err != nil {
+			utils.LogWarningContext(ctx, "Failed to cache available seats for ticket class %d in Redis after seat creation: %v", tc.ID, err)
 		}
 	}
 
-	tx.Commit()
-	utils.LogInfo("Successfully created %d seats for Concert ID: %d and set status to ACTIVE.", msg.TotalSeats, msg.ConcertID)
+	utils.LogInfoContext(ctx, "Successfully created %d seats for Concert ID: %d and set status to ACTIVE.", msg.TotalSeats, msg.ConcertID)
+
+	snapshot := concert.ToConcertResponse()
+	s.Events.PublishAvailabilityEvent(ctx, models.AvailabilityEvent{
+		Type:      models.AvailabilityEventSnapshot,
+		ConcertID: concert.ID,
+		Concert:   &snapshot,
+	})
 	return nil
 }
+
+func (s *ConcertService) markConcertFailed(ctx context.Context, concertID uint) {
+	if err := s.ConcertRepo.UpdateConcertStatus(ctx, concertID, models.ConcertStatusFailed); err != nil {
+		utils.LogErrorContext(ctx, "Failed to mark concert %d as failed: %v", concertID, err)
+	}
+}
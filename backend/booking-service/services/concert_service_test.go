@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"backend/booking-service/fakes"
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+)
+
+type concertFixture struct {
+	store   *fakes.Store
+	cache   *fakes.SeatCache
+	events  *fakes.EventPublisher
+	bus     *fakes.Bus
+	service *ConcertService
+}
+
+func newConcertFixture() *concertFixture {
+	f := &concertFixture{
+		store:  fakes.NewStore(),
+		cache:  fakes.NewSeatCache(),
+		events: fakes.NewEventPublisher(),
+		bus:    fakes.NewBus(),
+	}
+	f.service = NewConcertService(f.store, f.store, f.store, f.cache, f.events, f.bus)
+	return f
+}
+
+func createConcertRequest() *models.CreateConcertRequest {
+	return &models.CreateConcertRequest{
+		Name:   "Java Jazz",
+		Artist: "Various",
+		Date:   testNow,
+		Venue:  "JIExpo",
+		TicketClasses: []models.CreateTicketClassRequest{
+			{Name: "VIP", Price: 100, TotalSeatsInClass: 2},
+			{Name: "Regular", Price: 50, TotalSeatsInClass: 3},
+		},
+	}
+}
+
+func TestCreateConcertCreatesSeatsInBackground(t *testing.T) {
+	f := newConcertFixture()
+	f.bus.Subscribe(utils.SeatCreationQueue(), f.service.ProcessSeatCreationMessage)
+
+	resp, err := f.service.CreateConcert(context.Background(), createConcertRequest())
+	if err != nil {
+		t.Fatalf("CreateConcert() error = %v", err)
+	}
+	if resp.TotalSeats != 5 {
+		t.Errorf("TotalSeats = %d, want 5", resp.TotalSeats)
+	}
+
+	concert, _ := f.store.Concert(resp.ID)
+	if concert.Status != models.ConcertStatusActive {
+		t.Errorf("concert status = %q, want %q", concert.Status, models.ConcertStatusActive)
+	}
+	seats, _ := f.store.GetSeatsByConcertID(context.Background(), resp.ID)
+	if len(seats) != 5 {
+		t.Errorf("%d seats created, want 5", len(seats))
+	}
+	for _, tc := range concert.TicketClasses {
+		if got, _ := f.cache.GetAvailableSeatsByClass(context.Background(), tc.ID); got != tc.TotalSeatsInClass {
+			t.Errorf("cached seats of class %s = %d, want %d", tc.Name, got, tc.TotalSeatsInClass)
+		}
+	}
+	if got, _ := f.cache.GetAvailableSeats(context.Background(), resp.ID); got != 5 {
+		t.Errorf("cached seats of concert = %d, want 5", got)
+	}
+	if events := f.events.AvailabilityEvents(); len(events) != 1 || events[0].Type != models.AvailabilityEventSnapshot {
+		t.Errorf("availability events = %+v, want one snapshot", events)
+	}
+}
+
+func TestCreateConcertRejectsEmptyTicketClasses(t *testing.T) {
+	f := newConcertFixture()
+	req := createConcertRequest()
+	req.TicketClasses = nil
+
+	_, err := f.service.CreateConcert(context.Background(), req)
+	assertDomainError(t, err, ErrInvalidTicketClasses)
+}
+
+func TestCreateConcertMarksConcertFailedWhenPublishFails(t *testing.T) {
+	f := newConcertFixture()
+	f.bus.FailOn("Publish", errors.New("channel closed"))
+
+	_, err := f.service.CreateConcert(context.Background(), createConcertRequest())
+	if !errors.Is(err, ErrInternal) {
+		t.Fatalf("error = %v, want an internal error", err)
+	}
+	concerts, _ := f.store.GetConcertsByStatus(context.Background(), models.ConcertStatusFailed)
+	if len(concerts) != 1 {
+		t.Errorf("%d failed concerts, want 1", len(concerts))
+	}
+}
+
+func TestProcessSeatCreationMessage(t *testing.T) {
+	t.Run("marks concert failed when seats cannot be created", func(t *testing.T) {
+		f := newConcertFixture()
+		resp, err := f.service.CreateConcert(context.Background(), createConcertRequest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.store.FailOn("CreateSeatsInBatches", errors.New("disk full"))
+
+		if err := f.service.ProcessSeatCreationMessage(context.Background(), f.bus.Messages(utils.SeatCreationQueue())[0]); err == nil {
+			t.Fatalf("ProcessSeatCreationMessage() succeeded, want an error")
+		}
+		concert, _ := f.store.Concert(resp.ID)
+		if concert.Status != models.ConcertStatusFailed {
+			t.Errorf("concert status = %q, want %q", concert.Status, models.ConcertStatusFailed)
+		}
+	})
+
+	t.Run("rolls back seats when concert update fails", func(t *testing.T) {
+		f := newConcertFixture()
+		resp, err := f.service.CreateConcert(context.Background(), createConcertRequest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.store.FailOn("UpdateConcert", errors.New("deadlock"))
+
+		if err := f.service.ProcessSeatCreationMessage(context.Background(), f.bus.Messages(utils.SeatCreationQueue())[0]); err == nil {
+			t.Fatalf("ProcessSeatCreationMessage() succeeded, want an error")
+		}
+		if seats, _ := f.store.GetSeatsByConcertID(context.Background(), resp.ID); len(seats) != 0 {
+			t.Errorf("%d seats left behind, want none", len(seats))
+		}
+		concert, _ := f.store.Concert(resp.ID)
+		if concert.Status != models.ConcertStatusPendingSeatCreation {
+			t.Errorf("concert status = %q, want it to stay %q for a retry", concert.Status, models.ConcertStatusPendingSeatCreation)
+		}
+	})
+
+	t.Run("skips redelivered message", func(t *testing.T) {
+		f := newConcertFixture()
+		f.bus.Subscribe(utils.SeatCreationQueue(), f.service.ProcessSeatCreationMessage)
+		resp, err := f.service.CreateConcert(context.Background(), createConcertRequest())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := json.Marshal(models.SeatCreationMessage{ConcertID: resp.ID, TotalSeats: resp.TotalSeats})
+		if err := f.service.ProcessSeatCreationMessage(context.Background(), body); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if seats, _ := f.store.GetSeatsByConcertID(context.Background(), resp.ID); len(seats) != 5 {
+			t.Errorf("%d seats, want 5", len(seats))
+		}
+	})
+}
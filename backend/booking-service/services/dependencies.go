@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+// SeatCache holds the live seat counters and the booking expiry schedule. In
+// production both live in Redis (utils.RedisSeatCache).
+type SeatCache interface {
+	SetAvailableSeats(ctx context.Context, concertID uint, availableSeats int) error
+	SetAvailableSeatsByClass(ctx context.Context, ticketClassID uint, availableSeats int) error
+	GetAvailableSeats(ctx context.Context, concertID uint) (int, error)
+	GetAvailableSeatsByClass(ctx context.Context, ticketClassID uint) (int, error)
+	// DecreaseAvailableSeats reserves seats of a ticket class. It fails with
+	// utils.ErrNotEnoughSeats or utils.ErrSeatContention.
+	DecreaseAvailableSeats(ctx context.Context, concertID, ticketClassID uint, numSeats int) (int64, error)
+	IncreaseAvailableSeats(ctx context.Context, concertID, ticketClassID uint, numSeats int) (int64, error)
+
+	ScheduleBookingExpiry(ctx context.Context, bookingID string, expiresAt time.Time) error
+	UnscheduleBookingExpiry(ctx context.Context, bookingID string) error
+	ClaimDueBookingExpiries(ctx context.Context, now time.Time, claimTimeout time.Duration, limit int) ([]string, error)
+}
+
+// EventPublisher broadcasts availability and booking events to every replica.
+// Publishing is best effort, so it does not return errors.
+type EventPublisher interface {
+	PublishAvailabilityEvent(ctx context.Context, event models.AvailabilityEvent)
+	PublishBookingEvent(ctx context.Context, event models.BookingEvent)
+}
+
+// MessageBus publishes messages to a work queue (RabbitMQ in production).
+type MessageBus interface {
+	Publish(ctx context.Context, queue string, body []byte) error
+	// Ping fails when the bus cannot take messages; the readiness probe uses it.
+	Ping(ctx context.Context) error
+}
+
+type Clock interface {
+	Now() time.Time
+}
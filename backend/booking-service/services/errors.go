@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+)
+
+// Error kinds group domain errors by how a caller should react. Check them with
+// errors.Is, e.g. errors.Is(err, services.ErrNotFound).
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrForbidden    = errors.New("forbidden")
+	ErrInternal     = errors.New("internal error")
+)
+
+// DomainError is the structured error returned by the service layer. Code is the
+// stable code sent to clients and Message is safe to show them; Err is the
+// underlying cause and only ends up in logs.
+type DomainError struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *DomainError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *DomainError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Is matches any DomainError with the same code, so errors.Is(err, ErrBookingNotFound)
+// also holds for copies made with Withf or Wrap.
+func (e *DomainError) Is(target error) bool {
+	t, ok := target.(*DomainError)
+	return ok && t.Code == e.Code
+}
+
+// Withf returns a copy of the error with a more specific message.
+func (e *DomainError) Withf(format string, args ...any) *DomainError {
+	copied := *e
+	copied.Message = fmt.Sprintf(format, args...)
+	return &copied
+}
+
+// Wrap returns a copy of the error that records its cause.
+func (e *DomainError) Wrap(err error) *DomainError {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+func newInternalError(message string, err error) *DomainError {
+	return &DomainError{Kind: ErrInternal, Code: models.CodeInternalError, Message: message, Err: err}
+}
+
+// transactionError passes domain errors raised inside a transaction through and
+// wraps anything else, such as a failed commit, as an internal error.
+func transactionError(err error, message string) error {
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) {
+		return err
+	}
+	utils.LogError("Transaction failed: %s: %v", message, err)
+	return newInternalError(message, err)
+}
+
+var (
+	ErrConcertNotFound          = &DomainError{Kind: ErrNotFound, Code: models.CodeConcertNotFound, Message: "concert not found"}
+	ErrConcertNotActive         = &DomainError{Kind: ErrConflict, Code: models.CodeConcertNotActive, Message: "concert is not active for booking"}
+	ErrInvalidTicketClasses     = &DomainError{Kind: ErrInvalidInput, Code: models.CodeInvalidTicketClasses, Message: "total seats from ticket classes must be greater than 0"}
+	ErrTicketClassNotFound      = &DomainError{Kind: ErrNotFound, Code: models.CodeTicketClassNotFound, Message: "ticket class not found for concert"}
+	ErrInvalidTicketQuantity    = &DomainError{Kind: ErrInvalidInput, Code: models.CodeInvalidTicketQuantity, Message: "invalid total number of tickets requested (must be between 1 and 5)"}
+	ErrInvalidKTPNumber         = &DomainError{Kind: ErrInvalidInput, Code: models.CodeInvalidKTPNumber, Message: "invalid KTP number"}
+	ErrAgeRestricted            = &DomainError{Kind: ErrForbidden, Code: models.CodeAgeRestricted, Message: "the ticket holder is below the minimum age of this concert"}
+	ErrNotEnoughSeats           = &DomainError{Kind: ErrConflict, Code: models.CodeNotEnoughSeats, Message: "not enough seats available"}
+	ErrSeatReservationContended = &DomainError{Kind: ErrConflict, Code: models.CodeSeatReservationContended, Message: "too many concurrent reservations, please try again"}
+	ErrActiveBookingExists      = &DomainError{Kind: ErrConflict, Code: models.CodeActiveBookingExists, Message: "you already have an active (pending or confirmed) booking for this concert. Please cancel your existing booking to proceed"}
+	ErrPurchaseLimitExceeded    = &DomainError{Kind: ErrConflict, Code: models.CodePurchaseLimitExceeded, Message: "the purchase limit of this concert has been reached"}
+	ErrBookingNotFound          = &DomainError{Kind: ErrNotFound, Code: models.CodeBookingNotFound, Message: "booking not found"}
+	ErrBookingAccessDenied      = &DomainError{Kind: ErrForbidden, Code: models.CodeBookingAccessDenied, Message: "unauthorized: you can only access your own bookings"}
+	ErrBookingNotCancellable    = &DomainError{Kind: ErrConflict, Code: models.CodeBookingNotCancellable, Message: "only pending bookings can be cancelled"}
+	ErrBookingNotConfirmed      = &DomainError{Kind: ErrConflict, Code: models.CodeBookingNotConfirmed, Message: "only confirmed bookings can be checked in"}
+	ErrInvalidStatusTransition  = &DomainError{Kind: ErrConflict, Code: models.CodeInvalidStatusTransition, Message: "invalid booking status transition"}
+	ErrUnsupportedBookingStatus = &DomainError{Kind: ErrInvalidInput, Code: models.CodeUnsupportedBookingStatus, Message: "unsupported booking status"}
+	ErrInvalidCancellation      = &DomainError{Kind: ErrInvalidInput, Code: models.CodeInvalidCancellation, Message: "invalid booking cancellation message: booking_id, reason and actor are required"}
+	ErrQueueNotFound            = &DomainError{Kind: ErrNotFound, Code: models.CodeQueueNotFound, Message: "queue not found"}
+	ErrInvalidDateRange         = &DomainError{Kind: ErrInvalidInput, Code: models.CodeInvalidDateRange, Message: "invalid date range: 'from' must be before 'to'"}
+	ErrUnsupportedReportGroup   = &DomainError{Kind: ErrInvalidInput, Code: models.CodeUnsupportedReportGroup, Message: "unsupported report grouping"}
+)
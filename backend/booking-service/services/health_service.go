@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+)
+
+// readinessCheckTimeout bounds each dependency check, so a hung dependency
+// fails the probe instead of stalling it.
+const readinessCheckTimeout = 2 * time.Second
+
+// HealthCheck checks one dependency the service cannot serve traffic without.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthService struct {
+	checks        []HealthCheck
+	dbStats       func() sql.DBStats
+	leaderElector *LeaderElector
+}
+
+func NewHealthService(checks []HealthCheck, dbStats func() sql.DBStats, le *LeaderElector) *HealthService {
+	return &HealthService{checks: checks, dbStats: dbStats, leaderElector: le}
+}
+
+// Readiness runs every check concurrently. The service is ready only if all of
+// them pass.
+func (s *HealthService) Readiness(ctx context.Context) models.ReadinessReport {
+	report := models.ReadinessReport{
+		Status: models.HealthStatusOK,
+		Checks: make([]models.DependencyCheck, len(s.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status != models.HealthStatusOK {
+			report.Status = models.HealthStatusDown
+			utils.LogWarningContext(ctx, "Readiness check '%s' failed: %s", check.Name, check.Error)
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check HealthCheck) models.DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	started := time.Now()
+	err := check.Check(ctx)
+	result := models.DependencyCheck{
+		Name:    check.Name,
+		Status:  models.HealthStatusOK,
+		Latency: time.Since(started).Round(time.Microsecond).String(),
+	}
+	if err != nil {
+		result.Status = models.HealthStatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Diagnostics reports readiness together with pool usage, consumer and leader
+// state and the build of this instance.
+func (s *HealthService) Diagnostics(ctx context.Context) models.Diagnostics {
+	diagnostics := models.Diagnostics{
+		Readiness: s.Readiness(ctx),
+		Build:     utils.Build(),
+		Consumers: utils.ConsumerStatuses(),
+	}
+
+	if s.dbStats != nil {
+		stats := s.dbStats()
+		diagnostics.Database = models.DBPoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDuration:       stats.WaitDuration.String(),
+		}
+	}
+
+	if utils.RedisClient != nil {
+		stats := utils.RedisClient.PoolStats()
+		diagnostics.Redis = &models.RedisPoolStats{
+			TotalConnections: stats.TotalConns,
+			IdleConnections:  stats.IdleConns,
+			StaleConnections: stats.StaleConns,
+			Hits:             stats.Hits,
+			Misses:           stats.Misses,
+			Timeouts:         stats.Timeouts,
+		}
+	}
+
+	if s.leaderElector != nil {
+		leader := s.leaderElector.Status(ctx)
+		diagnostics.Leader = &leader
+	}
+	return diagnostics
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	leaderLeaseKey = "booking-service:leader"
+	leaderTermKey  = "booking-service:leader:term"
+)
+
+// acquireLeaseScript takes the lease if nobody holds it and hands out the next
+// term number, which tells successive leaders apart in logs and the job status.
+// Nothing checks it, so it is not a fencing token.
+var acquireLeaseScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// LeaderElector keeps a Redis lease so that scheduled jobs normally run on one
+// replica at a time. The holder renews the lease every third of its TTL and
+// steps down as soon as a renewal fails. That narrows but does not close the
+// window in which two replicas both believe they lead: a leader that stalls
+// past its lease keeps running until its next renewal. Jobs must therefore be
+// safe to run twice at once, as the ones scheduled in main.go are.
+type LeaderElector struct {
+	InstanceID string
+	LeaseTTL   time.Duration
+
+	mu          sync.RWMutex
+	isLeader    bool
+	term        int64
+	leaderSince time.Time
+	leaderCtx   context.Context
+	stepDown    context.CancelFunc
+	onElected   []func(ctx context.Context)
+	jobs        map[string]*models.JobStatus
+}
+
+func NewLeaderElector(leaseTTL time.Duration) *LeaderElector {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "booking-service"
+	}
+	return &LeaderElector{
+		InstanceID: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8]),
+		LeaseTTL:   leaseTTL,
+		jobs:       make(map[string]*models.JobStatus),
+	}
+}
+
+// OnElected registers a function that runs every time this instance becomes the
+// leader. Its context is cancelled when leadership is lost. Register hooks before
+// calling Run.
+func (e *LeaderElector) OnElected(fn func(ctx context.Context)) {
+	e.mu.Lock()
+	e.onElected = append(e.onElected, fn)
+	e.mu.Unlock()
+}
+
+// Run campaigns for and renews the lease until ctx is cancelled.
+func (e *LeaderElector) Run(ctx context.Context) {
+	utils.LogInfoContext(ctx, "Leader election started for instance %s (lease TTL %s).", e.InstanceID, e.LeaseTTL)
+	ticker := time.NewTicker(e.LeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		if e.IsLeader() {
+			if err := e.renew(ctx); err != nil {
+				e.resignLocally(err.Error())
+			}
+		} else {
+			e.tryAcquire(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *LeaderElector) tryAcquire(ctx context.Context) {
+	callCtx, cancel := context.WithTimeout(ctx, e.LeaseTTL/3)
+	defer cancel()
+
+	token, err := acquireLeaseScript.Run(callCtx, utils.RedisClient, []string{leaderLeaseKey, leaderTermKey}, e.InstanceID, e.LeaseTTL.Milliseconds()).Int64()
+	if err != nil {
+		utils.LogWarningContext(ctx, "Instance %s failed to campaign for leadership: %v", e.InstanceID, err)
+		return
+	}
+	if token == 0 {
+		return
+	}
+
+	leaderCtx, stepDown := context.WithCancel(context.Background())
+	e.mu.Lock()
+	e.isLeader = true
+	e.term = token
+	e.leaderSince = time.Now()
+	e.leaderCtx = leaderCtx
+	e.stepDown = stepDown
+	hooks := append([]func(context.Context){}, e.onElected...)
+	e.mu.Unlock()
+
+	utils.LogInfoContext(ctx, "Instance %s became leader (term %d).", e.InstanceID, token)
+	for _, hook := range hooks {
+		go hook(leaderCtx)
+	}
+}
+
+func (e *LeaderElector) renew(ctx context.Context) error {
+	callCtx, cancel := context.WithTimeout(ctx, e.LeaseTTL/3)
+	defer cancel()
+
+	renewed, err := renewLeaseScript.Run(callCtx, utils.RedisClient, []string{leaderLeaseKey}, e.InstanceID, e.LeaseTTL.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to renew leader lease: %w", err)
+	}
+	if renewed == 0 {
+		return errors.New("leader lease was taken over by another instance")
+	}
+	return nil
+}
+
+func (e *LeaderElector) resignLocally(reason string) {
+	e.mu.Lock()
+	if !e.isLeader {
+		e.mu.Unlock()
+		return
+	}
+	term := e.term
+	e.isLeader = false
+	e.stepDown()
+	e.mu.Unlock()
+	utils.LogWarning("Instance %s stepped down as leader (term %d): %s", e.InstanceID, term, reason)
+}
+
+// Resign gives up the lease right away so another replica can take over without
+// waiting for it to expire. It is called on shutdown.
+func (e *LeaderElector) Resign(ctx context.Context) {
+	if !e.IsLeader() {
+		return
+	}
+	e.resignLocally("shutting down")
+	if err := releaseLeaseScript.Run(ctx, utils.RedisClient, []string{leaderLeaseKey}, e.InstanceID).Err(); err != nil {
+		utils.LogWarningContext(ctx, "Failed to release leader lease of instance %s: %v", e.InstanceID, err)
+	}
+}
+
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+// RunAsLeader runs a scheduled job if this instance leads. The lease is renewed
+// first so a job never starts on a stale lease, and the job's context is
+// cancelled if leadership is lost while it runs. It reports whether the job ran.
+func (e *LeaderElector) RunAsLeader(name string, timeout time.Duration, job func(ctx context.Context) error) bool {
+	e.mu.Lock()
+	status, ok := e.jobs[name]
+	if !ok {
+		status = &models.JobStatus{Name: name}
+		e.jobs[name] = status
+	}
+	leaderCtx := e.leaderCtx
+	isLeader := e.isLeader
+	e.mu.Unlock()
+
+	if !isLeader {
+		e.recordSkip(status)
+		return false
+	}
+	if err := e.renew(leaderCtx); err != nil {
+		e.resignLocally(err.Error())
+		e.recordSkip(status)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(leaderCtx, timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	err := job(ctx)
+
+	e.mu.Lock()
+	status.Runs++
+	status.LastRunAt = &startedAt
+	status.LastDuration = time.Since(startedAt).String()
+	status.LastError = ""
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	e.mu.Unlock()
+
+	if err != nil {
+		utils.LogErrorContext(ctx, "Scheduled job '%s' failed: %v", name, err)
+	}
+	return true
+}
+
+func (e *LeaderElector) recordSkip(status *models.JobStatus) {
+	e.mu.Lock()
+	status.Skipped++
+	e.mu.Unlock()
+}
+
+func (e *LeaderElector) Status(ctx context.Context) models.LeaderStatus {
+	e.mu.RLock()
+	status := models.LeaderStatus{
+		InstanceID: e.InstanceID,
+		IsLeader:   e.isLeader,
+		LeaseTTL:   e.LeaseTTL.String(),
+		Jobs:       make([]models.JobStatus, 0, len(e.jobs)),
+	}
+	if e.isLeader {
+		since := e.leaderSince
+		status.LeaderSince = &since
+		status.Term = e.term
+	}
+	for _, job := range e.jobs {
+		status.Jobs = append(status.Jobs, *job)
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(status.Jobs, func(i, j int) bool { return status.Jobs[i].Name < status.Jobs[j].Name })
+
+	currentLeader, err := utils.RedisClient.Get(ctx, leaderLeaseKey).Result()
+	if err != nil && err != redis.Nil {
+		utils.LogWarningContext(ctx, "Failed to read current leader from Redis: %v", err)
+	}
+	status.CurrentLeader = currentLeader
+	return status
+}
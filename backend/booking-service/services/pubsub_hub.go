@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+)
+
+// PubSubHub fans events received from a Redis pub/sub channel pattern out to
+// the streaming clients connected to this replica, grouped by a key such as a
+// concert or booking ID. Every replica runs its own hub and receives every
+// event, so clients see the same updates whichever replica serves them.
+type PubSubHub[K comparable, E any] struct {
+	name    string
+	pattern string
+	buffer  int
+	key     func(E) K
+
+	mu          sync.RWMutex
+	subscribers map[K]map[chan E]struct{}
+	done        chan struct{}
+}
+
+// NewPubSubHub returns a hub for the JSON events published on channels
+// matching pattern. Each subscriber gets a buffer of buffer events, and key
+// picks the subscribers of an event. name is used in logs.
+func NewPubSubHub[K comparable, E any](name, pattern string, buffer int, key func(E) K) *PubSubHub[K, E] {
+	return &PubSubHub[K, E]{
+		name:        name,
+		pattern:     pattern,
+		buffer:      buffer,
+		key:         key,
+		subscribers: make(map[K]map[chan E]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// AvailabilityHub streams concert availability, keyed by concert ID. Events
+// carry absolute availability values, so one dropped for a slow client is
+// corrected by the next.
+type AvailabilityHub = PubSubHub[uint, models.AvailabilityEvent]
+
+func NewAvailabilityHub() *AvailabilityHub {
+	return NewPubSubHub("availability", utils.AvailabilityChannelPattern, 32,
+		func(event models.AvailabilityEvent) uint { return event.ConcertID })
+}
+
+// BookingEventHub streams booking events to checkout pages, keyed by booking
+// ID. Status changes are rare per booking, so a full buffer means the client
+// has stopped reading.
+type BookingEventHub = PubSubHub[string, models.BookingEvent]
+
+func NewBookingEventHub() *BookingEventHub {
+	return NewPubSubHub("booking", utils.BookingEventsChannelPattern, 16,
+		func(event models.BookingEvent) string { return event.BookingID })
+}
+
+// Run listens for events until ctx is cancelled. go-redis re-subscribes on its
+// own after a connection drop.
+func (h *PubSubHub[K, E]) Run(ctx context.Context) {
+	defer close(h.done)
+	pubsub := utils.RedisClient.PSubscribe(ctx, h.pattern)
+	defer pubsub.Close()
+
+	utils.LogInfoContext(ctx, "The %s hub subscribed to Redis channel pattern '%s'.", h.name, h.pattern)
+	msgs := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			var event E
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				utils.LogWarningContext(ctx, "Discarding malformed %s event on channel %s: %v", h.name, msg.Channel, err)
+				continue
+			}
+			h.broadcast(event)
+		}
+	}
+}
+
+// Done is closed once Run returns. Streams end then, so the HTTP server does not
+// wait on them when it shuts down; clients reconnect to another replica.
+func (h *PubSubHub[K, E]) Done() <-chan struct{} {
+	return h.done
+}
+
+// Subscribe registers a listener for one key. The returned function must be
+// called once the listener goes away.
+func (h *PubSubHub[K, E]) Subscribe(key K) (<-chan E, func()) {
+	ch := make(chan E, h.buffer)
+
+	h.mu.Lock()
+	if h.subscribers[key] == nil {
+		h.subscribers[key] = make(map[chan E]struct{})
+	}
+	h.subscribers[key][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[key], ch)
+			if len(h.subscribers[key]) == 0 {
+				delete(h.subscribers, key)
+			}
+			h.mu.Unlock()
+		})
+	}
+	return ch, unsubscribe
+}
+
+// broadcast never blocks on a slow client; the event is dropped for it instead.
+func (h *PubSubHub[K, E]) broadcast(event E) {
+	key := h.key(event)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[key] {
+		select {
+		case ch <- event:
+		default:
+			utils.LogWarning("Dropping %s event for a slow subscriber of %v", h.name, key)
+		}
+	}
+}
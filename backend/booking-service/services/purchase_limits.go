@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"backend/booking-service/models"
+	"backend/booking-service/repositories"
+	"backend/booking-service/utils"
+
+	"gorm.io/gorm"
+)
+
+// identityFingerprintLength is how much of an identifier's blind index the
+// review of shared identifiers shows.
+const identityFingerprintLength = 12
+
+// identityNames describe the kinds of identifier in error messages.
+var identityNames = map[string]string{
+	models.IdentityBuyerKTP:  "buyer KTP number",
+	models.IdentityHolderKTP: "ticket holder KTP number",
+	models.IdentityPhone:     "phone number",
+	models.IdentityCard:      "payment card",
+}
+
+// claimPurchaseLimits counts the tickets of a new booking against each of its
+// identifiers, in a fixed order so that concurrent bookings lock the counters
+// in the same order. Identifiers are recorded even when the concert does not
+// limit them, for the review of accounts that share them.
+func claimPurchaseLimits(ctx context.Context, tx repositories.Stores, concert *models.Concert, booking *models.Booking, tickets int, values map[string]string) error {
+	for _, kind := range []string{models.IdentityBuyerKTP, models.IdentityHolderKTP, models.IdentityPhone} {
+		value, ok := values[kind]
+		if !ok {
+			continue
+		}
+		if err := claimPurchaseLimit(ctx, tx, concert, booking, kind, value, tickets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func claimPurchaseLimit(ctx context.Context, tx repositories.Stores, concert *models.Concert, booking *models.Booking, kind, value string, tickets int) error {
+	limit := concert.PurchaseLimits.For(kind)
+	claimed, err := tx.Bookings.ClaimBookingIdentity(ctx, &models.BookingIdentity{
+		BookingID: booking.ID,
+		ConcertID: concert.ID,
+		UserID:    booking.UserID,
+		Kind:      kind,
+		Value:     value,
+		Tickets:   tickets,
+	}, limit)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to count the tickets of booking %s against its %s: %v", booking.ID, identityNames[kind], err)
+		return newInternalError("failed to check purchase limits", err)
+	}
+	if !claimed {
+		utils.LogWarningContext(ctx, "Booking %s of user %d refused: %s limit of %d tickets for concert %d reached", booking.ID, booking.UserID, kind, limit, concert.ID)
+		return ErrPurchaseLimitExceeded.Withf("concert '%s' allows at most %d tickets per %s across all accounts, and the %s of this booking would go over it", concert.Name, limit, identityNames[kind], identityNames[kind])
+	}
+	return nil
+}
+
+// ClaimPaymentCard counts the tickets of a pending booking against the card it
+// is about to be paid with. The payment service calls it before charging, so
+// a card over the limit is refused before any money moves. Paying again with
+// another card moves the tickets to that card.
+func (s *BookingService) ClaimPaymentCard(ctx context.Context, bookingID, cardFingerprint string) error {
+	ctx = utils.WithBookingID(ctx, bookingID)
+	booking, err := s.BookingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBookingNotFound
+		}
+		utils.LogErrorContext(ctx, "DB error getting booking %s to claim its payment card: %v", bookingID, err)
+		return newInternalError("failed to retrieve booking details", err)
+	}
+	if booking.Status != models.BookingStatusPending {
+		return ErrInvalidStatusTransition.Withf("booking %s is %s, only pending bookings can be paid", bookingID, booking.Status)
+	}
+
+	err = s.Transactor.WithinTransaction(ctx, func(tx repositories.Stores) error {
+		if err := tx.Bookings.ReleaseBookingIdentities(ctx, bookingID, models.IdentityCard, s.Clock.Now()); err != nil {
+			utils.LogErrorContext(ctx, "Failed to release the previous payment card of booking %s: %v", bookingID, err)
+			return newInternalError("failed to check purchase limits", err)
+		}
+		if err := claimPurchaseLimit(ctx, tx, &booking.Concert, booking, models.IdentityCard, cardFingerprint, len(booking.Seats)); err != nil {
+			return err
+		}
+		// A cancellation that committed in the meantime has already released
+		// the booking's identities, so the card must not stay claimed.
+		current, err := tx.Bookings.GetBookingByID(ctx, bookingID)
+		if err != nil {
+			utils.LogErrorContext(ctx, "DB error reloading booking %s after claiming its payment card: %v", bookingID, err)
+			return newInternalError("failed to retrieve booking details", err)
+		}
+		if current.Status != models.BookingStatusPending {
+			return ErrInvalidStatusTransition.Withf("booking %s is %s, only pending bookings can be paid", bookingID, current.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		return transactionError(err, "failed to claim payment card")
+	}
+	return nil
+}
+
+// GetIdentityClusters groups the accounts whose bookings share a KTP number,
+// phone number or payment card, for admins to review as possible scalpers.
+// Accounts are in one cluster when they are linked through any chain of
+// shared identifiers. Released bookings still link accounts. Only clusters of
+// two or more accounts are returned, largest first.
+func (s *BookingService) GetIdentityClusters(ctx context.Context, concertID uint) ([]models.IdentityCluster, error) {
+	identities, err := s.BookingRepo.GetBookingIdentities(ctx, concertID)
+	if err != nil {
+		utils.LogErrorContext(ctx, "DB error getting booking identities for concert %d: %v", concertID, err)
+		return nil, newInternalError("failed to retrieve booking identities", err)
+	}
+
+	parent := make(map[uint]uint)
+	var find func(uint) uint
+	find = func(user uint) uint {
+		if parent[user] == user {
+			return user
+		}
+		parent[user] = find(parent[user])
+		return parent[user]
+	}
+
+	type identifier struct {
+		kinds map[string]bool
+		users map[uint]bool
+	}
+	identifiers := make(map[string]*identifier)
+	bookingsOf := make(map[uint]map[string]bool)
+	activeTickets := make(map[string]int)
+	for _, identity := range identities {
+		if _, ok := parent[identity.UserID]; !ok {
+			parent[identity.UserID] = identity.UserID
+			bookingsOf[identity.UserID] = make(map[string]bool)
+		}
+		bookingsOf[identity.UserID][identity.BookingID] = true
+		if identity.ReleasedAt == nil && identity.Tickets > activeTickets[identity.BookingID] {
+			activeTickets[identity.BookingID] = identity.Tickets
+		}
+
+		id, ok := identifiers[identity.ValueIndex]
+		if !ok {
+			id = &identifier{kinds: make(map[string]bool), users: make(map[uint]bool)}
+			identifiers[identity.ValueIndex] = id
+		}
+		id.kinds[identity.Kind] = true
+		for user := range id.users {
+			parent[find(user)] = find(identity.UserID)
+			break
+		}
+		id.users[identity.UserID] = true
+	}
+
+	byRoot := make(map[uint]*models.IdentityCluster)
+	for user := range parent {
+		root := find(user)
+		cluster, ok := byRoot[root]
+		if !ok {
+			cluster = &models.IdentityCluster{}
+			byRoot[root] = cluster
+		}
+		cluster.UserIDs = append(cluster.UserIDs, user)
+		for bookingID := range bookingsOf[user] {
+			cluster.BookingIDs = append(cluster.BookingIDs, bookingID)
+			cluster.ActiveTickets += activeTickets[bookingID]
+		}
+	}
+	for index, id := range identifiers {
+		if len(id.users) < 2 {
+			continue
+		}
+		shared := models.SharedIdentifier{Fingerprint: index}
+		if len(index) > identityFingerprintLength {
+			shared.Fingerprint = index[:identityFingerprintLength]
+		}
+		for kind := range id.kinds {
+			shared.Kinds = append(shared.Kinds, kind)
+		}
+		for user := range id.users {
+			shared.UserIDs = append(shared.UserIDs, user)
+		}
+		sort.Strings(shared.Kinds)
+		sortUserIDs(shared.UserIDs)
+		cluster := byRoot[find(shared.UserIDs[0])]
+		cluster.SharedIdentifiers = append(cluster.SharedIdentifiers, shared)
+	}
+
+	clusters := []models.IdentityCluster{}
+	for _, cluster := range byRoot {
+		if len(cluster.UserIDs) < 2 {
+			continue
+		}
+		sortUserIDs(cluster.UserIDs)
+		sort.Strings(cluster.BookingIDs)
+		sort.Slice(cluster.SharedIdentifiers, func(i, j int) bool {
+			return cluster.SharedIdentifiers[i].Fingerprint < cluster.SharedIdentifiers[j].Fingerprint
+		})
+		clusters = append(clusters, *cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		a, b := clusters[i], clusters[j]
+		if len(a.UserIDs) != len(b.UserIDs) {
+			return len(a.UserIDs) > len(b.UserIDs)
+		}
+		if a.ActiveTickets != b.ActiveTickets {
+			return a.ActiveTickets > b.ActiveTickets
+		}
+		return a.UserIDs[0] < b.UserIDs[0]
+	})
+	return clusters, nil
+}
+
+func sortUserIDs(ids []uint) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
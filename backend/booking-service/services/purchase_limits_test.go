@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"backend/booking-service/models"
+)
+
+// bookWithPhone books quantity regular tickets for userID, with a KTP number
+// of its own and the given phone number.
+func (f *bookingFixture) bookWithPhone(userID uint, phone string, quantity int) (*models.BookingResponse, error) {
+	req := f.request(fmt.Sprintf("317101100190%04d", userID), tickets(f.regular.ID, quantity))
+	req.BuyerInfo.PhoneNumber = phone
+	return f.service.CreateBooking(context.Background(), userID, req)
+}
+
+func (f *bookingFixture) setPurchaseLimits(limits models.PurchaseLimits) {
+	f.concert.PurchaseLimits = limits
+	f.store.UpdateConcert(context.Background(), &f.concert)
+}
+
+func TestCreateBookingEnforcesPurchaseLimits(t *testing.T) {
+	f := newBookingFixture(t)
+	f.setPurchaseLimits(models.PurchaseLimits{Phone: 3, HolderKTP: 1})
+
+	first, err := f.bookWithPhone(1, "081234567890", 2)
+	if err != nil {
+		t.Fatalf("CreateBooking() error = %v", err)
+	}
+
+	// Another account with the same number, written differently.
+	_, err = f.bookWithPhone(2, "+62 812-3456-7890", 2)
+	assertDomainError(t, err, ErrPurchaseLimitExceeded)
+	f.assertCachedSeats(t, f.regular.ID, 8)
+	if got := f.store.BookingCount(); got != 1 {
+		t.Errorf("bookings stored = %d, want 1", got)
+	}
+	if _, err := f.bookWithPhone(2, "+62 812-3456-7890", 1); err != nil {
+		t.Fatalf("CreateBooking() within the limit: error = %v", err)
+	}
+	if got := f.store.PurchaseCount(f.concert.ID, models.IdentityPhone, "phone:081234567890"); got != 3 {
+		t.Errorf("tickets counted against the phone number = %d, want 3", got)
+	}
+
+	// Cancelling gives the tickets back to the phone number.
+	if err := f.service.CancelBooking(context.Background(), first.ID, 1); err != nil {
+		t.Fatalf("CancelBooking() error = %v", err)
+	}
+	if _, err := f.bookWithPhone(3, "081234567890", 2); err != nil {
+		t.Errorf("CreateBooking() after cancellation: error = %v", err)
+	}
+
+	req := f.request("3171011001900009", tickets(f.regular.ID, 2))
+	req.BuyerInfo.PhoneNumber = "081111111111"
+	req.TicketHolderInfo = &models.TicketHolderRequest{FullName: "Siti Aminah", KTPNumber: "3171015001900009"}
+	_, err = f.service.CreateBooking(context.Background(), 9, req)
+	assertDomainError(t, err, ErrPurchaseLimitExceeded)
+}
+
+func TestClaimPaymentCard(t *testing.T) {
+	f := newBookingFixture(t)
+	f.setPurchaseLimits(models.PurchaseLimits{Card: 2})
+	cardX, cardY := fmt.Sprintf("%064d", 1), fmt.Sprintf("%064d", 2)
+
+	first, _ := f.bookWithPhone(1, "081100000001", 2)
+	second, _ := f.bookWithPhone(2, "081100000002", 1)
+
+	if err := f.service.ClaimPaymentCard(context.Background(), first.ID, cardX); err != nil {
+		t.Fatalf("ClaimPaymentCard() error = %v", err)
+	}
+	// Retrying the payment with the same card does not count it twice.
+	if err := f.service.ClaimPaymentCard(context.Background(), first.ID, cardX); err != nil {
+		t.Fatalf("repeated ClaimPaymentCard() error = %v", err)
+	}
+	err := f.service.ClaimPaymentCard(context.Background(), second.ID, cardX)
+	assertDomainError(t, err, ErrPurchaseLimitExceeded)
+	if err := f.service.ClaimPaymentCard(context.Background(), second.ID, cardY); err != nil {
+		t.Fatalf("ClaimPaymentCard() with another card: error = %v", err)
+	}
+
+	// A failed payment releases the card.
+	if err := f.service.UpdateBookingStatusFromPayment(context.Background(), first.ID, models.BookingStatusFailed, 7); err != nil {
+		t.Fatalf("UpdateBookingStatusFromPayment() error = %v", err)
+	}
+	if err := f.service.ClaimPaymentCard(context.Background(), second.ID, cardX); err != nil {
+		t.Errorf("ClaimPaymentCard() after the other booking failed: error = %v", err)
+	}
+	if got := f.store.PurchaseCount(f.concert.ID, models.IdentityCard, "card:"+cardY); got != 0 {
+		t.Errorf("tickets counted against the card paid with before = %d, want 0", got)
+	}
+
+	err = f.service.ClaimPaymentCard(context.Background(), first.ID, cardY)
+	assertDomainError(t, err, ErrInvalidStatusTransition)
+	err = f.service.ClaimPaymentCard(context.Background(), "missing", cardY)
+	assertDomainError(t, err, ErrBookingNotFound)
+}
+
+func TestGetIdentityClusters(t *testing.T) {
+	f := newBookingFixture(t)
+	card := fmt.Sprintf("%064d", 1)
+
+	// Users 1 and 2 share a phone number, 2 and 3 a card; 4 shares nothing.
+	f.bookWithPhone(1, "081100000001", 1)
+	second, _ := f.bookWithPhone(2, "0811-0000-0001", 2)
+	third, _ := f.bookWithPhone(3, "081100000003", 1)
+	f.bookWithPhone(4, "081100000004", 1)
+	f.service.ClaimPaymentCard(context.Background(), second.ID, card)
+	f.service.ClaimPaymentCard(context.Background(), third.ID, card)
+	f.service.CancelBooking(context.Background(), third.ID, 3)
+
+	clusters, err := f.service.GetIdentityClusters(context.Background(), f.concert.ID)
+	if err != nil {
+		t.Fatalf("GetIdentityClusters() error = %v", err)
+	}
+	if len(clusters) != 1 {
+		t.Fatalf("GetIdentityClusters() = %+v, want one cluster", clusters)
+	}
+	cluster := clusters[0]
+	if !reflect.DeepEqual(cluster.UserIDs, []uint{1, 2, 3}) || len(cluster.BookingIDs) != 3 || cluster.ActiveTickets != 3 {
+		t.Errorf("cluster = users %v, %d bookings, %d active tickets; want users [1 2 3], 3 bookings, 3 active tickets", cluster.UserIDs, len(cluster.BookingIDs), cluster.ActiveTickets)
+	}
+	want := []models.SharedIdentifier{
+		{Kinds: []string{models.IdentityCard}, Fingerprint: "card:0000000", UserIDs: []uint{2, 3}},
+		{Kinds: []string{models.IdentityPhone}, Fingerprint: "phone:081100", UserIDs: []uint{1, 2}},
+	}
+	if !reflect.DeepEqual(cluster.SharedIdentifiers, want) {
+		t.Errorf("shared identifiers = %+v, want %+v", cluster.SharedIdentifiers, want)
+	}
+
+	if clusters, _ := f.service.GetIdentityClusters(context.Background(), 999); len(clusters) != 0 {
+		t.Errorf("clusters of another concert = %+v, want none", clusters)
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"backend/booking-service/models"
+	"backend/booking-service/utils"
+)
+
+type QueueService struct{}
+
+func NewQueueService() *QueueService {
+	return &QueueService{}
+}
+
+func (s *QueueService) GetDeadLetters(ctx context.Context, queueName string, limit int) ([]models.DeadLetterMessage, error) {
+	messages, err := utils.InspectDeadLetters(queueName, limit)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnknownQueue) {
+			return nil, ErrQueueNotFound.Withf("queue not found: %s", queueName)
+		}
+		utils.LogErrorContext(ctx, "Failed to inspect dead-letter queue of '%s': %v", queueName, err)
+		return nil, newInternalError("failed to read dead-lettered messages", err)
+	}
+	return messages, nil
+}
+
+// ReplayDeadLetters replays up to utils.MaxDeadLetterReplay dead-lettered
+// messages of a queue; more is set when some may be left.
+func (s *QueueService) ReplayDeadLetters(ctx context.Context, queueName string, messageIDs []string) (replayed int, more bool, err error) {
+	replayed, more, err = utils.ReplayDeadLetters(queueName, messageIDs)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnknownQueue) {
+			return 0, false, ErrQueueNotFound.Withf("queue not found: %s", queueName)
+		}
+		utils.LogErrorContext(ctx, "Failed to replay dead-lettered messages of '%s' (replayed %d): %v", queueName, replayed, err)
+		return replayed, false, newInternalError("failed to replay dead-lettered messages", err)
+	}
+	return replayed, more, nil
+}
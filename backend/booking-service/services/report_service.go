@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"backend/booking-service/models"
+	"backend/booking-service/repositories"
+	"backend/booking-service/utils"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type ReportService struct {
+	ReportRepo *repositories.ReportRepository
+}
+
+func NewReportService(rRepo *repositories.ReportRepository) *ReportService {
+	return &ReportService{ReportRepo: rRepo}
+}
+
+func (s *ReportService) GetSalesReport(ctx context.Context, filter *models.SalesReportFilter) (*models.SalesReportResponse, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidDateRange
+	}
+
+	var rows []models.SalesReportRow
+	var err error
+	switch filter.GroupBy {
+	case models.ReportGroupByConcert:
+		rows, err = s.ReportRepo.GetSalesByConcert(ctx, filter)
+	case models.ReportGroupByTicketClass:
+		rows, err = s.ReportRepo.GetSalesByTicketClass(ctx, filter)
+	case models.ReportGroupByDay:
+		rows, err = s.ReportRepo.GetSalesByDay(ctx, filter)
+	default:
+		return nil, ErrUnsupportedReportGroup.Withf("unsupported report grouping: %s", filter.GroupBy)
+	}
+	if err != nil {
+		utils.LogErrorContext(ctx, "DB error aggregating sales report by %s: %v", filter.GroupBy, err)
+		return nil, newInternalError("failed to generate sales report", err)
+	}
+
+	totals, err := s.ReportRepo.GetSalesTotals(ctx, filter)
+	if err != nil {
+		utils.LogErrorContext(ctx, "DB error aggregating sales report totals: %v", err)
+		return nil, newInternalError("failed to generate sales report", err)
+	}
+
+	if rows == nil {
+		rows = []models.SalesReportRow{}
+	}
+	return &models.SalesReportResponse{
+		GroupBy:     filter.GroupBy,
+		ConcertID:   filter.ConcertID,
+		From:        filter.From,
+		To:          filter.To,
+		Rows:        rows,
+		Totals:      *totals,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+func salesReportHeader(groupBy string) []string {
+	var header []string
+	switch groupBy {
+	case models.ReportGroupByConcert:
+		header = []string{"Concert ID", "Concert"}
+	case models.ReportGroupByTicketClass:
+		header = []string{"Concert ID", "Concert", "Ticket Class ID", "Ticket Class"}
+	case models.ReportGroupByDay:
+		header = []string{"Day"}
+	}
+	return append(header,
+		"Total Bookings", "Confirmed", "Pending", "Cancelled", "Expired", "Failed",
+		"Tickets Sold", "Revenue", "Average Order Value")
+}
+
+func salesReportKeyColumns(groupBy string, row *models.SalesReportRow) []interface{} {
+	switch groupBy {
+	case models.ReportGroupByConcert:
+		return []interface{}{row.ConcertID, row.ConcertName}
+	case models.ReportGroupByTicketClass:
+		return []interface{}{row.ConcertID, row.ConcertName, row.TicketClassID, row.TicketClassName}
+	default:
+		return []interface{}{row.Day}
+	}
+}
+
+func salesReportMetricColumns(bookings, confirmed, pending, cancelled, expired, failed, tickets int64, revenue, aov float64) []interface{} {
+	return []interface{}{bookings, confirmed, pending, cancelled, expired, failed, tickets, revenue, aov}
+}
+
+// salesReportTable flattens the report into rows of cells, header and totals included.
+func salesReportTable(report *models.SalesReportResponse) [][]interface{} {
+	header := salesReportHeader(report.GroupBy)
+	table := make([][]interface{}, 0, len(report.Rows)+2)
+
+	headerRow := make([]interface{}, len(header))
+	for i, h := range header {
+		headerRow[i] = h
+	}
+	table = append(table, headerRow)
+
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		cells := salesReportKeyColumns(report.GroupBy, row)
+		cells = append(cells, salesReportMetricColumns(row.TotalBookings, row.ConfirmedBookings, row.PendingBookings,
+			row.CancelledBookings, row.ExpiredBookings, row.FailedBookings, row.TicketsSold, row.Revenue, row.AverageOrderValue)...)
+		table = append(table, cells)
+	}
+
+	t := report.Totals
+	totalsRow := make([]interface{}, len(header)-9)
+	totalsRow[0] = "TOTAL"
+	for i := 1; i < len(totalsRow); i++ {
+		totalsRow[i] = ""
+	}
+	totalsRow = append(totalsRow, salesReportMetricColumns(t.TotalBookings, t.ConfirmedBookings, t.PendingBookings,
+		t.CancelledBookings, t.ExpiredBookings, t.FailedBookings, t.TicketsSold, t.Revenue, t.AverageOrderValue)...)
+	return append(table, totalsRow)
+}
+
+func (s *ReportService) WriteSalesReportCSV(w io.Writer, report *models.SalesReportResponse) error {
+	writer := csv.NewWriter(w)
+	for _, cells := range salesReportTable(report) {
+		record := make([]string, len(cells))
+		for i, cell := range cells {
+			switch v := cell.(type) {
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'f', 2, 64)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write sales report CSV row: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (s *ReportService) WriteSalesReportXLSX(w io.Writer, report *models.SalesReportResponse) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Sales Report"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return fmt.Errorf("failed to name sales report sheet: %w", err)
+	}
+
+	for i, cells := range salesReportTable(report) {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return fmt.Errorf("failed to resolve sales report cell: %w", err)
+		}
+		if err := f.SetSheetRow(sheet, cell, &cells); err != nil {
+			return fmt.Errorf("failed to write sales report XLSX row: %w", err)
+		}
+	}
+
+	if _, err := f.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write sales report XLSX: %w", err)
+	}
+	return nil
+}
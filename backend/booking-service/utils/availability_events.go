@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+// AvailabilityChannelPattern matches the per-concert availability channels of every concert.
+const AvailabilityChannelPattern = "concert:*:availability"
+
+func AvailabilityChannel(concertID uint) string {
+	return fmt.Sprintf("concert:%d:availability", concertID)
+}
+
+// PublishAvailabilityEvent broadcasts an availability change to all booking-service
+// replicas. Publishing is best effort: a failure is logged and never fails the caller.
+func PublishAvailabilityEvent(ctx context.Context, event models.AvailabilityEvent) {
+	if RedisClient == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		LogErrorContext(ctx, "Failed to marshal availability event for concert %d: %v", event.ConcertID, err)
+		return
+	}
+	if err := RedisClient.Publish(ctx, AvailabilityChannel(event.ConcertID), payload).Err(); err != nil {
+		LogWarningContext(ctx, "Failed to publish availability event for concert %d: %v", event.ConcertID, err)
+	}
+}
+
+func publishTicketClassAvailability(ctx context.Context, concertID, ticketClassID uint, available int64, delta int) {
+	PublishAvailabilityEvent(ctx, models.AvailabilityEvent{
+		Type:           models.AvailabilityEventTicketClass,
+		ConcertID:      concertID,
+		TicketClassID:  ticketClassID,
+		AvailableSeats: &available,
+		Delta:          delta,
+	})
+}
+
+// PublishSeatStatusChanges announces the new status of seats once the database
+// transaction that changed them has been committed.
+func PublishSeatStatusChanges(ctx context.Context, concertID uint, seats []*models.Seat) {
+	if len(seats) == 0 {
+		return
+	}
+	PublishAvailabilityEvent(ctx, NewSeatStatusEvent(concertID, seats))
+}
+
+func NewSeatStatusEvent(concertID uint, seats []*models.Seat) models.AvailabilityEvent {
+	changes := make([]models.SeatStatusChange, 0, len(seats))
+	for _, seat := range seats {
+		if seat == nil {
+			continue
+		}
+		changes = append(changes, models.SeatStatusChange{
+			SeatID:        seat.ID,
+			SeatNumber:    seat.SeatNumber,
+			TicketClassID: seat.TicketClassID,
+			Status:        seat.Status,
+		})
+	}
+	return models.AvailabilityEvent{
+		Type:      models.AvailabilityEventSeatStatus,
+		ConcertID: concertID,
+		Seats:     changes,
+	}
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+// BookingEventsChannelPattern matches the event channels of every booking.
+const BookingEventsChannelPattern = "booking:*:events"
+
+func BookingEventsChannel(bookingID string) string {
+	return fmt.Sprintf("booking:%s:events", bookingID)
+}
+
+// PublishBookingEvent broadcasts a booking event to all booking-service replicas.
+// Like availability events it is best effort and only logs failures.
+func PublishBookingEvent(ctx context.Context, event models.BookingEvent) {
+	if RedisClient == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		LogErrorContext(ctx, "Failed to marshal booking event for booking %s: %v", event.BookingID, err)
+		return
+	}
+	if err := RedisClient.Publish(ctx, BookingEventsChannel(event.BookingID), payload).Err(); err != nil {
+		LogWarningContext(ctx, "Failed to publish booking event for booking %s: %v", event.BookingID, err)
+	}
+}
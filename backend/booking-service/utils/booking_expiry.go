@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BookingExpiryKey is a sorted set of pending booking IDs scored by the unix
+// millisecond at which their hold expires.
+const BookingExpiryKey = "booking:expiry"
+
+// claimDueExpiriesScript hands out due bookings and pushes their score forward by
+// the claim timeout instead of removing them. A worker that dies mid-way leaves
+// the entry behind, and it becomes due again once the claim runs out.
+var claimDueExpiriesScript = redis.NewScript(`
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[3])
+for _, id in ipairs(ids) do
+	redis.call("ZADD", KEYS[1], ARGV[2], id)
+end
+return ids
+`)
+
+// ScheduleBookingExpiry schedules (or reschedules) the release of a booking's hold.
+func ScheduleBookingExpiry(ctx context.Context, bookingID string, expiresAt time.Time) error {
+	err := RedisClient.ZAdd(ctx, BookingExpiryKey, redis.Z{Score: float64(expiresAt.UnixMilli()), Member: bookingID}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to schedule expiry of booking %s: %w", bookingID, err)
+	}
+	return nil
+}
+
+func UnscheduleBookingExpiry(ctx context.Context, bookingID string) error {
+	if err := RedisClient.ZRem(ctx, BookingExpiryKey, bookingID).Err(); err != nil {
+		return fmt.Errorf("failed to unschedule expiry of booking %s: %w", bookingID, err)
+	}
+	return nil
+}
+
+// ClaimDueBookingExpiries atomically claims up to limit bookings whose hold has
+// expired, so several replicas can run the expiry worker side by side.
+func ClaimDueBookingExpiries(ctx context.Context, now time.Time, claimTimeout time.Duration, limit int) ([]string, error) {
+	ids, err := claimDueExpiriesScript.Run(ctx, RedisClient, []string{BookingExpiryKey},
+		now.UnixMilli(), now.Add(claimTimeout).UnixMilli(), limit).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to claim due booking expiries: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+// Version is the release of the service, set at build time with
+// -ldflags "-X backend/booking-service/utils.Version=v1.2.3".
+var Version = "dev"
+
+var startedAt = time.Now()
+
+// Build describes the running binary.
+func Build() models.BuildInfo {
+	info := models.BuildInfo{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		StartedAt: startedAt,
+		Uptime:    time.Since(startedAt).Round(time.Second).String(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	return info
+}
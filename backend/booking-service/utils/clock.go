@@ -0,0 +1,10 @@
+package utils
+
+import "time"
+
+// SystemClock tells the wall-clock time.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strings"
+
+	"backend/booking-service/models"
+)
+
+// IdentityIndex is the blind index of an identifier bookings are limited by.
+// KTP numbers of buyers and holders get their ktp_index, so the identity
+// cluster review links a person who buys on one account and attends on
+// another. Purchase limits still count the two roles separately, against
+// buyer_ktp and holder_ktp. Other values are prefixed with their kind, so
+// equal values of different kinds never match.
+func IdentityIndex(kind, value string) (string, error) {
+	switch kind {
+	case models.IdentityBuyerKTP, models.IdentityHolderKTP:
+		return BlindIndex(value)
+	case models.IdentityPhone:
+		return BlindIndex(kind + ":" + NormalizePhoneNumber(value))
+	default:
+		return BlindIndex(kind + ":" + value)
+	}
+}
+
+// NormalizePhoneNumber reduces an Indonesian phone number to its digits in
+// the national format, so 0812-3456-7890, +62 812 3456 7890 and 6281234567890
+// are the same number.
+func NormalizePhoneNumber(phone string) string {
+	var digits strings.Builder
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			digits.WriteRune(c)
+		}
+	}
+	normalized := digits.String()
+	if strings.HasPrefix(normalized, "62") {
+		normalized = "0" + normalized[2:]
+	}
+	return normalized
+}
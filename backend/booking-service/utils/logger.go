@@ -1,41 +1,194 @@
 package utils
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var (
-	infoLogger  *log.Logger
-	warnLogger  *log.Logger
-	errorLogger *log.Logger
+// RequestIDHeader carries the request ID on HTTP requests and responses and on
+// RabbitMQ messages, so the log lines of one request can be found in every
+// service it touched.
+const RequestIDHeader = "X-Request-ID"
+
+// Log formats selectable with LOG_FORMAT.
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
 )
 
-func init() {
+var logger = slog.New(newContextHandler(os.Stdout, LogFormatJSON, slog.LevelInfo))
+
+// InitLogger replaces the default logger with one writing to w. level is
+// debug, info, warn or error. The standard library log package is redirected
+// to the same handler, so log.Printf lines come out in the same format.
+func InitLogger(w io.Writer, level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level '%s': %w", level, err)
+	}
+	if format != LogFormatJSON && format != LogFormatText {
+		return fmt.Errorf("invalid log format '%s', want %s or %s", format, LogFormatJSON, LogFormatText)
+	}
+	logger = slog.New(newContextHandler(w, format, lvl))
+	slog.SetDefault(logger)
+	return nil
+}
+
+// Logger returns the service logger for code that wants key-value pairs
+// rather than a format string.
+func Logger() *slog.Logger {
+	return logger
+}
+
+// contextHandler adds the request ID, the fields stored with WithLogFields and
+// the trace ID of the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func newContextHandler(w io.Writer, format string, level slog.Level) contextHandler {
+	opts := &slog.HandlerOptions{
+		AddSource: true,
+		Level:     level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if src, ok := a.Value.Any().(*slog.Source); ok && a.Key == slog.SourceKey {
+				return slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", filepath.Base(src.File), src.Line))
+			}
+			return a
+		},
+	}
+	service := []slog.Attr{slog.String("service", ServiceName)}
+	if format == LogFormatText {
+		return contextHandler{slog.NewTextHandler(w, opts).WithAttrs(service)}
+	}
+	return contextHandler{slog.NewJSONHandler(w, opts).WithAttrs(service)}
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if fields, ok := ctx.Value(logFieldsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(fields...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type requestIDKey struct{}
 
-	infoLogger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	warnLogger = log.New(os.Stdout, "WARN: ", log.Ldate|log.Ltime|log.Lshortfile)
-	errorLogger = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+type logFieldsKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of ctx, or "" if it has none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithLogFields returns a copy of ctx whose log lines carry attrs, such as
+// user_id, booking_id or concert_id. An attr replaces an earlier one with the
+// same key.
+func WithLogFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(logFieldsKey{}).([]slog.Attr)
+	fields := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, field := range existing {
+		replaced := false
+		for _, attr := range attrs {
+			if attr.Key == field.Key {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			fields = append(fields, field)
+		}
+	}
+	fields = append(fields, attrs...)
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// WithUserID returns a copy of ctx whose log lines carry the user_id field.
+func WithUserID(ctx context.Context, id uint) context.Context {
+	return WithLogFields(ctx, slog.Uint64("user_id", uint64(id)))
+}
+
+// WithBookingID returns a copy of ctx whose log lines carry the booking_id field.
+func WithBookingID(ctx context.Context, id string) context.Context {
+	return WithLogFields(ctx, slog.String("booking_id", id))
+}
+
+// WithConcertID returns a copy of ctx whose log lines carry the concert_id field.
+func WithConcertID(ctx context.Context, id uint) context.Context {
+	return WithLogFields(ctx, slog.Uint64("concert_id", uint64(id)))
+}
+
+// logf formats a message and logs it with the caller of the Log function as
+// the source.
+func logf(ctx context.Context, level slog.Level, format string, v ...interface{}) {
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, fmt.Sprintf(format, v...), pcs[0])
+	_ = logger.Handler().Handle(ctx, r)
 }
 
 func LogInfo(format string, v ...interface{}) {
-	infoLogger.Printf(format+"\n", v...)
+	logf(context.Background(), slog.LevelInfo, format, v...)
 }
 
 func LogWarning(format string, v ...interface{}) {
-	warnLogger.Printf(format+"\n", v...)
+	logf(context.Background(), slog.LevelWarn, format, v...)
 }
 
 func LogError(format string, v ...interface{}) {
-	errorLogger.Printf(format+"\n", v...)
+	logf(context.Background(), slog.LevelError, format, v...)
 }
 
 func LogFatal(format string, v ...interface{}) {
-	errorLogger.Fatalf(format+"\n", v...)
+	logf(context.Background(), slog.LevelError, format, v...)
+	os.Exit(1)
+}
+
+// LogInfoContext logs like LogInfo with the request ID and fields of ctx.
+func LogInfoContext(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelInfo, format, v...)
+}
+
+// LogWarningContext logs like LogWarning with the request ID and fields of ctx.
+func LogWarningContext(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelWarn, format, v...)
+}
+
+// LogErrorContext logs like LogError with the request ID and fields of ctx.
+func LogErrorContext(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelError, format, v...)
 }
 
 func FormatValidationErrors(errs validator.ValidationErrors) string {
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metricsNamespace prefixes every metric of the service, so the three services
+// can share one registry (as they do in the end-to-end tests).
+const metricsNamespace = "booking"
+
+// Booking lifecycle events counted by BookingEventsTotal.
+const (
+	BookingEventCreated   = "created"
+	BookingEventConfirmed = "confirmed"
+	BookingEventFailed    = "failed"
+	BookingEventCancelled = "cancelled"
+	BookingEventExpired   = "expired"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	BookingEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "bookings_total",
+		Help:      "Bookings created, confirmed, failed, cancelled and expired.",
+	}, []string{"event"})
+
+	SeatReservationRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "seat_reservation_retries_total",
+		Help:      "Redis seat counter transactions retried because another client changed the counter first.",
+	}, []string{"operation"})
+
+	QueueMessageLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_message_lag_seconds",
+		Help:      "Time first deliveries spent in a queue between publishing and consuming.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 60, 300, 900},
+	}, []string{"queue"})
+
+	QueueProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_processing_duration_seconds",
+		Help:      "Time consumers spent handling a message, by queue and outcome (ok or error).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"queue", "outcome"})
+)
+
+// RecordBookingEvent counts a booking lifecycle event.
+func RecordBookingEvent(event string) {
+	BookingEventsTotal.WithLabelValues(event).Inc()
+}
+
+// observeDelivery records the lag of a first delivery and how long its handler ran.
+func observeDelivery(queueName string, published time.Time, firstDelivery bool, started time.Time, err error) {
+	if firstDelivery && !published.IsZero() {
+		QueueMessageLag.WithLabelValues(queueName).Observe(started.Sub(published).Seconds())
+	}
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	QueueProcessingDuration.WithLabelValues(queueName, outcome).Observe(time.Since(started).Seconds())
+}
+
+// RegisterDBStats exports the connection pool statistics of db.
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, dbName))
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/booking-service/config"
+	"backend/booking-service/models"
+)
+
+// A NIK is PPRRDD-DDMMYY-SSSS: province, regency and district codes, the birth
+// date (with 40 added to the day for women) and a serial number.
+
+// ErrInvalidNIK is wrapped by every error ParseNIK returns.
+var ErrInvalidNIK = errors.New("invalid NIK")
+
+// nikRegionsCSV is the bundled region table: a code,name header followed by
+// province (11), regency (11.01) and district (11.01.01) codes, in the format
+// of the Kemendagri region list. It lists every province, but regencies and
+// districts only for some of them; NIK_REGIONS_FILE replaces it with the full
+// list.
+//
+//go:embed nik_regions.csv
+var nikRegionsCSV []byte
+
+// nikRegionTable maps 2, 4 and 6 digit region codes to names. A province with
+// no regencies in the table accepts any regency code, and a regency with no
+// districts any district code, so a partial table never rejects a real NIK.
+type nikRegionTable struct {
+	names        map[string]string
+	hasRegencies map[string]bool
+	hasDistricts map[string]bool
+}
+
+var nikRegions = mustParseNIKRegions(nikRegionsCSV)
+
+func mustParseNIKRegions(data []byte) *nikRegionTable {
+	table, err := parseNIKRegions(bytes.NewReader(data))
+	if err != nil {
+		panic(fmt.Sprintf("bundled NIK region table: %v", err))
+	}
+	return table
+}
+
+// InitNIKRegions loads the region table from cfg.NIKRegionsFile, if set, in
+// place of the bundled one.
+func InitNIKRegions(cfg *config.Config) error {
+	if cfg.NIKRegionsFile == "" {
+		return nil
+	}
+	f, err := os.Open(cfg.NIKRegionsFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	table, err := parseNIKRegions(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cfg.NIKRegionsFile, err)
+	}
+	nikRegions = table
+	LogInfo("Loaded %d NIK region codes from %s.", len(table.names), cfg.NIKRegionsFile)
+	return nil
+}
+
+func parseNIKRegions(r io.Reader) (*nikRegionTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	table := &nikRegionTable{
+		names:        make(map[string]string),
+		hasRegencies: make(map[string]bool),
+		hasDistricts: make(map[string]bool),
+	}
+	for i, record := range records {
+		if i == 0 && record[0] == "code" {
+			continue
+		}
+		code := strings.ReplaceAll(strings.TrimSpace(record[0]), ".", "")
+		if _, err := strconv.Atoi(code); err != nil {
+			return nil, fmt.Errorf("line %d: region code %q is not numeric", i+1, record[0])
+		}
+		switch len(code) {
+		case 2:
+		case 4:
+			table.hasRegencies[code[:2]] = true
+		case 6:
+			table.hasDistricts[code[:4]] = true
+		default:
+			// Villages (10 digits) are not part of a NIK.
+			continue
+		}
+		table.names[code] = strings.TrimSpace(record[1])
+	}
+	if len(table.names) == 0 {
+		return nil, errors.New("no region codes")
+	}
+	return table, nil
+}
+
+// ParseNIK validates a NIK and decodes it. The birth year has two digits, so
+// it is taken as the latest year that does not put the birth date after now.
+func ParseNIK(nik string, now time.Time) (models.NIKInfo, error) {
+	if len(nik) != 16 {
+		return models.NIKInfo{}, fmt.Errorf("%w: must be 16 digits", ErrInvalidNIK)
+	}
+	for _, c := range nik {
+		if c < '0' || c > '9' {
+			return models.NIKInfo{}, fmt.Errorf("%w: must be 16 digits", ErrInvalidNIK)
+		}
+	}
+
+	info := models.NIKInfo{
+		ProvinceCode: nik[:2],
+		RegencyCode:  nik[:4],
+		DistrictCode: nik[:6],
+		Serial:       nik[12:],
+	}
+	regions := nikRegions
+	var ok bool
+	if info.Province, ok = regions.names[info.ProvinceCode]; !ok {
+		return models.NIKInfo{}, fmt.Errorf("%w: unknown province code %s", ErrInvalidNIK, info.ProvinceCode)
+	}
+	if regions.hasRegencies[info.ProvinceCode] {
+		if info.Regency, ok = regions.names[info.RegencyCode]; !ok {
+			return models.NIKInfo{}, fmt.Errorf("%w: unknown regency code %s", ErrInvalidNIK, info.RegencyCode)
+		}
+	} else if nik[2:4] == "00" {
+		return models.NIKInfo{}, fmt.Errorf("%w: unknown regency code %s", ErrInvalidNIK, info.RegencyCode)
+	}
+	if regions.hasDistricts[info.RegencyCode] {
+		if info.District, ok = regions.names[info.DistrictCode]; !ok {
+			return models.NIKInfo{}, fmt.Errorf("%w: unknown district code %s", ErrInvalidNIK, info.DistrictCode)
+		}
+	} else if nik[4:6] == "00" {
+		return models.NIKInfo{}, fmt.Errorf("%w: unknown district code %s", ErrInvalidNIK, info.DistrictCode)
+	}
+
+	day, _ := strconv.Atoi(nik[6:8])
+	month, _ := strconv.Atoi(nik[8:10])
+	yy, _ := strconv.Atoi(nik[10:12])
+	info.Gender = models.GenderMale
+	if day > 40 {
+		info.Gender = models.GenderFemale
+		day -= 40
+	}
+	year := now.Year()/100*100 + yy
+	if year > now.Year() {
+		year -= 100
+	}
+	birthDate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if day < 1 || month < 1 || month > 12 || birthDate.Day() != day {
+		return models.NIKInfo{}, fmt.Errorf("%w: birth date %s does not exist", ErrInvalidNIK, nik[6:12])
+	}
+	if birthDate.After(now) {
+		// Only possible for this year's two digits with a later day.
+		birthDate = birthDate.AddDate(-100, 0, 0)
+		if birthDate.Day() != day {
+			return models.NIKInfo{}, fmt.Errorf("%w: birth date %s does not exist", ErrInvalidNIK, nik[6:12])
+		}
+	}
+	info.BirthDate = birthDate
+
+	if info.Serial == "0000" {
+		return models.NIKInfo{}, fmt.Errorf("%w: serial number 0000", ErrInvalidNIK)
+	}
+	return info, nil
+}
+
+// FormatNIK builds the NIK of a person from the 6-digit district code, birth
+// date, gender and serial number (1 to 9999). It is the inverse of ParseNIK.
+func FormatNIK(districtCode string, birthDate time.Time, gender string, serial int) string {
+	day := birthDate.Day()
+	if gender == models.GenderFemale {
+		day += 40
+	}
+	return fmt.Sprintf("%s%02d%02d%02d%04d", districtCode, day, int(birthDate.Month()), birthDate.Year()%100, serial)
+}
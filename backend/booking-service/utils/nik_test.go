@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+func TestParseNIK(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	info, err := ParseNIK("3171015708950123", now)
+	if err != nil {
+		t.Fatalf("ParseNIK() error = %v", err)
+	}
+	want := models.NIKInfo{
+		ProvinceCode: "31", Province: "DKI Jakarta",
+		RegencyCode: "3171", Regency: "Kota Administrasi Jakarta Selatan",
+		DistrictCode: "317101", District: "Jagakarsa",
+		BirthDate: time.Date(1995, time.August, 17, 0, 0, 0, 0, time.UTC),
+		Gender:    models.GenderFemale,
+		Serial:    "0123",
+	}
+	if info != want {
+		t.Errorf("ParseNIK() = %+v, want %+v", info, want)
+	}
+	if age := info.AgeOn(now); age != 31 {
+		t.Errorf("AgeOn() = %d, want 31", age)
+	}
+
+	// Provinces without regencies in the bundled table accept any regency and
+	// district, and the century follows the current date.
+	info, err = ParseNIK("3273122909240001", now)
+	if err != nil {
+		t.Fatalf("ParseNIK() error = %v", err)
+	}
+	if info.Province != "Jawa Barat" || info.Regency != "" || info.Gender != models.GenderMale || info.BirthDate.Year() != 2024 {
+		t.Errorf("ParseNIK() = %+v", info)
+	}
+	if info, err := ParseNIK("3273120111260001", now); err != nil || info.BirthDate.Year() != 1926 {
+		t.Errorf("ParseNIK() with a birth date later this year = %+v, %v; want 1926", info, err)
+	}
+	if info, err := ParseNIK("3273122902960001", now); err != nil || info.BirthDate != time.Date(1996, time.February, 29, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("ParseNIK() of 29 February in a leap year = %+v, %v", info, err)
+	}
+}
+
+func TestParseNIKRejectsInvalidNumbers(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"317101170895012":   "16 digits",
+		"31710117089501234": "16 digits",
+		"31710117089a0123":  "16 digits",
+		"9971011708950123":  "province",
+		"3199011708950123":  "regency",
+		"3171991708950123":  "district",
+		"3200001708950123":  "regency",
+		"3273001708950123":  "district",
+		"3171010008950123":  "birth date",
+		"3171013508950123":  "birth date",
+		"3171017208950123":  "birth date",
+		"3171011713950123":  "birth date",
+		"3171013002950123":  "birth date",
+		"3171012902950123":  "birth date",
+		"3171011708950000":  "serial",
+	}
+	for nik, reason := range tests {
+		_, err := ParseNIK(nik, now)
+		if !errors.Is(err, ErrInvalidNIK) || !strings.Contains(err.Error(), reason) {
+			t.Errorf("ParseNIK(%s) error = %v, want an invalid %s", nik, err, reason)
+		}
+	}
+}
+
+func TestFormatNIKRoundTrips(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	birthDate := time.Date(2001, time.March, 9, 0, 0, 0, 0, time.UTC)
+	nik := FormatNIK("340401", birthDate, models.GenderFemale, 42)
+	if nik != "3404014903010042" {
+		t.Fatalf("FormatNIK() = %s", nik)
+	}
+	info, err := ParseNIK(nik, now)
+	if err != nil || !info.BirthDate.Equal(birthDate) || info.Gender != models.GenderFemale || info.Regency != "Kabupaten Sleman" {
+		t.Errorf("ParseNIK(FormatNIK()) = %+v, %v", info, err)
+	}
+}
+
+func TestParseNIKRegionsAcceptsKemendagriCodes(t *testing.T) {
+	table, err := parseNIKRegions(strings.NewReader("code,name\n11,ACEH\n11.01,KAB. ACEH SELATAN\n11.01.01,Bakongan\n11.01.01.2001,Keude Bakongan\n"))
+	if err != nil {
+		t.Fatalf("parseNIKRegions() error = %v", err)
+	}
+	if table.names["110101"] != "Bakongan" || !table.hasRegencies["11"] || !table.hasDistricts["1101"] || len(table.names) != 3 {
+		t.Errorf("parseNIKRegions() = %+v", table)
+	}
+	if _, err := parseNIKRegions(strings.NewReader("code,name\nxx,Nowhere\n")); err == nil {
+		t.Errorf("parseNIKRegions() accepted a non-numeric code")
+	}
+}
@@ -0,0 +1,326 @@
+package utils
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"backend/booking-service/config"
+
+	"gorm.io/gorm/schema"
+)
+
+// Buyer and ticket holder PII is stored with envelope encryption: every value
+// is sealed with its own random data key, and the data key is sealed with a
+// master key from PII_ENCRYPTION_KEYS. A stored value looks like
+//
+//	pii1.<key id>.<sealed data key>.<sealed value>
+//
+// The first configured master key seals new values; the others are only used
+// to open values written before a rotation. Rotating therefore means putting a
+// new key first and re-sealing the data keys (database.RotatePIIKeys), without
+// touching the values themselves.
+
+const piiPrefix = "pii1"
+
+var (
+	ErrPIINotConfigured = errors.New("PII encryption keys are not configured: set PII_ENCRYPTION_KEYS, or PII_ALLOW_DEV_KEYS=true for local development")
+	ErrPIIUnknownKey    = errors.New("PII value is sealed with an unknown key")
+	ErrPIIMalformed     = errors.New("malformed encrypted PII value")
+)
+
+type piiKeyring struct {
+	primary  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+var piiKeys *piiKeyring
+
+// Development keys, used only when PII_ALLOW_DEV_KEYS is set and the real
+// keys are not. They are public, so anything sealed with them is not private.
+const (
+	devPIIEncryptionKeys = "dev1:wUrD8HZY6SBxjz3pmvpCxQZyEGRB4or6o+GCRb9r6uY="
+	devPIIBlindIndexKey  = "5a2jOSTN7n74d6tVhApvSoZ7KBobssoPbMkE4Y2Imjg="
+)
+
+// InitPII loads the master keys and the blind index key. PII_ENCRYPTION_KEYS
+// is a comma-separated list of id:base64-key pairs with 32-byte keys, newest
+// first; PII_BLIND_INDEX_KEY is a base64 key of at least 32 bytes. Both are
+// required unless PII_ALLOW_DEV_KEYS is set, which fills in public
+// development keys for whichever is missing.
+func InitPII(cfg *config.Config) error {
+	encryptionKeys, blindIndexKey := cfg.PIIEncryptionKeys, cfg.PIIBlindIndexKey
+	if cfg.PIIAllowDevKeys {
+		if strings.TrimSpace(encryptionKeys) == "" {
+			encryptionKeys = devPIIEncryptionKeys
+			LogWarning("PII_ENCRYPTION_KEYS is not set; sealing PII with the public development key. Never do this in production.")
+		}
+		if blindIndexKey == "" {
+			blindIndexKey = devPIIBlindIndexKey
+			LogWarning("PII_BLIND_INDEX_KEY is not set; indexing KTP numbers with the public development key. Never do this in production.")
+		}
+	}
+
+	ring := &piiKeyring{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(encryptionKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || strings.ContainsAny(id, ".:") {
+			return fmt.Errorf("invalid PII encryption key entry %q: want id:base64-key", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("PII encryption key %q must be 32 bytes of base64", id)
+		}
+		if _, exists := ring.keys[id]; exists {
+			return fmt.Errorf("duplicate PII encryption key id %q", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return err
+		}
+		ring.keys[id] = aead
+		if ring.primary == "" {
+			ring.primary = id
+		}
+	}
+	if ring.primary == "" {
+		return ErrPIINotConfigured
+	}
+
+	if blindIndexKey == "" {
+		return errors.New("PII blind index key is not configured")
+	}
+	indexKey, err := base64.StdEncoding.DecodeString(blindIndexKey)
+	if err != nil || len(indexKey) < 32 {
+		return errors.New("PII blind index key must be at least 32 bytes of base64")
+	}
+	ring.indexKey = indexKey
+
+	piiKeys = ring
+	LogInfo("PII encryption enabled with %d master key(s), sealing with key '%s'.", len(ring.keys), ring.primary)
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrPIIMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// EncryptPII seals plaintext under the primary master key. context names the
+// column the value belongs to; the value only decrypts with the same context,
+// so sealed values cannot be swapped between columns.
+func EncryptPII(plaintext, context string) (string, error) {
+	if piiKeys == nil {
+		return "", ErrPIINotConfigured
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealedValue, err := seal(dataAEAD, []byte(plaintext), []byte(context))
+	if err != nil {
+		return "", err
+	}
+	sealedKey, err := seal(piiKeys.keys[piiKeys.primary], dataKey, []byte(piiKeys.primary))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		piiPrefix,
+		piiKeys.primary,
+		base64.RawStdEncoding.EncodeToString(sealedKey),
+		base64.RawStdEncoding.EncodeToString(sealedValue),
+	}, "."), nil
+}
+
+// IsEncryptedPII reports whether value was written by EncryptPII. Rows from
+// before encryption was introduced hold plaintext until they are rotated.
+func IsEncryptedPII(value string) bool {
+	return strings.HasPrefix(value, piiPrefix+".")
+}
+
+type sealedPII struct {
+	keyID      string
+	sealedKey  []byte
+	sealedData []byte
+}
+
+func parsePII(value string) (sealedPII, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 4 || parts[0] != piiPrefix {
+		return sealedPII{}, ErrPIIMalformed
+	}
+	sealedKey, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return sealedPII{}, ErrPIIMalformed
+	}
+	sealedData, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return sealedPII{}, ErrPIIMalformed
+	}
+	return sealedPII{keyID: parts[1], sealedKey: sealedKey, sealedData: sealedData}, nil
+}
+
+func (p sealedPII) dataKey() ([]byte, error) {
+	master, ok := piiKeys.keys[p.keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrPIIUnknownKey, p.keyID)
+	}
+	dataKey, err := open(master, p.sealedKey, []byte(p.keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PII data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// DecryptPII opens a value sealed by EncryptPII with the same context. Legacy
+// plaintext values are returned as they are.
+func DecryptPII(value, context string) (string, error) {
+	if !IsEncryptedPII(value) {
+		return value, nil
+	}
+	if piiKeys == nil {
+		return "", ErrPIINotConfigured
+	}
+	sealed, err := parsePII(value)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := sealed.dataKey()
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, sealed.sealedData, []byte(context))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt PII: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// RewrapPII brings a stored value up to date with the primary master key: a
+// data key sealed with an older master key is re-sealed, and legacy plaintext
+// is encrypted. It reports whether the value changed.
+func RewrapPII(value, context string) (string, bool, error) {
+	if piiKeys == nil {
+		return "", false, ErrPIINotConfigured
+	}
+	if !IsEncryptedPII(value) {
+		encrypted, err := EncryptPII(value, context)
+		return encrypted, err == nil, err
+	}
+	sealed, err := parsePII(value)
+	if err != nil {
+		return "", false, err
+	}
+	if sealed.keyID == piiKeys.primary {
+		return value, false, nil
+	}
+	dataKey, err := sealed.dataKey()
+	if err != nil {
+		return "", false, err
+	}
+	sealedKey, err := seal(piiKeys.keys[piiKeys.primary], dataKey, []byte(piiKeys.primary))
+	if err != nil {
+		return "", false, err
+	}
+	return strings.Join([]string{
+		piiPrefix,
+		piiKeys.primary,
+		base64.RawStdEncoding.EncodeToString(sealedKey),
+		base64.RawStdEncoding.EncodeToString(sealed.sealedData),
+	}, "."), true, nil
+}
+
+// BlindIndex is a keyed hash of a KTP number. It is stored next to the
+// encrypted value so bookings can still be found by KTP, and purchase limits
+// and the identity cluster review match identifiers by it. It is not unique:
+// one KTP number may be on several bookings. Spaces are ignored.
+func BlindIndex(ktpNumber string) (string, error) {
+	if piiKeys == nil {
+		return "", ErrPIINotConfigured
+	}
+	mac := hmac.New(sha256.New, piiKeys.indexKey)
+	mac.Write([]byte(strings.ReplaceAll(ktpNumber, " ", "")))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// PIISerializer encrypts string fields tagged `gorm:"serializer:pii"` on the
+// way into the database and decrypts them on the way out. The table and column
+// name are the encryption context.
+type PIISerializer struct{}
+
+func init() {
+	schema.RegisterSerializer("pii", PIISerializer{})
+}
+
+func piiContext(field *schema.Field) string {
+	return field.Schema.Table + "." + field.DBName
+}
+
+func (PIISerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+		return nil
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted field %s", dbValue, field.Name)
+	}
+	plaintext, err := DecryptPII(stored, piiContext(field))
+	if err != nil {
+		return fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (PIISerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s must be a string, got %T", field.Name, fieldValue)
+	}
+	return EncryptPII(plaintext, piiContext(field))
+}
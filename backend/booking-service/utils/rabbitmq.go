@@ -2,30 +2,56 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
+	"backend/booking-service/config"
+
+	"github.com/google/uuid"
 	"github.com/rabbitmq/amqp091-go"
 )
 
+// RabbitMQConn and RabbitMQChannel are replaced on every reconnect. Read them
+// through currentRabbitMQ instead of directly.
 var (
 	RabbitMQConn    *amqp091.Connection
 	RabbitMQChannel *amqp091.Channel
+	QueuePolicies   = map[string]config.QueueConfig{}
+
+	rabbitMQURL   string
+	rabbitMQMu    sync.RWMutex
+	rabbitMQReady = make(chan struct{})
+	rabbitMQDone  = make(chan struct{})
+	closeOnce     sync.Once
 )
 
 const SeatCreationQueueName = "seat_creation_queue"
 const BookingCancellationQueueName = "booking_cancellation_queue"
 
-func InitRabbitMQ(amqpURL string) error {
+const (
+	reconnectBaseDelay = 1 * time.Second
+	reconnectMaxDelay  = 30 * time.Second
+	publishTimeout     = 10 * time.Second
+)
+
+// ErrRabbitMQClosed is returned once CloseRabbitMQConnection has been called.
+var ErrRabbitMQClosed = errors.New("rabbitmq connection closed")
+
+func InitRabbitMQ(amqpURL string, policies map[string]config.QueueConfig) error {
 	LogInfo("Attempting to connect to RabbitMQ at: %s", amqpURL)
+	rabbitMQURL = amqpURL
+	QueuePolicies = policies
+
 	var err error
 	var counts uint8 = 1
 	const maxRetries = 10
 	const retryDelay = 5 * time.Second
 
 	for counts <= maxRetries {
-		RabbitMQConn, err = amqp091.Dial(amqpURL)
+		err = connectRabbitMQ()
 		if err != nil {
 			LogError("Attempt %d/%d: Failed to connect to RabbitMQ: %v. Retrying in %s...", counts, maxRetries, err, retryDelay)
 			time.Sleep(retryDelay)
@@ -42,75 +68,236 @@ func InitRabbitMQ(amqpURL string) error {
 		return fmt.Errorf("failed to connect to RabbitMQ after retries: %w", err)
 	}
 
-	LogInfo("Opening RabbitMQ channel...")
-	RabbitMQChannel, err = RabbitMQConn.Channel()
+	LogInfo("RabbitMQ channel and all queues declared successfully!")
+	return nil
+}
+
+// connectRabbitMQ dials the broker, opens a publishing channel in confirm mode,
+// declares the topology and starts watching the connection. It only installs the
+// new connection once all of that succeeded.
+func connectRabbitMQ() error {
+	conn, err := amqp091.Dial(rabbitMQURL)
 	if err != nil {
-		LogError("Failed to open RabbitMQ channel: %v", err)
-		RabbitMQConn.Close()
-		log.Fatalf("Failed to open RabbitMQ channel: %v", err)
-		return fmt.Errorf("failed to open a RabbitMQ channel: %w", err)
+		return err
 	}
 
-	if _, err := DeclareQueue(SeatCreationQueueName); err != nil {
-		LogError("Failed to declare queue '%s': %v", SeatCreationQueueName, err)
-		RabbitMQChannel.Close()
-		RabbitMQConn.Close()
-		log.Fatalf("Failed to declare queue '%s': %v", SeatCreationQueueName, err)
-		return fmt.Errorf("failed to declare queue '%s': %w", SeatCreationQueueName, err)
+	ch, err := openRabbitMQChannel(conn)
+	if err != nil {
+		conn.Close()
+		return err
 	}
 
-	if _, err := DeclareQueue(BookingCancellationQueueName); err != nil {
-		LogError("Failed to declare queue '%s': %v", BookingCancellationQueueName, err)
-		RabbitMQChannel.Close()
-		RabbitMQConn.Close()
-		log.Fatalf("Failed to declare queue '%s': %v", BookingCancellationQueueName, err)
-		return fmt.Errorf("failed to declare queue '%s': %w", BookingCancellationQueueName, err)
+	for _, queueName := range []string{SeatCreationQueueName, BookingCancellationQueueName} {
+		if err := DeclareQueueTopology(ch, queueName); err != nil {
+			ch.Close()
+			conn.Close()
+			return fmt.Errorf("failed to declare queue '%s': %w", queueName, err)
+		}
 	}
 
-	LogInfo("RabbitMQ channel and all queues declared successfully!")
+	rabbitMQMu.Lock()
+	RabbitMQConn = conn
+	RabbitMQChannel = ch
+	close(rabbitMQReady)
+	rabbitMQMu.Unlock()
+
+	go watchRabbitMQ(conn, ch)
 	return nil
 }
 
-func PublishMessage(exchange, routingKey string, body []byte) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func openRabbitMQChannel(conn *amqp091.Connection) (*amqp091.Channel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a RabbitMQ channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to put RabbitMQ channel into confirm mode: %w", err)
+	}
+	return ch, nil
+}
+
+// watchRabbitMQ reopens the publishing channel when the broker closes it and
+// reconnects with exponential back-off when the whole connection is lost. A nil
+// close error means the connection was closed on purpose.
+func watchRabbitMQ(conn *amqp091.Connection, ch *amqp091.Channel) {
+	connClosed := conn.NotifyClose(make(chan *amqp091.Error, 1))
+	chClosed := ch.NotifyClose(make(chan *amqp091.Error, 1))
+
+	for {
+		select {
+		case err := <-chClosed:
+			if err == nil || conn.IsClosed() {
+				chClosed = nil
+				continue
+			}
+			LogWarning("RabbitMQ publishing channel closed: %v. Reopening it...", err)
+			newCh, openErr := openRabbitMQChannel(conn)
+			if openErr != nil {
+				LogError("Failed to reopen RabbitMQ publishing channel, reconnecting: %v", openErr)
+				conn.Close()
+				chClosed = nil
+				continue
+			}
+			rabbitMQMu.Lock()
+			RabbitMQChannel = newCh
+			rabbitMQMu.Unlock()
+			chClosed = newCh.NotifyClose(make(chan *amqp091.Error, 1))
+			LogInfo("RabbitMQ publishing channel reopened.")
+		case err, ok := <-connClosed:
+			select {
+			case <-rabbitMQDone:
+				return
+			default:
+			}
+			if ok && err != nil {
+				LogError("RabbitMQ connection lost: %v", err)
+			} else {
+				LogWarning("RabbitMQ connection closed.")
+			}
+			rabbitMQMu.Lock()
+			rabbitMQReady = make(chan struct{})
+			rabbitMQMu.Unlock()
+			reconnectRabbitMQ()
+			return
+		}
+	}
+}
+
+func reconnectRabbitMQ() {
+	delay := reconnectBaseDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-rabbitMQDone:
+			return
+		case <-time.After(delay):
+		}
+
+		if err := connectRabbitMQ(); err != nil {
+			LogError("Reconnect attempt %d to RabbitMQ failed: %v. Retrying in %s...", attempt, err, delay)
+			delay *= 2
+			if delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+			continue
+		}
+		LogInfo("Reconnected to RabbitMQ after %d attempt(s); topology re-declared.", attempt)
+		return
+	}
+}
+
+// currentRabbitMQ waits until a connection is available, ctx ends or the
+// connection is closed for good.
+func currentRabbitMQ(ctx context.Context) (*amqp091.Connection, *amqp091.Channel, error) {
+	for {
+		rabbitMQMu.RLock()
+		ready, conn, ch := rabbitMQReady, RabbitMQConn, RabbitMQChannel
+		rabbitMQMu.RUnlock()
+
+		select {
+		case <-ready:
+			return conn, ch, nil
+		default:
+		}
+
+		select {
+		case <-ready:
+		case <-rabbitMQDone:
+			return nil, nil, ErrRabbitMQClosed
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("rabbitmq connection not available: %w", ctx.Err())
+		}
+	}
+}
+
+// RabbitMQConnected reports whether a broker connection is currently open.
+func RabbitMQConnected() bool {
+	rabbitMQMu.RLock()
+	defer rabbitMQMu.RUnlock()
+	select {
+	case <-rabbitMQReady:
+		return RabbitMQConn != nil && !RabbitMQConn.IsClosed()
+	default:
+		return false
+	}
+}
+
+// PublishMessage publishes a persistent message and waits for the broker to
+// confirm it, so a nil error means RabbitMQ has taken responsibility for it.
+// During a reconnect it waits for the connection to come back until the publish
+// timeout runs out. The trace context of ctx travels in the message headers.
+func PublishMessage(ctx context.Context, exchange, routingKey string, body []byte) (err error) {
+	messageID := uuid.New().String()
+	headers := amqp091.Table{}
+	if id := RequestIDFromContext(ctx); id != "" {
+		headers[RequestIDHeader] = id
+	}
+	ctx, span := startPublishSpan(ctx, routingKey, messageID, headers)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
 	defer cancel()
 
-	err := RabbitMQChannel.PublishWithContext(
+	_, ch, err := currentRabbitMQ(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to queue '%s': %w", routingKey, err)
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(
 		ctx,
 		exchange,
 		routingKey,
 		false,
 		false,
 		amqp091.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+			Headers:      headers,
+			ContentType:  "application/json",
+			DeliveryMode: amqp091.Persistent,
+			MessageId:    messageID,
+			Timestamp:    time.Now(),
+			Body:         body,
 		})
 	if err != nil {
 		return fmt.Errorf("failed to publish message to queue '%s': %w", routingKey, err)
 	}
-	LogInfo("Message published to exchange '%s' with routing key '%s'.", exchange, routingKey)
+	if err := waitForConfirm(ctx, confirmation); err != nil {
+		return fmt.Errorf("failed to publish message to queue '%s': %w", routingKey, err)
+	}
+	LogInfoContext(ctx, "Message %s published to exchange '%s' with routing key '%s' and confirmed.", messageID, exchange, routingKey)
 	return nil
 }
 
-func ConsumeMessages(queueName string) (<-chan amqp091.Delivery, error) {
-	msgs, err := RabbitMQChannel.Consume(
-		queueName,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+// waitForConfirm blocks until the broker acks or nacks a publish. Channels that
+// are not in confirm mode return no confirmation and are treated as accepted.
+func waitForConfirm(ctx context.Context, confirmation *amqp091.DeferredConfirmation) error {
+	if confirmation == nil {
+		return nil
+	}
+	acked, err := confirmation.WaitContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to register a consumer for queue '%s': %w", queueName, err)
+		return fmt.Errorf("no publisher confirm received: %w", err)
+	}
+	if !acked {
+		return errors.New("message was nacked by the broker")
 	}
-	LogInfo("Started consuming messages from queue '%s'.", queueName)
-	return msgs, nil
+	return nil
+}
+
+// RabbitMQBus publishes to work queues through the default exchange.
+type RabbitMQBus struct{}
+
+func (RabbitMQBus) Publish(ctx context.Context, queue string, body []byte) error {
+	return PublishMessage(ctx, "", queue, body)
 }
 
-func DeclareQueue(queueName string) (amqp091.Queue, error) {
-	q, err := RabbitMQChannel.QueueDeclare(
+// Ping waits up to the deadline of ctx for a broker connection.
+func (RabbitMQBus) Ping(ctx context.Context) error {
+	_, _, err := currentRabbitMQ(ctx)
+	return err
+}
+
+func DeclareQueue(ch *amqp091.Channel, queueName string) (amqp091.Queue, error) {
+	q, err := ch.QueueDeclare(
 		queueName,
 		true,
 		false,
@@ -134,12 +321,18 @@ func BookingCancellationQueue() string {
 }
 
 func CloseRabbitMQConnection() {
-	if RabbitMQChannel != nil {
-		RabbitMQChannel.Close()
+	closeOnce.Do(func() { close(rabbitMQDone) })
+
+	rabbitMQMu.RLock()
+	conn, ch := RabbitMQConn, RabbitMQChannel
+	rabbitMQMu.RUnlock()
+
+	if ch != nil {
+		ch.Close()
 		LogInfo("RabbitMQ channel closed.")
 	}
-	if RabbitMQConn != nil {
-		RabbitMQConn.Close()
+	if conn != nil {
+		conn.Close()
 		LogInfo("RabbitMQ connection closed.")
 	}
 }
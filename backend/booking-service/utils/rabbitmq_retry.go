@@ -0,0 +1,500 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/booking-service/config"
+	"backend/booking-service/models"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	RetryCountHeader    = "x-retry-count"
+	LastErrorHeader     = "x-last-error"
+	OriginalQueueHeader = "x-original-queue"
+	FailedAtHeader      = "x-failed-at"
+)
+
+const (
+	// deadLetterPageSize is how many dead-lettered messages a replay reads at a time.
+	deadLetterPageSize = 100
+	// MaxDeadLetterReplay caps the dead-lettered messages one replay looks at,
+	// so a large dead-letter queue is replayed over several calls instead of
+	// being held in memory at once.
+	MaxDeadLetterReplay = 1000
+)
+
+// ErrUnknownQueue is returned by the dead-letter helpers for queues without a policy.
+var ErrUnknownQueue = errors.New("unknown queue")
+
+// ErrPermanent marks a failure that retrying cannot fix, such as a message
+// that does not parse. Handlers wrap such errors with Permanent.
+var ErrPermanent = errors.New("permanent failure")
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string   { return e.err.Error() }
+func (e permanentError) Unwrap() []error { return []error{ErrPermanent, e.err} }
+
+// Permanent marks err as a failure that retrying cannot fix, so the message is
+// dead-lettered at once. errors.Is still matches the wrapped error.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// RetryQueueName names retry queues after their delay, so changing a policy
+// declares new queues instead of clashing with the TTL of existing ones.
+func RetryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", queueName, delay.Milliseconds())
+}
+
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+func queuePolicy(queueName string) (config.QueueConfig, bool) {
+	policy, ok := QueuePolicies[queueName]
+	return policy, ok
+}
+
+// retryDelay doubles the base delay on every attempt, capped at the maximum delay.
+func retryDelay(policy config.QueueConfig, attempt int) time.Duration {
+	delay := policy.RetryBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= policy.RetryMaxDelay {
+			return policy.RetryMaxDelay
+		}
+	}
+	if delay > policy.RetryMaxDelay {
+		return policy.RetryMaxDelay
+	}
+	return delay
+}
+
+// DeclareQueueTopology declares a work queue together with a retry queue for each
+// back-off delay and a dead-letter queue. A retry queue holds messages for its delay
+// (x-message-ttl) and then dead-letters them back into the work queue through
+// the default exchange, which gives exponential back-off without a plugin.
+func DeclareQueueTopology(ch *amqp091.Channel, queueName string) error {
+	if _, err := DeclareQueue(ch, queueName); err != nil {
+		return err
+	}
+
+	policy, ok := queuePolicy(queueName)
+	if !ok {
+		return nil
+	}
+
+	for attempt := 1; attempt <= policy.MaxRetries; attempt++ {
+		delay := retryDelay(policy, attempt)
+		retryQueue := RetryQueueName(queueName, delay)
+		_, err := ch.QueueDeclare(
+			retryQueue,
+			true,
+			false,
+			false,
+			false,
+			amqp091.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue '%s': %w", retryQueue, err)
+		}
+	}
+
+	if _, err := DeclareQueue(ch, DeadLetterQueueName(queueName)); err != nil {
+		return err
+	}
+	LogInfo("Queue '%s' declared with %d retry queue(s) and dead-letter queue '%s'.", queueName, policy.MaxRetries, DeadLetterQueueName(queueName))
+	return nil
+}
+
+func retryCount(headers amqp091.Table) int {
+	switch v := headers[RetryCountHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func copyHeaders(headers amqp091.Table) amqp091.Table {
+	copied := amqp091.Table{}
+	for k, v := range headers {
+		copied[k] = v
+	}
+	return copied
+}
+
+// republish waits for the publisher confirm, so the original delivery is only
+// acknowledged once the copy is safely stored.
+func republish(ch *amqp091.Channel, routingKey string, d amqp091.Delivery, headers amqp091.Table) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", routingKey, false, false, amqp091.Publishing{
+		Headers:      headers,
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp091.Persistent,
+		MessageId:    d.MessageId,
+		Timestamp:    d.Timestamp,
+		Body:         d.Body,
+	})
+	if err != nil {
+		return err
+	}
+	return waitForConfirm(ctx, confirmation)
+}
+
+// handleDelivery runs the handler and settles the delivery. A failed message is
+// moved to the next retry queue, or to the dead-letter queue once its retries are
+// used up or the failure is permanent, and only then acknowledged, so a crash
+// never loses it.
+func handleDelivery(ch *amqp091.Channel, queueName string, policy config.QueueConfig, d amqp091.Delivery, handler func(context.Context, []byte) error) {
+	ctx, span := startConsumeSpan(queueName, d)
+	if id, ok := d.Headers[RequestIDHeader].(string); ok {
+		ctx = WithRequestID(ctx, id)
+	}
+	ctx = WithLogFields(ctx, slog.String("queue", queueName), slog.String("message_id", d.MessageId))
+	started := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic while handling message: %v", r)
+			}
+		}()
+		return handler(ctx, d.Body)
+	}()
+	endSpan(span, err)
+	observeDelivery(queueName, d.Timestamp, retryCount(d.Headers) == 0, started, err)
+	recordConsumed(queueName, err)
+	if err == nil {
+		if ackErr := d.Ack(false); ackErr != nil {
+			LogErrorContext(ctx, "Failed to ack message %s on queue '%s': %v", d.MessageId, queueName, ackErr)
+		}
+		return
+	}
+
+	attempt := retryCount(d.Headers) + 1
+	headers := copyHeaders(d.Headers)
+	headers[RetryCountHeader] = int32(attempt)
+	headers[LastErrorHeader] = err.Error()
+	headers[OriginalQueueHeader] = queueName
+
+	target := RetryQueueName(queueName, retryDelay(policy, attempt))
+	if permanent := errors.Is(err, ErrPermanent); permanent || attempt > policy.MaxRetries {
+		target = DeadLetterQueueName(queueName)
+		headers[FailedAtHeader] = time.Now().UTC().Format(time.RFC3339)
+		if permanent {
+			LogErrorContext(ctx, "Message %s on queue '%s' cannot be processed, moving it to '%s' without retrying: %v", d.MessageId, queueName, target, err)
+		} else {
+			LogErrorContext(ctx, "Message %s on queue '%s' failed after %d attempt(s), moving it to '%s': %v", d.MessageId, queueName, attempt, target, err)
+		}
+	} else {
+		LogWarningContext(ctx, "Message %s on queue '%s' failed (attempt %d/%d), retrying in %s: %v", d.MessageId, queueName, attempt, policy.MaxRetries+1, retryDelay(policy, attempt), err)
+	}
+
+	if pubErr := republish(ch, target, d, headers); pubErr != nil {
+		LogErrorContext(ctx, "Failed to move message %s to '%s', requeueing it: %v", d.MessageId, target, pubErr)
+		d.Nack(false, true)
+		return
+	}
+	if ackErr := d.Ack(false); ackErr != nil {
+		LogErrorContext(ctx, "Failed to ack message %s on queue '%s' after moving it to '%s': %v", d.MessageId, queueName, target, ackErr)
+	}
+}
+
+var (
+	consumersMu sync.Mutex
+	consumers   = make(map[string]*models.ConsumerStatus)
+)
+
+// consumerStatus returns the status entry of queueName. consumersMu must be held.
+func consumerStatus(queueName string) *models.ConsumerStatus {
+	status, ok := consumers[queueName]
+	if !ok {
+		status = &models.ConsumerStatus{Queue: queueName}
+		consumers[queueName] = status
+	}
+	return status
+}
+
+func setConsumerRunning(queueName string, running bool, err error) {
+	consumersMu.Lock()
+	defer consumersMu.Unlock()
+	status := consumerStatus(queueName)
+	if running {
+		now := time.Now()
+		if status.Since != nil {
+			status.Restarts++
+		}
+		status.Since = &now
+	}
+	status.Running = running
+	if err != nil {
+		status.LastError = err.Error()
+	}
+}
+
+func recordConsumed(queueName string, err error) {
+	consumersMu.Lock()
+	defer consumersMu.Unlock()
+	status := consumerStatus(queueName)
+	now := time.Now()
+	status.LastMessageAt = &now
+	status.Processed++
+	if err != nil {
+		status.Failed++
+		status.LastError = err.Error()
+	}
+}
+
+// ConsumerStatuses reports the consumers started on this instance.
+func ConsumerStatuses() []models.ConsumerStatus {
+	consumersMu.Lock()
+	defer consumersMu.Unlock()
+	statuses := make([]models.ConsumerStatus, 0, len(consumers))
+	for _, status := range consumers {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Queue < statuses[j].Queue })
+	return statuses
+}
+
+// ConsumeWithRetry consumes a queue with manual acknowledgements and the retry
+// policy of the queue. When the channel or connection drops it waits for the
+// connection manager to reconnect and starts consuming again, so it only returns
+// once ctx is cancelled or RabbitMQ is closed for good. On cancellation the
+// broker stops sending and the deliveries already received are handled before
+// it returns.
+func ConsumeWithRetry(ctx context.Context, queueName string, handler func(context.Context, []byte) error) error {
+	policy, ok := queuePolicy(queueName)
+	if !ok {
+		return fmt.Errorf("%w: '%s'", ErrUnknownQueue, queueName)
+	}
+
+	for {
+		conn, _, err := currentRabbitMQ(ctx)
+		if err != nil {
+			if errors.Is(err, ErrRabbitMQClosed) || ctx.Err() != nil {
+				LogInfo("Consumer for queue '%s' stopped.", queueName)
+				return nil
+			}
+			return err
+		}
+
+		if err := consumeQueue(ctx, conn, queueName, policy, handler); err != nil {
+			LogError("Consumer for queue '%s' failed: %v", queueName, err)
+			setConsumerRunning(queueName, false, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			LogInfo("Consumer for queue '%s' stopped.", queueName)
+			return nil
+		case <-rabbitMQDone:
+			LogInfo("Consumer for queue '%s' stopped.", queueName)
+			return nil
+		case <-time.After(reconnectBaseDelay):
+			LogWarning("Restarting consumer for queue '%s'...", queueName)
+		}
+	}
+}
+
+// consumeQueue consumes on its own channel until that channel closes or ctx is
+// cancelled.
+func consumeQueue(ctx context.Context, conn *amqp091.Connection, queueName string, policy config.QueueConfig, handler func(context.Context, []byte) error) error {
+	ch, err := openRabbitMQChannel(conn)
+	if err != nil {
+		return fmt.Errorf("failed to open a RabbitMQ channel for queue '%s': %w", queueName, err)
+	}
+	defer ch.Close()
+
+	if err := ch.Qos(policy.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch for queue '%s': %w", queueName, err)
+	}
+
+	consumerTag := fmt.Sprintf("%s-%s", queueName, uuid.NewString())
+	msgs, err := ch.Consume(queueName, consumerTag, false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to register a consumer for queue '%s': %w", queueName, err)
+	}
+	// Cancelling the consumer closes msgs once the deliveries the broker has
+	// already pushed (up to the prefetch) are read, so the loop below drains them.
+	stopCancel := context.AfterFunc(ctx, func() {
+		LogInfo("Stopping consumer for queue '%s', draining in-flight deliveries...", queueName)
+		if err := ch.Cancel(consumerTag, false); err != nil {
+			LogWarning("Failed to cancel consumer for queue '%s': %v", queueName, err)
+		}
+	})
+	defer stopCancel()
+	LogInfo("Started consuming messages from queue '%s' (max retries: %d, prefetch: %d).", queueName, policy.MaxRetries, policy.Prefetch)
+	setConsumerRunning(queueName, true, nil)
+
+	for d := range msgs {
+		handleDelivery(ch, queueName, policy, d, handler)
+	}
+	if ctx.Err() != nil {
+		LogInfo("Consumer for queue '%s' drained.", queueName)
+		setConsumerRunning(queueName, false, nil)
+		return nil
+	}
+	LogWarning("Consumer for queue '%s' interrupted: channel closed.", queueName)
+	setConsumerRunning(queueName, false, errors.New("channel closed"))
+	return nil
+}
+
+func deadLetterFromDelivery(d amqp091.Delivery) models.DeadLetterMessage {
+	msg := models.DeadLetterMessage{
+		MessageID:  d.MessageId,
+		RetryCount: retryCount(d.Headers),
+		Body:       string(d.Body),
+	}
+	if v, ok := d.Headers[LastErrorHeader].(string); ok {
+		msg.LastError = v
+	}
+	if v, ok := d.Headers[OriginalQueueHeader].(string); ok {
+		msg.OriginalQueue = v
+	}
+	if v, ok := d.Headers[FailedAtHeader].(string); ok {
+		msg.FailedAt = v
+	}
+	if !d.Timestamp.IsZero() {
+		published := d.Timestamp
+		msg.PublishedAt = &published
+	}
+	return msg
+}
+
+// fetchDeadLetters takes up to limit messages off a dead-letter queue without
+// acknowledging them. Unacknowledged messages are not redelivered while the
+// channel is open, so every message is seen at most once per channel.
+func fetchDeadLetters(ch *amqp091.Channel, queueName string, limit int) ([]amqp091.Delivery, error) {
+	dlq := DeadLetterQueueName(queueName)
+	var deliveries []amqp091.Delivery
+	for len(deliveries) < limit {
+		d, ok, err := ch.Get(dlq, false)
+		if err != nil {
+			return deliveries, fmt.Errorf("failed to read dead-letter queue '%s': %w", dlq, err)
+		}
+		if !ok {
+			break
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+// adminChannel opens a short-lived confirm-mode channel for the dead-letter helpers.
+func adminChannel() (*amqp091.Channel, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	conn, _, err := currentRabbitMQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return openRabbitMQChannel(conn)
+}
+
+// InspectDeadLetters returns up to limit dead-lettered messages of a queue and
+// leaves them in place.
+func InspectDeadLetters(queueName string, limit int) ([]models.DeadLetterMessage, error) {
+	if _, ok := queuePolicy(queueName); !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownQueue, queueName)
+	}
+
+	ch, err := adminChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	deliveries, err := fetchDeadLetters(ch, queueName, limit)
+	messages := make([]models.DeadLetterMessage, 0, len(deliveries))
+	for _, d := range deliveries {
+		messages = append(messages, deadLetterFromDelivery(d))
+		d.Nack(false, true)
+	}
+	return messages, err
+}
+
+// ReplayDeadLetters moves dead-lettered messages back to their work queue with a
+// fresh retry budget. With no message IDs every dead-lettered message is
+// replayed. The queue is read a page at a time and at most MaxDeadLetterReplay
+// messages are looked at per call; more reports that the call stopped there
+// before the end of the queue, and calling again continues.
+func ReplayDeadLetters(queueName string, messageIDs []string) (replayed int, more bool, err error) {
+	if _, ok := queuePolicy(queueName); !ok {
+		return 0, false, fmt.Errorf("%w: '%s'", ErrUnknownQueue, queueName)
+	}
+
+	wanted := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		wanted[id] = true
+	}
+
+	ch, err := adminChannel()
+	if err != nil {
+		return 0, false, err
+	}
+	defer ch.Close()
+
+	// Skipped messages stay unacknowledged until the end, so the next page
+	// does not read them again.
+	var skipped []amqp091.Delivery
+	defer func() {
+		for _, d := range skipped {
+			d.Nack(false, true)
+		}
+		if replayed > 0 {
+			LogInfo("Replayed %d dead-lettered message(s) to queue '%s'.", replayed, queueName)
+		}
+	}()
+
+	for seen := 0; seen < MaxDeadLetterReplay; {
+		pageSize := min(deadLetterPageSize, MaxDeadLetterReplay-seen)
+		page, err := fetchDeadLetters(ch, queueName, pageSize)
+		seen += len(page)
+		for _, d := range page {
+			if len(messageIDs) > 0 && !wanted[d.MessageId] {
+				skipped = append(skipped, d)
+				continue
+			}
+			headers := copyHeaders(d.Headers)
+			delete(headers, RetryCountHeader)
+			delete(headers, FailedAtHeader)
+			if pubErr := republish(ch, queueName, d, headers); pubErr != nil {
+				LogError("Failed to replay dead-lettered message %s to '%s': %v", d.MessageId, queueName, pubErr)
+				skipped = append(skipped, d)
+				continue
+			}
+			d.Ack(false)
+			delete(wanted, d.MessageId)
+			replayed++
+		}
+		if err != nil {
+			return replayed, false, err
+		}
+		if len(page) < pageSize || (len(messageIDs) > 0 && len(wanted) == 0) {
+			return replayed, false, nil
+		}
+	}
+	return replayed, true, nil
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"backend/booking-service/models"
+)
+
+// RedisSeatCache is the Redis implementation of services.SeatCache. It uses the
+// shared RedisClient.
+type RedisSeatCache struct{}
+
+func (RedisSeatCache) SetAvailableSeats(ctx context.Context, concertID uint, availableSeats int) error {
+	return SetAvailableSeatsCache(ctx, concertID, availableSeats)
+}
+
+func (RedisSeatCache) SetAvailableSeatsByClass(ctx context.Context, ticketClassID uint, availableSeats int) error {
+	return SetAvailableSeatsCacheByClass(ctx, ticketClassID, availableSeats)
+}
+
+func (RedisSeatCache) GetAvailableSeats(ctx context.Context, concertID uint) (int, error) {
+	return GetAvailableSeatsFromCache(ctx, concertID)
+}
+
+func (RedisSeatCache) GetAvailableSeatsByClass(ctx context.Context, ticketClassID uint) (int, error) {
+	return GetAvailableSeatsCacheByClass(ctx, ticketClassID)
+}
+
+func (RedisSeatCache) DecreaseAvailableSeats(ctx context.Context, concertID, ticketClassID uint, numSeats int) (int64, error) {
+	return DecreaseAvailableSeatsAtomically(ctx, concertID, ticketClassID, numSeats)
+}
+
+func (RedisSeatCache) IncreaseAvailableSeats(ctx context.Context, concertID, ticketClassID uint, numSeats int) (int64, error) {
+	return IncreaseAvailableSeatsAtomically(ctx, concertID, ticketClassID, numSeats)
+}
+
+func (RedisSeatCache) ScheduleBookingExpiry(ctx context.Context, bookingID string, expiresAt time.Time) error {
+	return ScheduleBookingExpiry(ctx, bookingID, expiresAt)
+}
+
+func (RedisSeatCache) UnscheduleBookingExpiry(ctx context.Context, bookingID string) error {
+	return UnscheduleBookingExpiry(ctx, bookingID)
+}
+
+func (RedisSeatCache) ClaimDueBookingExpiries(ctx context.Context, now time.Time, claimTimeout time.Duration, limit int) ([]string, error) {
+	return ClaimDueBookingExpiries(ctx, now, claimTimeout, limit)
+}
+
+// RedisEventPublisher is the Redis pub/sub implementation of services.EventPublisher.
+type RedisEventPublisher struct{}
+
+func (RedisEventPublisher) PublishAvailabilityEvent(ctx context.Context, event models.AvailabilityEvent) {
+	PublishAvailabilityEvent(ctx, event)
+}
+
+func (RedisEventPublisher) PublishBookingEvent(ctx context.Context, event models.BookingEvent) {
+	PublishBookingEvent(ctx, event)
+}
@@ -8,17 +8,26 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
 var RedisClient *redis.Client
 
+var (
+	ErrNotEnoughSeats = errors.New("not enough available seats")
+	ErrSeatContention = errors.New("too much contention on the seat counter")
+)
+
 func InitRedis(addr, password string, db int) error {
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: password,
 		DB:       db,
 	})
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		LogWarning("Failed to instrument Redis client for tracing: %v", err)
+	}
 
 	var err error
 	var counts uint8 = 1
@@ -36,7 +45,7 @@ func InitRedis(addr, password string, db int) error {
 			counts++
 			continue
 		} else {
-			LogInfo("Connected to Redis successfully for rate limiting!")
+			LogInfoContext(ctx, "Connected to Redis successfully for rate limiting!")
 			RedisClient = client
 			return nil
 		}
@@ -97,9 +106,10 @@ func GetAvailableSeatsCacheByClass(ctx context.Context, ticketClassID uint) (int
 	return seats, nil
 }
 
-func DecreaseAvailableSeatsAtomically(ctx context.Context, ticketClassID uint, numSeats int) (int64, error) {
+func DecreaseAvailableSeatsAtomically(ctx context.Context, concertID, ticketClassID uint, numSeats int) (int64, error) {
 	key := fmt.Sprintf("ticket_class:%d:available_seats", ticketClassID)
 
+	var remaining int64
 	txf := func(tx *redis.Tx) error {
 		n, err := tx.Get(ctx, key).Int()
 		if err != nil && err != redis.Nil {
@@ -111,33 +121,37 @@ func DecreaseAvailableSeatsAtomically(ctx context.Context, ticketClassID uint, n
 		}
 
 		if n < numSeats {
-			return fmt.Errorf("not enough available seats for class. Current: %d, Requested: %d", n, numSeats)
+			return fmt.Errorf("%w for class. Current: %d, Requested: %d", ErrNotEnoughSeats, n, numSeats)
 		}
 
-		_, err = tx.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
 			pipe.Set(ctx, key, n-numSeats, 0)
 			return nil
 		})
+		remaining = int64(n - numSeats)
 		return err
 	}
 
 	for retries := 0; retries < 5; retries++ {
 		err := RedisClient.Watch(ctx, txf, key)
 		if err == nil {
-			return RedisClient.Get(ctx, key).Int64()
+			publishTicketClassAvailability(ctx, concertID, ticketClassID, remaining, -numSeats)
+			return remaining, nil
 		}
 		if err == redis.TxFailedErr {
-			LogWarning("Redis transaction failed, retrying for ticket class %d. Attempt: %d", ticketClassID, retries+1)
+			SeatReservationRetriesTotal.WithLabelValues("decrease").Inc()
+			LogWarningContext(ctx, "Redis transaction failed, retrying for ticket class %d. Attempt: %d", ticketClassID, retries+1)
 			continue
 		}
 		return 0, err
 	}
-	return 0, errors.New("failed to decrease available seats after multiple retries due to contention")
+	return 0, fmt.Errorf("failed to decrease available seats after multiple retries: %w", ErrSeatContention)
 }
 
-func IncreaseAvailableSeatsAtomically(ctx context.Context, ticketClassID uint, numSeats int) (int64, error) {
+func IncreaseAvailableSeatsAtomically(ctx context.Context, concertID, ticketClassID uint, numSeats int) (int64, error) {
 	key := fmt.Sprintf("ticket_class:%d:available_seats", ticketClassID)
 
+	var remaining int64
 	txf := func(tx *redis.Tx) error {
 		n, err := tx.Get(ctx, key).Int()
 		if err != nil && err != redis.Nil {
@@ -147,23 +161,26 @@ func IncreaseAvailableSeatsAtomically(ctx context.Context, ticketClassID uint, n
 			n = 0
 		}
 
-		_, err = tx.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
 			pipe.Set(ctx, key, n+numSeats, 0)
 			return nil
 		})
+		remaining = int64(n + numSeats)
 		return err
 	}
 
 	for retries := 0; retries < 5; retries++ {
 		err := RedisClient.Watch(ctx, txf, key)
 		if err == nil {
-			return RedisClient.Get(ctx, key).Int64()
+			publishTicketClassAvailability(ctx, concertID, ticketClassID, remaining, numSeats)
+			return remaining, nil
 		}
 		if err == redis.TxFailedErr {
-			LogWarning("Redis transaction failed during seat increase, retrying for ticket class %d. Attempt: %d", ticketClassID, retries+1)
+			SeatReservationRetriesTotal.WithLabelValues("increase").Inc()
+			LogWarningContext(ctx, "Redis transaction failed during seat increase, retrying for ticket class %d. Attempt: %d", ticketClassID, retries+1)
 			continue
 		}
 		return 0, err
 	}
-	return 0, errors.New("failed to increase available seats after multiple retries due to contention")
+	return 0, fmt.Errorf("failed to increase available seats after multiple retries: %w", ErrSeatContention)
 }
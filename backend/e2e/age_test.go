@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	bookingmodels "backend/booking-service/models"
+	bookingutils "backend/booking-service/utils"
+	paymentmodels "backend/payment-service/models"
+)
+
+func TestAgeRestrictedConcertAndCheckIn(t *testing.T) {
+	h := Start(t)
+	admin := h.NewClient(t)
+	admin.RegisterAdmin("promoter")
+
+	date := time.Now().Add(30 * 24 * time.Hour)
+	var created bookingmodels.ConcertResponse
+	admin.Expect(http.StatusCreated, http.MethodPost, h.BookingURL+"/admin/concerts/", bookingmodels.CreateConcertRequest{
+		Name:          "Djakarta Warehouse Project",
+		Artist:        "Various Artists",
+		Date:          date,
+		Venue:         "GBK",
+		MinimumAge:    21,
+		ImageUrl:      "https://example.com/poster.jpg",
+		TicketClasses: []bookingmodels.CreateTicketClassRequest{{Name: "Regular", Price: 750000, TotalSeatsInClass: 3}},
+	}).Decode(t, &created)
+	concert := getConcert(t, h, created.ID)
+	if concert.MinimumAge != 21 {
+		t.Fatalf("concert minimum age = %d, want 21", concert.MinimumAge)
+	}
+	regularID, _ := classSeats(concert, "Regular")
+
+	// The buyer is old enough, but the holder turns 21 the day after the concert.
+	fan := h.NewClient(t)
+	fan.Register("budi")
+	tooYoung := bookingutils.FormatNIK("317101", date.AddDate(-21, 0, 1), bookingmodels.GenderFemale, 7)
+	req := bookingRequest(concert.ID, regularID, 1, 1)
+	req.TicketHolderInfo = &bookingmodels.TicketHolderRequest{FullName: "Siti Rahma", KTPNumber: tooYoung}
+	problem := fan.Expect(http.StatusForbidden, http.MethodPost, h.BookingURL+"/bookings/", req).Problem(t)
+	if problem.Code != bookingmodels.CodeAgeRestricted {
+		t.Fatalf("booking for a 20 year old holder: code %q, want %q", problem.Code, bookingmodels.CodeAgeRestricted)
+	}
+
+	req.TicketHolderInfo.KTPNumber = KTP(2)
+	booking := fan.book(req)
+	checkInURL := fmt.Sprintf("%s/admin/bookings/%s/check-in", h.BookingURL, booking.ID)
+	if problem := admin.Expect(http.StatusConflict, http.MethodPost, checkInURL, nil).Problem(t); problem.Code != bookingmodels.CodeBookingNotConfirmed {
+		t.Errorf("checking in a pending booking: code %q, want %q", problem.Code, bookingmodels.CodeBookingNotConfirmed)
+	}
+
+	fan.Expect(http.StatusOK, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    "12/30",
+		CVV:           "123",
+	})
+
+	fan.Expect(http.StatusForbidden, http.MethodPost, checkInURL, nil)
+	var first bookingmodels.CheckInResponse
+	admin.Expect(http.StatusOK, http.MethodPost, checkInURL, nil).Decode(t, &first)
+	if first.HolderName != "Siti Rahma" || first.AgeBand != bookingmodels.AgeBand21Plus || !first.MeetsMinimumAge || first.MinimumAge != 21 || first.Tickets != 1 || first.AlreadyCheckedIn {
+		t.Errorf("check-in = %+v", first)
+	}
+
+	// A second scan of the same ticket is flagged.
+	var second bookingmodels.CheckInResponse
+	admin.Expect(http.StatusOK, http.MethodPost, checkInURL, nil).Decode(t, &second)
+	if !second.AlreadyCheckedIn || !second.CheckedInAt.Equal(first.CheckedInAt) {
+		t.Errorf("second check-in = %+v, want already checked in at %s", second, first.CheckedInAt)
+	}
+	if got := fan.getBooking(booking.ID).CheckedInAt; got == nil || !got.Equal(first.CheckedInAt) {
+		t.Errorf("booking checked_in_at = %v, want %s", got, first.CheckedInAt)
+	}
+}
@@ -0,0 +1,153 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+	"time"
+
+	bookingmodels "backend/booking-service/models"
+	bookingutils "backend/booking-service/utils"
+	usermodels "backend/user-service/models"
+)
+
+// Client is a browser-like API client. Its cookie jar holds the session cookie
+// set by the user service, which the other services accept as well since
+// cookies are not scoped by port.
+type Client struct {
+	t    testing.TB
+	h    *Harness
+	http *http.Client
+}
+
+// Response is a buffered HTTP response.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Decode unmarshals the response body into v.
+func (r *Response) Decode(t testing.TB, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("decoding response %s: %v", r.Body, err)
+	}
+}
+
+// Problem decodes an application/problem+json error response.
+func (r *Response) Problem(t testing.TB) Problem {
+	t.Helper()
+	if ct := r.Header.Get("Content-Type"); ct != "application/problem+json" && ct != "application/problem+json; charset=utf-8" {
+		t.Fatalf("Content-Type = %q, want application/problem+json (body %s)", ct, r.Body)
+	}
+	var problem Problem
+	r.Decode(t, &problem)
+	return problem
+}
+
+// Problem holds the members of an RFC 7807 error the tests look at.
+type Problem struct {
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+func (h *Harness) NewClient(t testing.TB) *Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{t: t, h: h, http: &http.Client{Jar: jar}}
+}
+
+// Do sends body, if not nil, as JSON and returns the buffered response.
+func (c *Client) Do(method, url string, body any, headers ...string) *Response {
+	c.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("%s %s: reading response: %v", method, url, err)
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody}
+}
+
+// Expect sends a request like Do and fails the test unless the response has
+// the wanted status code.
+func (c *Client) Expect(wantStatus int, method, url string, body any, headers ...string) *Response {
+	c.t.Helper()
+	resp := c.Do(method, url, body, headers...)
+	if resp.StatusCode != wantStatus {
+		c.t.Fatalf("%s %s: status %d, want %d (body %s)", method, url, resp.StatusCode, wantStatus, resp.Body)
+	}
+	return resp
+}
+
+// Register signs up a user and logs the client in as that user.
+func (c *Client) Register(username string) usermodels.UserResponse {
+	c.t.Helper()
+	password := "secret-" + username
+	c.Expect(http.StatusCreated, http.MethodPost, c.h.UserURL+"/register", usermodels.UserRegisterRequest{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: password,
+	})
+	return c.Login(username, password)
+}
+
+func (c *Client) Login(username, password string) usermodels.UserResponse {
+	c.t.Helper()
+	resp := c.Expect(http.StatusOK, http.MethodPost, c.h.UserURL+"/login", usermodels.UserLoginRequest{
+		Username: username,
+		Password: password,
+	})
+	var body struct {
+		User usermodels.UserResponse `json:"user"`
+	}
+	resp.Decode(c.t, &body)
+	return body.User
+}
+
+// RegisterAdmin signs up a user, gives it the admin role and logs in again so
+// the session token carries the role.
+func (c *Client) RegisterAdmin(username string) usermodels.UserResponse {
+	c.t.Helper()
+	c.Register(username)
+	if err := c.h.UserDB.Model(&usermodels.User{}).Where("username = ?", username).Update("role", "admin").Error; err != nil {
+		c.t.Fatalf("promoting %s to admin: %v", username, err)
+	}
+	return c.Login(username, "secret-"+username)
+}
+
+// KTP returns a valid, distinct NIK for n from 1 to 9999: that of a man from
+// Jagakarsa, Jakarta Selatan, born on 10 January 1990, with serial number n.
+func KTP(n int) string {
+	return bookingutils.FormatNIK("317101", time.Date(1990, time.January, 10, 0, 0, 0, 0, time.UTC), bookingmodels.GenderMale, n)
+}
@@ -0,0 +1,219 @@
+// Package e2e boots the user, booking and payment services in one process for
+// end-to-end tests. Each service gets its own SQLite database, all of them share
+// one miniredis (like the single Redis in docker-compose), RabbitMQ is replaced
+// by the in-memory bus from backend/booking-service/fakes, and the services
+// call each other over httptest servers.
+package e2e
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	bookingconfig "backend/booking-service/config"
+	"backend/booking-service/fakes"
+	bookingserver "backend/booking-service/server"
+	bookingutils "backend/booking-service/utils"
+	paymentconfig "backend/payment-service/config"
+	paymentmiddlewares "backend/payment-service/middlewares"
+	paymentserver "backend/payment-service/server"
+	paymentutils "backend/payment-service/utils"
+	userconfig "backend/user-service/config"
+	usermiddlewares "backend/user-service/middlewares"
+	userserver "backend/user-service/server"
+	userutils "backend/user-service/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
+)
+
+const jwtSecret = "e2e-shared-jwt-secret"
+
+// internalKey is the key the payment service signs its calls to the booking
+// service's internal routes with.
+var internalKey = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, 32))
+
+// Harness is a running set of services. The Redis clients and JWT secrets of
+// the services are package globals, so only one harness may run at a time:
+// tests that start one must not call t.Parallel.
+type Harness struct {
+	UserURL    string
+	BookingURL string
+	PaymentURL string
+
+	UserDB    *gorm.DB
+	BookingDB *gorm.DB
+	PaymentDB *gorm.DB
+
+	Redis *miniredis.Miniredis
+	Bus   *fakes.Bus
+	Clock *fakes.Clock
+
+	Booking *bookingserver.Server
+	Payment *paymentserver.Server
+	User    *userserver.Server
+}
+
+// Configs are the configurations the services boot with. Rate limits are left
+// at zero, which turns them off, unless a test sets them.
+type Configs struct {
+	User    *userconfig.Config
+	Booking *bookingconfig.Config
+	Payment *paymentconfig.Config
+}
+
+// Start boots the three services and stops them when the test ends. Options
+// may change the configurations first.
+func Start(t testing.TB, options ...func(*Configs)) *Harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	h := &Harness{
+		Redis: miniredis.RunT(t),
+		Bus:   fakes.NewBus(),
+		Clock: fakes.NewClock(time.Now()),
+	}
+	dir := t.TempDir()
+	h.UserDB = openDB(t, filepath.Join(dir, "user.db"), userSchema)
+	h.BookingDB = openDB(t, filepath.Join(dir, "booking.db"), bookingSchema)
+	h.PaymentDB = openDB(t, filepath.Join(dir, "payment.db"), paymentSchema)
+
+	// The services need each other's URLs before their routers exist, so the
+	// servers start first and get their handlers once everything is built.
+	userHandler, userSrv := lateBoundServer(t)
+	bookingHandler, bookingSrv := lateBoundServer(t)
+	paymentHandler, paymentSrv := lateBoundServer(t)
+	h.UserURL = userSrv.URL + "/api/v1/users"
+	h.BookingURL = bookingSrv.URL + "/api/v1"
+	h.PaymentURL = paymentSrv.URL + "/api/v1"
+
+	bookingutils.RedisClient = newRedisClient(t, h.Redis)
+	paymentmiddlewares.RedisClient = newRedisClient(t, h.Redis)
+	usermiddlewares.RedisClient = newRedisClient(t, h.Redis)
+
+	userCfg := &userconfig.Config{JWTSecret: jwtSecret}
+	bookingCfg := &bookingconfig.Config{
+		JWTSecret:            jwtSecret,
+		PaymentServiceAPIURL: h.PaymentURL,
+		LeaderLeaseTTL:       15 * time.Second,
+		IdempotencyKeyTTL:    24 * time.Hour,
+		PIIEncryptionKeys:    "e2e:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)),
+		PIIBlindIndexKey:     base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)),
+		InternalServiceKeys:  "payment-service:e2e:" + internalKey,
+		InternalAuthMaxSkew:  5 * time.Minute,
+	}
+	paymentCfg := &paymentconfig.Config{
+		JWTSecret:            jwtSecret,
+		BookingServiceAPIURL: h.BookingURL,
+		IdempotencyKeyTTL:    24 * time.Hour,
+		CardFingerprintKey:   base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{4}, 32)),
+		InternalSigningKey:   "e2e:" + internalKey,
+	}
+	for _, option := range options {
+		option(&Configs{User: userCfg, Booking: bookingCfg, Payment: paymentCfg})
+	}
+
+	userutils.InitJWT(userCfg)
+	h.User = userserver.New(userCfg, h.UserDB)
+	userHandler.set(h.User.Router)
+
+	bookingutils.InitJWT(bookingCfg)
+	if err := bookingutils.InitPII(bookingCfg); err != nil {
+		t.Fatalf("initializing PII encryption: %v", err)
+	}
+	if err := bookingutils.InitInternalAuth(bookingCfg); err != nil {
+		t.Fatalf("loading internal service keys: %v", err)
+	}
+	h.Booking = bookingserver.New(bookingCfg, bookingserver.Dependencies{
+		DB:     h.BookingDB,
+		Cache:  bookingutils.RedisSeatCache{},
+		Events: bookingutils.RedisEventPublisher{},
+		Bus:    h.Bus,
+		Clock:  h.Clock,
+	})
+	h.Bus.Subscribe(bookingutils.SeatCreationQueue(), h.Booking.ConcertService.ProcessSeatCreationMessage)
+	h.Bus.Subscribe(bookingutils.BookingCancellationQueue(), h.Booking.BookingService.ProcessBookingCancellationMessage)
+	bookingHandler.set(h.Booking.Router)
+
+	paymentutils.InitJWT(paymentCfg)
+	if err := paymentutils.InitInternalAuth(paymentCfg); err != nil {
+		t.Fatalf("loading internal signing key: %v", err)
+	}
+	h.Payment = paymentserver.New(paymentCfg, h.PaymentDB)
+	paymentHandler.set(h.Payment.Router)
+
+	return h
+}
+
+func openDB(t testing.TB, path string, schema []string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics())); err != nil {
+		t.Fatalf("instrumenting %s: %v", path, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	// One connection keeps SQLite from failing concurrent writers with
+	// SQLITE_BUSY; they queue for the connection instead.
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, statement := range schema {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("creating schema in %s: %v", path, err)
+		}
+	}
+	return db
+}
+
+func newRedisClient(t testing.TB, mr *miniredis.Miniredis) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+type handlerSlot struct {
+	mu      sync.RWMutex
+	handler http.Handler
+}
+
+func (s *handlerSlot) set(handler http.Handler) {
+	s.mu.Lock()
+	s.handler = handler
+	s.mu.Unlock()
+}
+
+func (s *handlerSlot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	handler := s.handler
+	s.mu.RUnlock()
+	if handler == nil {
+		http.Error(w, "service is starting", http.StatusServiceUnavailable)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
+
+func lateBoundServer(t testing.TB) (*handlerSlot, *httptest.Server) {
+	slot := &handlerSlot{}
+	srv := httptest.NewServer(slot)
+	t.Cleanup(srv.Close)
+	return slot, srv
+}
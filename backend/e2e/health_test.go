@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	bookingmodels "backend/booking-service/models"
+	paymentmodels "backend/payment-service/models"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	h := Start(t)
+	client := h.NewClient(t)
+
+	roots := map[string]string{
+		"user":    strings.TrimSuffix(h.UserURL, "/api/v1/users"),
+		"booking": strings.TrimSuffix(h.BookingURL, "/api/v1"),
+		"payment": strings.TrimSuffix(h.PaymentURL, "/api/v1"),
+	}
+	for service, root := range roots {
+		client.Expect(http.StatusOK, http.MethodGet, root+"/healthz", nil)
+		var report bookingmodels.ReadinessReport
+		client.Expect(http.StatusOK, http.MethodGet, root+"/readyz", nil).Decode(t, &report)
+		if report.Status != bookingmodels.HealthStatusOK {
+			t.Errorf("%s readiness = %+v, want ok", service, report)
+		}
+	}
+
+	// A broker outage takes the booking service out of rotation but does not
+	// fail its liveness probe.
+	h.Bus.FailOn("Ping", errors.New("connection refused"))
+	client.Expect(http.StatusOK, http.MethodGet, roots["booking"]+"/healthz", nil)
+	var report bookingmodels.ReadinessReport
+	client.Expect(http.StatusServiceUnavailable, http.MethodGet, roots["booking"]+"/readyz", nil).Decode(t, &report)
+	for _, check := range report.Checks {
+		wantDown := check.Name == "rabbitmq"
+		if (check.Status == bookingmodels.HealthStatusDown) != wantDown {
+			t.Errorf("check %+v, want down only for rabbitmq", check)
+		}
+	}
+
+	// Redis down fails every service's readiness.
+	h.Redis.Close()
+	for service, root := range roots {
+		if resp := client.Do(http.MethodGet, root+"/readyz", nil); resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s /readyz with Redis down = %d, want 503", service, resp.StatusCode)
+		}
+	}
+}
+
+func TestAdminDiagnostics(t *testing.T) {
+	h := Start(t)
+	admin := h.NewClient(t)
+	admin.RegisterAdmin("ops")
+
+	var booking bookingmodels.Diagnostics
+	admin.Expect(http.StatusOK, http.MethodGet, h.BookingURL+"/admin/diagnostics", nil).Decode(t, &booking)
+	if booking.Readiness.Status != bookingmodels.HealthStatusOK || len(booking.Readiness.Checks) != 3 {
+		t.Errorf("booking readiness = %+v, want 3 passing checks", booking.Readiness)
+	}
+	if booking.Build.Version == "" || booking.Build.GoVersion == "" {
+		t.Errorf("booking build = %+v, want version and Go version", booking.Build)
+	}
+	if booking.Database.MaxOpenConnections != 1 || booking.Redis == nil || booking.Leader == nil {
+		t.Errorf("booking diagnostics = %+v, want pool stats and leader status", booking)
+	}
+
+	var payment paymentmodels.Diagnostics
+	admin.Expect(http.StatusOK, http.MethodGet, h.PaymentURL+"/admin/diagnostics", nil).Decode(t, &payment)
+	if payment.Readiness.Status != paymentmodels.HealthStatusOK || payment.Redis == nil {
+		t.Errorf("payment diagnostics = %+v", payment)
+	}
+
+	fan := h.NewClient(t)
+	fan.Register("biasa")
+	fan.Expect(http.StatusForbidden, http.MethodGet, h.BookingURL+"/admin/diagnostics", nil)
+	fan.Expect(http.StatusForbidden, http.MethodGet, h.PaymentURL+"/admin/diagnostics", nil)
+}
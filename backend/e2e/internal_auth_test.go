@@ -0,0 +1,119 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	bookingmodels "backend/booking-service/models"
+	paymentutils "backend/payment-service/utils"
+)
+
+// signedCall is a call to an internal route of the booking service, signed
+// like the payment service signs them.
+type signedCall struct {
+	service, keyID string
+	key            []byte
+	signedAt       time.Time
+	nonce          string
+	// signedBody, if set, is signed instead of the body that is sent.
+	signedBody any
+}
+
+func (c *Client) internal(call signedCall, path string, body any) *Response {
+	c.t.Helper()
+	target := c.h.BookingURL + path
+	u, err := url.Parse(target)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	signed := body
+	if call.signedBody != nil {
+		signed = call.signedBody
+	}
+	payload, err := json.Marshal(signed)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	timestamp := strconv.FormatInt(call.signedAt.Unix(), 10)
+	return c.Do(http.MethodPut, target, body,
+		"X-Service-Name", call.service,
+		"X-Service-Key-ID", call.keyID,
+		"X-Service-Timestamp", timestamp,
+		"X-Service-Nonce", call.nonce,
+		"X-Service-Signature", paymentutils.InternalSignature(call.key, http.MethodPut, u.RequestURI(), timestamp, call.nonce, payload),
+	)
+}
+
+func TestInternalRoutesRequireSignedCalls(t *testing.T) {
+	newKey := bytes.Repeat([]byte{4}, 32)
+	userKey := bytes.Repeat([]byte{5}, 32)
+	h := Start(t, func(c *Configs) {
+		// The payment service is between keys: it still signs with the old one.
+		c.Booking.InternalServiceKeys = "payment-service:new:" + base64.StdEncoding.EncodeToString(newKey) +
+			",payment-service:e2e:" + internalKey +
+			",user-service:u1:" + base64.StdEncoding.EncodeToString(userKey)
+	})
+	oldKey, _ := base64.StdEncoding.DecodeString(internalKey)
+
+	concert := createConcert(t, h)
+	regularID, _ := classSeats(concert, "Regular")
+	fan := h.NewClient(t)
+	fan.Register("budi")
+	booking := fan.book(bookingRequest(concert.ID, regularID, 1, 1))
+	statusPath := "/internal/bookings/" + booking.ID + "/status"
+	cardPath := "/internal/bookings/" + booking.ID + "/payment-card"
+	confirm := bookingmodels.UpdateBookingStatusRequest{Status: bookingmodels.BookingStatusConfirmed, PaymentID: 1}
+	card := bookingmodels.ClaimPaymentCardRequest{CardFingerprint: fmt.Sprintf("%064d", 1)}
+
+	caller := h.NewClient(t)
+	valid := signedCall{service: "payment-service", keyID: "new", key: newKey, signedAt: time.Now(), nonce: "0123456789abcdef0001"}
+
+	// Anyone who can reach the booking service used to be able to confirm a
+	// booking without paying.
+	problem := caller.Expect(http.StatusUnauthorized, http.MethodPut, h.BookingURL+statusPath, confirm).Problem(t)
+	if problem.Code != bookingmodels.CodeUnauthorized {
+		t.Errorf("unsigned call: code %q, want %q", problem.Code, bookingmodels.CodeUnauthorized)
+	}
+
+	refused := map[string]struct {
+		call signedCall
+		want int
+	}{
+		"unknown key":      {signedCall{service: "payment-service", keyID: "gone", key: newKey, signedAt: time.Now(), nonce: "0123456789abcdef0002"}, http.StatusUnauthorized},
+		"wrong key":        {signedCall{service: "payment-service", keyID: "new", key: oldKey, signedAt: time.Now(), nonce: "0123456789abcdef0003"}, http.StatusUnauthorized},
+		"stale timestamp":  {signedCall{service: "payment-service", keyID: "new", key: newKey, signedAt: time.Now().Add(-10 * time.Minute), nonce: "0123456789abcdef0004"}, http.StatusUnauthorized},
+		"tampered body":    {signedCall{service: "payment-service", keyID: "new", key: newKey, signedAt: time.Now(), nonce: "0123456789abcdef0005", signedBody: bookingmodels.UpdateBookingStatusRequest{Status: bookingmodels.BookingStatusFailed}}, http.StatusUnauthorized},
+		"short nonce":      {signedCall{service: "payment-service", keyID: "new", key: newKey, signedAt: time.Now(), nonce: "1"}, http.StatusUnauthorized},
+		"another identity": {signedCall{service: "user-service", keyID: "u1", key: userKey, signedAt: time.Now(), nonce: "0123456789abcdef0006"}, http.StatusForbidden},
+	}
+	for name, tc := range refused {
+		if resp := caller.internal(tc.call, statusPath, confirm); resp.StatusCode != tc.want {
+			t.Errorf("%s: status %d, want %d (body %s)", name, resp.StatusCode, tc.want, resp.Body)
+		}
+	}
+	if got := fan.getBooking(booking.ID).Status; got != bookingmodels.BookingStatusPending {
+		t.Fatalf("booking status after refused calls = %q, want pending", got)
+	}
+
+	// Both keys of the payment service are accepted while it rotates.
+	if resp := caller.internal(valid, cardPath, card); resp.StatusCode != http.StatusOK {
+		t.Fatalf("call signed with the new key: status %d (body %s)", resp.StatusCode, resp.Body)
+	}
+	old := signedCall{service: "payment-service", keyID: "e2e", key: oldKey, signedAt: time.Now(), nonce: "0123456789abcdef0007"}
+	if resp := caller.internal(old, cardPath, card); resp.StatusCode != http.StatusOK {
+		t.Fatalf("call signed with the old key: status %d (body %s)", resp.StatusCode, resp.Body)
+	}
+
+	// A captured call cannot be sent again.
+	problem = caller.internal(valid, cardPath, card).Problem(t)
+	if problem.Status != http.StatusUnauthorized || problem.Code != bookingmodels.CodeUnauthorized {
+		t.Errorf("replayed call: status %d, code %q; want 401, %q", problem.Status, problem.Code, bookingmodels.CodeUnauthorized)
+	}
+}
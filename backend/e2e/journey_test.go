@@ -0,0 +1,315 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	bookingmodels "backend/booking-service/models"
+	paymentmodels "backend/payment-service/models"
+)
+
+// createConcert has an admin create a concert with 2 VIP and 5 regular seats.
+// The seats are created through the in-memory bus before the call returns.
+func createConcert(t *testing.T, h *Harness) bookingmodels.ConcertResponse {
+	t.Helper()
+	admin := h.NewClient(t)
+	admin.RegisterAdmin("promoter")
+
+	resp := admin.Expect(http.StatusCreated, http.MethodPost, h.BookingURL+"/admin/concerts/", bookingmodels.CreateConcertRequest{
+		Name:     "Java Jazz",
+		Artist:   "Various Artists",
+		Date:     time.Now().Add(30 * 24 * time.Hour),
+		Venue:    "JIExpo Kemayoran",
+		ImageUrl: "https://example.com/poster.jpg",
+		TicketClasses: []bookingmodels.CreateTicketClassRequest{
+			{Name: "VIP", Price: 1500000, TotalSeatsInClass: 2},
+			{Name: "Regular", Price: 500000, TotalSeatsInClass: 5},
+		},
+	})
+	var concert bookingmodels.ConcertResponse
+	resp.Decode(t, &concert)
+	return getConcert(t, h, concert.ID)
+}
+
+func getConcert(t *testing.T, h *Harness, id uint) bookingmodels.ConcertResponse {
+	t.Helper()
+	var concert bookingmodels.ConcertResponse
+	h.NewClient(t).Expect(http.StatusOK, http.MethodGet, fmt.Sprintf("%s/concerts/%d", h.BookingURL, id), nil).Decode(t, &concert)
+	return concert
+}
+
+func classSeats(concert bookingmodels.ConcertResponse, name string) (id uint, available int) {
+	for _, tc := range concert.TicketClasses {
+		if tc.Name == name {
+			return tc.ID, tc.AvailableSeatsInClass
+		}
+	}
+	return 0, -1
+}
+
+func bookingRequest(concertID, ticketClassID uint, quantity, ktp int) bookingmodels.CreateBookingRequest {
+	return bookingmodels.CreateBookingRequest{
+		ConcertID:      concertID,
+		TicketsByClass: []bookingmodels.TicketQuantityByClass{{TicketClassID: ticketClassID, Quantity: quantity}},
+		BuyerInfo: bookingmodels.BuyerRequest{
+			FullName:    "Budi Santoso",
+			PhoneNumber: "081234567890",
+			Email:       "budi@example.com",
+			KTPNumber:   KTP(ktp),
+		},
+	}
+}
+
+func (c *Client) book(req bookingmodels.CreateBookingRequest) bookingmodels.BookingResponse {
+	c.t.Helper()
+	var booking bookingmodels.BookingResponse
+	c.Expect(http.StatusCreated, http.MethodPost, c.h.BookingURL+"/bookings/", req).Decode(c.t, &booking)
+	return booking
+}
+
+func (c *Client) getBooking(id string) bookingmodels.BookingResponse {
+	c.t.Helper()
+	var booking bookingmodels.BookingResponse
+	c.Expect(http.StatusOK, http.MethodGet, c.h.BookingURL+"/bookings/"+id, nil).Decode(c.t, &booking)
+	return booking
+}
+
+func TestBookPayAndConfirmJourney(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	if concert.Status != bookingmodels.ConcertStatusActive {
+		t.Fatalf("concert status = %q, want %q", concert.Status, bookingmodels.ConcertStatusActive)
+	}
+	vipID, available := classSeats(concert, "VIP")
+	if available != 2 {
+		t.Fatalf("VIP seats = %d, want 2", available)
+	}
+
+	fan := h.NewClient(t)
+	user := fan.Register("budi")
+
+	var profile struct {
+		Username string `json:"username"`
+	}
+	fan.Expect(http.StatusOK, http.MethodGet, h.UserURL+"/profile", nil).Decode(t, &profile)
+	if profile.Username != "budi" {
+		t.Errorf("profile username = %q, want budi", profile.Username)
+	}
+
+	booking := fan.book(bookingRequest(concert.ID, vipID, 2, 1))
+	if booking.Status != bookingmodels.BookingStatusPending || booking.UserID != user.ID || booking.TotalPrice != 3000000 {
+		t.Fatalf("booking = status %q, user %d, total %v", booking.Status, booking.UserID, booking.TotalPrice)
+	}
+	if _, available := classSeats(getConcert(t, h, concert.ID), "VIP"); available != 0 {
+		t.Errorf("VIP seats after booking = %d, want 0", available)
+	}
+
+	var payment paymentmodels.PaymentResponse
+	fan.Expect(http.StatusOK, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    "12/30",
+		CVV:           "123",
+	}).Decode(t, &payment)
+	if payment.Status != "completed" {
+		t.Fatalf("payment status = %q, want completed", payment.Status)
+	}
+
+	// The payment service reported the result through the internal callback.
+	confirmed := fan.getBooking(booking.ID)
+	if confirmed.Status != bookingmodels.BookingStatusConfirmed {
+		t.Fatalf("booking status after payment = %q, want %q", confirmed.Status, bookingmodels.BookingStatusConfirmed)
+	}
+	if confirmed.PaymentID == nil || *confirmed.PaymentID != payment.ID {
+		t.Errorf("booking payment ID = %v, want %d", confirmed.PaymentID, payment.ID)
+	}
+	for _, seat := range confirmed.BookedSeats {
+		if seat.Status != bookingmodels.SeatStatusBooked {
+			t.Errorf("seat %s status = %q, want %q", seat.SeatNumber, seat.Status, bookingmodels.SeatStatusBooked)
+		}
+	}
+
+	problem := fan.Expect(http.StatusConflict, http.MethodPut, h.BookingURL+"/bookings/"+booking.ID+"/cancel", nil).Problem(t)
+	if problem.Code != bookingmodels.CodeBookingNotCancellable {
+		t.Errorf("cancelling a confirmed booking: code %q, want %q", problem.Code, bookingmodels.CodeBookingNotCancellable)
+	}
+
+	var mine []bookingmodels.BookingResponse
+	fan.Expect(http.StatusOK, http.MethodGet, h.BookingURL+"/bookings/my", nil).Decode(t, &mine)
+	if len(mine) != 1 || mine[0].ID != booking.ID {
+		t.Errorf("my bookings = %+v, want only %s", mine, booking.ID)
+	}
+}
+
+func TestCancelPendingBookingReleasesSeats(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	regularID, _ := classSeats(concert, "Regular")
+
+	fan := h.NewClient(t)
+	fan.Register("siti")
+	booking := fan.book(bookingRequest(concert.ID, regularID, 3, 1))
+	if _, available := classSeats(getConcert(t, h, concert.ID), "Regular"); available != 2 {
+		t.Fatalf("regular seats after booking = %d, want 2", available)
+	}
+
+	other := h.NewClient(t)
+	other.Register("mallory")
+	problem := other.Expect(http.StatusForbidden, http.MethodPut, h.BookingURL+"/bookings/"+booking.ID+"/cancel", nil).Problem(t)
+	if problem.Code != bookingmodels.CodeBookingAccessDenied {
+		t.Errorf("cancelling someone else's booking: code %q, want %q", problem.Code, bookingmodels.CodeBookingAccessDenied)
+	}
+
+	fan.Expect(http.StatusOK, http.MethodPut, h.BookingURL+"/bookings/"+booking.ID+"/cancel", nil)
+	if status := fan.getBooking(booking.ID).Status; status != bookingmodels.BookingStatusCancelled {
+		t.Fatalf("booking status = %q, want %q", status, bookingmodels.BookingStatusCancelled)
+	}
+	if _, available := classSeats(getConcert(t, h, concert.ID), "Regular"); available != 5 {
+		t.Errorf("regular seats after cancellation = %d, want 5", available)
+	}
+
+	// With the first booking cancelled the user may book again.
+	fan.book(bookingRequest(concert.ID, regularID, 5, 2))
+}
+
+func TestExpiredHoldIsReleased(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	vipID, _ := classSeats(concert, "VIP")
+
+	fan := h.NewClient(t)
+	fan.Register("andi")
+	booking := fan.book(bookingRequest(concert.ID, vipID, 1, 1))
+
+	h.Clock.Advance(16 * time.Minute)
+	if err := h.Booking.BookingService.CancelExpiredPendingBookings(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := fan.getBooking(booking.ID).Status; status != bookingmodels.BookingStatusCancelled {
+		t.Fatalf("booking status = %q, want %q", status, bookingmodels.BookingStatusCancelled)
+	}
+	if _, available := classSeats(getConcert(t, h, concert.ID), "VIP"); available != 2 {
+		t.Errorf("VIP seats after expiry = %d, want 2", available)
+	}
+
+	// A payment that arrives after the hold expired cannot confirm the booking.
+	fan.Expect(http.StatusOK, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+	})
+	if status := fan.getBooking(booking.ID).Status; status != bookingmodels.BookingStatusCancelled {
+		t.Errorf("booking status after late payment = %q, want %q", status, bookingmodels.BookingStatusCancelled)
+	}
+}
+
+func TestBookingRequiresSession(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	vipID, _ := classSeats(concert, "VIP")
+
+	anonymous := h.NewClient(t)
+	problem := anonymous.Expect(http.StatusUnauthorized, http.MethodPost, h.BookingURL+"/bookings/", bookingRequest(concert.ID, vipID, 1, 1)).Problem(t)
+	if problem.Code != bookingmodels.CodeUnauthorized {
+		t.Errorf("code = %q, want %q", problem.Code, bookingmodels.CodeUnauthorized)
+	}
+
+	fan := h.NewClient(t)
+	fan.Register("dewi")
+	fan.Expect(http.StatusOK, http.MethodPost, h.UserURL+"/logout", nil)
+	fan.Expect(http.StatusUnauthorized, http.MethodGet, h.BookingURL+"/bookings/my", nil)
+	fan.Expect(http.StatusUnauthorized, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID: "any", Amount: 1, PaymentMethod: "credit_card",
+	})
+}
+
+func TestRetriedBookingIsIdempotent(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	regularID, _ := classSeats(concert, "Regular")
+
+	fan := h.NewClient(t)
+	fan.Register("rina")
+	req := bookingRequest(concert.ID, regularID, 2, 1)
+
+	first := fan.Expect(http.StatusCreated, http.MethodPost, h.BookingURL+"/bookings/", req, "Idempotency-Key", "checkout-1")
+	retry := fan.Expect(http.StatusCreated, http.MethodPost, h.BookingURL+"/bookings/", req, "Idempotency-Key", "checkout-1")
+	if retry.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry was not replayed")
+	}
+	var a, b bookingmodels.BookingResponse
+	first.Decode(t, &a)
+	retry.Decode(t, &b)
+	if a.ID != b.ID {
+		t.Errorf("retry created booking %s, want the original %s", b.ID, a.ID)
+	}
+	if _, available := classSeats(getConcert(t, h, concert.ID), "Regular"); available != 3 {
+		t.Errorf("regular seats = %d, want 3", available)
+	}
+
+	// The same payload with its fields in another order is the same request.
+	var reordered map[string]any
+	payload, _ := json.Marshal(req)
+	if err := json.Unmarshal(payload, &reordered); err != nil {
+		t.Fatal(err)
+	}
+	again := fan.Expect(http.StatusCreated, http.MethodPost, h.BookingURL+"/bookings/", reordered, "Idempotency-Key", "checkout-1")
+	if again.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry with reordered fields was not replayed")
+	}
+
+	req.TicketsByClass[0].Quantity = 1
+	problem := fan.Expect(http.StatusUnprocessableEntity, http.MethodPost, h.BookingURL+"/bookings/", req, "Idempotency-Key", "checkout-1").Problem(t)
+	if problem.Code != bookingmodels.CodeIdempotencyKeyReuse {
+		t.Errorf("code = %q, want %q", problem.Code, bookingmodels.CodeIdempotencyKeyReuse)
+	}
+}
+
+func TestLastSeatsAreNotOversold(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	vipID, _ := classSeats(concert, "VIP")
+
+	const buyers = 6
+	fans := make([]*Client, buyers)
+	for i := range fans {
+		fans[i] = h.NewClient(t)
+		fans[i].Register(fmt.Sprintf("fan%d", i))
+	}
+
+	statuses := make([]int, buyers)
+	var wg sync.WaitGroup
+	for i, fan := range fans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = fan.Do(http.MethodPost, h.BookingURL+"/bookings/", bookingRequest(concert.ID, vipID, 1, i+1)).StatusCode
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, status := range statuses {
+		switch status {
+		case http.StatusCreated:
+			succeeded++
+		case http.StatusConflict:
+		default:
+			t.Errorf("buyer %d got status %d", i, status)
+		}
+	}
+	if succeeded != 2 {
+		t.Errorf("%d buyers got a VIP seat, want exactly 2", succeeded)
+	}
+	if _, available := classSeats(getConcert(t, h, concert.ID), "VIP"); available != 0 {
+		t.Errorf("VIP seats = %d, want 0", available)
+	}
+}
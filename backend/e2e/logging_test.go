@@ -0,0 +1,113 @@
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	bookingutils "backend/booking-service/utils"
+	paymentmodels "backend/payment-service/models"
+	paymentutils "backend/payment-service/utils"
+	userutils "backend/user-service/utils"
+)
+
+// lockedBuffer collects log lines written from many goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// lines decodes the JSON log lines written so far.
+func (b *lockedBuffer) lines(t *testing.T) []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("log line is not JSON: %s", scanner.Text())
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// captureLogs sends the JSON logs of all three services to a buffer until the
+// test ends.
+func captureLogs(t *testing.T) *lockedBuffer {
+	logs := &lockedBuffer{}
+	inits := []func(io.Writer, string, string) error{bookingutils.InitLogger, paymentutils.InitLogger, userutils.InitLogger}
+	for _, init := range inits {
+		if err := init(logs, "info", "json"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		for _, init := range inits {
+			init(os.Stdout, "info", "json")
+		}
+	})
+	return logs
+}
+
+func TestRequestIDFollowsThePaymentIntoTheBookingService(t *testing.T) {
+	logs := captureLogs(t)
+	h := Start(t)
+	concert := createConcert(t, h)
+	regularID, _ := classSeats(concert, "Regular")
+
+	fan := h.NewClient(t)
+	user := fan.Register("made")
+	booking := fan.book(bookingRequest(concert.ID, regularID, 1, 1))
+
+	const requestID = "checkout-7f3a"
+	resp := fan.Expect(http.StatusOK, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    "12/30",
+		CVV:           "123",
+	}, "X-Request-ID", requestID)
+	if got := resp.Header.Get("X-Request-ID"); got != requestID {
+		t.Errorf("payment response X-Request-ID = %q, want %q", got, requestID)
+	}
+	if got := fan.Do(http.MethodGet, h.BookingURL+"/concerts", nil).Header.Get("X-Request-ID"); got == "" {
+		t.Error("request without X-Request-ID got no generated ID")
+	}
+
+	// The payment service's lines carry the user and booking, and the booking
+	// service's lines for the callback carry the same request ID.
+	services := make(map[string]bool)
+	tagged := false
+	for _, line := range logs.lines(t) {
+		if line["request_id"] != requestID {
+			continue
+		}
+		services[line["service"].(string)] = true
+		if line["service"] == paymentutils.ServiceName && line["booking_id"] == booking.ID && line["user_id"] == float64(user.ID) {
+			tagged = true
+		}
+	}
+	if !tagged {
+		t.Errorf("no payment-service log line with user_id %d and booking_id %s", user.ID, booking.ID)
+	}
+	for _, service := range []string{paymentutils.ServiceName, bookingutils.ServiceName} {
+		if !services[service] {
+			t.Errorf("no %s log lines with request ID %s", service, requestID)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	bookingutils "backend/booking-service/utils"
+	paymentmodels "backend/payment-service/models"
+	paymentutils "backend/payment-service/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsCountBookingsAndPayments(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	regularID, _ := classSeats(concert, "Regular")
+
+	created := bookingutils.BookingEventsTotal.WithLabelValues(bookingutils.BookingEventCreated)
+	confirmed := bookingutils.BookingEventsTotal.WithLabelValues(bookingutils.BookingEventConfirmed)
+	cancelled := bookingutils.BookingEventsTotal.WithLabelValues(bookingutils.BookingEventCancelled)
+	paid := paymentutils.PaymentsTotal.WithLabelValues("credit_card", "completed")
+	createdBefore, confirmedBefore, cancelledBefore, paidBefore := testutil.ToFloat64(created), testutil.ToFloat64(confirmed), testutil.ToFloat64(cancelled), testutil.ToFloat64(paid)
+
+	fan := h.NewClient(t)
+	fan.Register("wayan")
+	booking := fan.book(bookingRequest(concert.ID, regularID, 1, 1))
+	fan.Expect(http.StatusOK, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    "12/30",
+		CVV:           "123",
+	})
+	other := h.NewClient(t)
+	other.Register("ketut")
+	dropped := other.book(bookingRequest(concert.ID, regularID, 1, 2))
+	other.Expect(http.StatusOK, http.MethodPut, h.BookingURL+"/bookings/"+dropped.ID+"/cancel", nil)
+
+	for name, c := range map[string]struct{ before, after float64 }{
+		"created":   {createdBefore, testutil.ToFloat64(created)},
+		"confirmed": {confirmedBefore, testutil.ToFloat64(confirmed)},
+		"cancelled": {cancelledBefore, testutil.ToFloat64(cancelled)},
+		"paid":      {paidBefore, testutil.ToFloat64(paid)},
+	} {
+		want := 1.0
+		if name == "created" {
+			want = 2
+		}
+		if got := c.after - c.before; got != want {
+			t.Errorf("%s went up by %v, want %v", name, got, want)
+		}
+	}
+
+	// Each service serves its metrics, with requests labelled by route template.
+	for service, base := range map[string]string{
+		"booking": strings.TrimSuffix(h.BookingURL, "/api/v1"),
+		"payment": strings.TrimSuffix(h.PaymentURL, "/api/v1"),
+		"user":    strings.TrimSuffix(h.UserURL, "/api/v1/users"),
+	} {
+		body := string(fan.Expect(http.StatusOK, http.MethodGet, base+"/metrics", nil).Body)
+		if !strings.Contains(body, service+"_http_request_duration_seconds_count{") {
+			t.Errorf("%s /metrics has no request latency histogram", service)
+		}
+	}
+	body := string(fan.Expect(http.StatusOK, http.MethodGet, strings.TrimSuffix(h.BookingURL, "/api/v1")+"/metrics", nil).Body)
+	if !strings.Contains(body, `route="/api/v1/bookings/:id/cancel"`) {
+		t.Error("booking request latency is not labelled by route template")
+	}
+}
@@ -0,0 +1,159 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+
+	bookingconfig "backend/booking-service/config"
+	bookingdatabase "backend/booking-service/database"
+	bookingmodels "backend/booking-service/models"
+	bookingutils "backend/booking-service/utils"
+)
+
+type storedBuyer struct {
+	PhoneNumber string
+	Email       string
+	KTPNumber   string
+	KTPIndex    *string
+}
+
+func readStoredBuyer(t *testing.T, h *Harness, bookingID string) storedBuyer {
+	t.Helper()
+	var buyer storedBuyer
+	if err := h.BookingDB.Table("buyers").Select("phone_number", "email", "ktp_number", "ktp_index").
+		Where("booking_id = ?", bookingID).Scan(&buyer).Error; err != nil {
+		t.Fatalf("reading buyer of %s: %v", bookingID, err)
+	}
+	return buyer
+}
+
+func TestBuyerPIIIsEncryptedAndMasked(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	regularID, _ := classSeats(concert, "Regular")
+
+	fan := h.NewClient(t)
+	fan.Register("budi")
+	req := bookingRequest(concert.ID, regularID, 1, 1)
+	req.TicketHolderInfo = &bookingmodels.TicketHolderRequest{FullName: "Siti Rahma", KTPNumber: KTP(2)}
+	booking := fan.book(req)
+
+	wantMasked := bookingmodels.BuyerResponse{PhoneNumber: "0812*****890", Email: "b***@example.com", KTPNumber: "3171********0001"}
+	for name, got := range map[string]bookingmodels.BookingResponse{"create": booking, "get": fan.getBooking(booking.ID)} {
+		b := got.BuyerInfo
+		if b == nil || b.PhoneNumber != wantMasked.PhoneNumber || b.Email != wantMasked.Email || b.KTPNumber != wantMasked.KTPNumber {
+			t.Errorf("%s response buyer = %+v, want masked %+v", name, b, wantMasked)
+		}
+		if th := got.TicketHolderInfo; th == nil || th.KTPNumber != "3171********0002" {
+			t.Errorf("%s response ticket holder = %+v, want KTP 3171********0002", name, th)
+		}
+	}
+
+	stored := readStoredBuyer(t, h, booking.ID)
+	for column, value := range map[string]string{"phone_number": stored.PhoneNumber, "email": stored.Email, "ktp_number": stored.KTPNumber} {
+		if !strings.HasPrefix(value, "pii1.e2e.") || strings.Contains(value, "0812") || strings.Contains(value, "budi") || strings.Contains(value, "3171") {
+			t.Errorf("stored %s = %q, want it encrypted", column, value)
+		}
+	}
+	if stored.KTPIndex == nil || len(*stored.KTPIndex) != 64 {
+		t.Errorf("stored ktp_index = %v, want a 64 character hash", stored.KTPIndex)
+	}
+
+	// Admins find the booking by either KTP number and see the details in full.
+	admin := h.NewClient(t)
+	admin.RegisterAdmin("support")
+	for _, ktp := range []string{KTP(1), KTP(2)} {
+		var found []bookingmodels.BookingResponse
+		admin.Expect(http.StatusOK, http.MethodPost, h.BookingURL+"/admin/bookings/lookup", bookingmodels.KTPLookupRequest{KTPNumber: ktp}).Decode(t, &found)
+		if len(found) != 1 || found[0].ID != booking.ID {
+			t.Fatalf("lookup of %s = %+v, want booking %s", ktp, found, booking.ID)
+		}
+		if b := found[0].BuyerInfo; b.PhoneNumber != "081234567890" || b.Email != "budi@example.com" || b.KTPNumber != KTP(1) {
+			t.Errorf("admin sees buyer %+v, want it unmasked", b)
+		}
+	}
+	var none []bookingmodels.BookingResponse
+	admin.Expect(http.StatusOK, http.MethodPost, h.BookingURL+"/admin/bookings/lookup", bookingmodels.KTPLookupRequest{KTPNumber: KTP(3)}).Decode(t, &none)
+	if len(none) != 0 {
+		t.Errorf("lookup of an unknown KTP = %+v, want none", none)
+	}
+	fan.Expect(http.StatusForbidden, http.MethodPost, h.BookingURL+"/admin/bookings/lookup", bookingmodels.KTPLookupRequest{KTPNumber: KTP(1)})
+
+	// A KTP number may be on several bookings, and the lookup finds them all.
+	other := h.NewClient(t)
+	other.Register("andi")
+	second := other.book(bookingRequest(concert.ID, regularID, 1, 1))
+	var both []bookingmodels.BookingResponse
+	admin.Expect(http.StatusOK, http.MethodPost, h.BookingURL+"/admin/bookings/lookup", bookingmodels.KTPLookupRequest{KTPNumber: KTP(1)}).Decode(t, &both)
+	ids := map[string]bool{}
+	for _, b := range both {
+		ids[b.ID] = true
+	}
+	if len(both) != 2 || !ids[booking.ID] || !ids[second.ID] {
+		t.Errorf("lookup of a KTP on two bookings = %+v, want bookings %s and %s", both, booking.ID, second.ID)
+	}
+}
+
+func TestPIIKeyRotation(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	regularID, _ := classSeats(concert, "Regular")
+
+	fan := h.NewClient(t)
+	fan.Register("budi")
+	req := bookingRequest(concert.ID, regularID, 1, 1)
+	req.TicketHolderInfo = &bookingmodels.TicketHolderRequest{FullName: "Siti Rahma", KTPNumber: KTP(2)}
+	booking := fan.book(req)
+
+	// A holder row from before encryption: plaintext and no blind index.
+	if err := h.BookingDB.Exec("UPDATE ticket_holders SET ktp_number = ?, ktp_index = NULL WHERE booking_id = ?", KTP(2), booking.ID).Error; err != nil {
+		t.Fatalf("making the ticket holder legacy plaintext: %v", err)
+	}
+
+	newKey := "new:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, 32))
+	initPII(t, newKey+","+"e2e:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	results, err := bookingdatabase.RotatePIIKeys(context.Background(), h.BookingDB)
+	if err != nil {
+		t.Fatalf("rotating PII keys: %v", err)
+	}
+	for _, r := range results {
+		if r.Scanned != 1 || r.Updated != 1 {
+			t.Errorf("rotation of %s = %+v, want 1 row updated", r.Table, r)
+		}
+	}
+	if stored := readStoredBuyer(t, h, booking.ID); !strings.HasPrefix(stored.KTPNumber, "pii1.new.") {
+		t.Errorf("stored ktp_number after rotation = %q, want it sealed with the new key", stored.KTPNumber)
+	}
+
+	// With the old key gone, everything still decrypts and both KTPs are found.
+	initPII(t, newKey)
+	admin := h.NewClient(t)
+	admin.RegisterAdmin("support")
+	for _, ktp := range []string{KTP(1), KTP(2)} {
+		var found []bookingmodels.BookingResponse
+		admin.Expect(http.StatusOK, http.MethodPost, h.BookingURL+"/admin/bookings/lookup", bookingmodels.KTPLookupRequest{KTPNumber: ktp}).Decode(t, &found)
+		if len(found) != 1 || found[0].BuyerInfo.KTPNumber != KTP(1) || found[0].TicketHolderInfo.KTPNumber != KTP(2) {
+			t.Fatalf("lookup of %s after rotation = %+v", ktp, found)
+		}
+	}
+
+	if results, err := bookingdatabase.RotatePIIKeys(context.Background(), h.BookingDB); err != nil || results[0].Updated != 0 || results[1].Updated != 0 {
+		t.Errorf("second rotation = %+v, %v; want nothing to update", results, err)
+	}
+}
+
+// initPII replaces the booking service's master keys, keeping the blind index
+// key of the harness.
+func initPII(t *testing.T, keys string) {
+	t.Helper()
+	if err := bookingutils.InitPII(&bookingconfig.Config{
+		PIIEncryptionKeys: keys,
+		PIIBlindIndexKey:  base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)),
+	}); err != nil {
+		t.Fatalf("initializing PII keys: %v", err)
+	}
+}
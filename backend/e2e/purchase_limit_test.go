@@ -0,0 +1,125 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	bookingmodels "backend/booking-service/models"
+	paymentmodels "backend/payment-service/models"
+)
+
+func TestPurchaseLimitsAcrossAccounts(t *testing.T) {
+	h := Start(t)
+	admin := h.NewClient(t)
+	admin.RegisterAdmin("promoter")
+
+	var created bookingmodels.ConcertResponse
+	admin.Expect(http.StatusCreated, http.MethodPost, h.BookingURL+"/admin/concerts/", bookingmodels.CreateConcertRequest{
+		Name:           "Coldplay",
+		Artist:         "Coldplay",
+		Date:           time.Now().Add(30 * 24 * time.Hour),
+		Venue:          "GBK",
+		PurchaseLimits: bookingmodels.PurchaseLimits{Phone: 3, Card: 2},
+		ImageUrl:       "https://example.com/poster.jpg",
+		TicketClasses:  []bookingmodels.CreateTicketClassRequest{{Name: "Regular", Price: 1000000, TotalSeatsInClass: 10}},
+	}).Decode(t, &created)
+	concert := getConcert(t, h, created.ID)
+	if concert.PurchaseLimits != (bookingmodels.PurchaseLimits{Phone: 3, Card: 2}) {
+		t.Fatalf("concert purchase limits = %+v", concert.PurchaseLimits)
+	}
+	regularID, _ := classSeats(concert, "Regular")
+
+	// Three accounts of one scalper, all with the same phone number.
+	scalper := h.NewClient(t)
+	scalper.Register("scalper1")
+	first := scalper.book(bookingRequest(concert.ID, regularID, 2, 1))
+
+	second := h.NewClient(t)
+	second.Register("scalper2")
+	problem := second.Expect(http.StatusConflict, http.MethodPost, h.BookingURL+"/bookings/", bookingRequest(concert.ID, regularID, 2, 2)).Problem(t)
+	if problem.Code != bookingmodels.CodePurchaseLimitExceeded {
+		t.Fatalf("booking over the phone limit: code %q, want %q", problem.Code, bookingmodels.CodePurchaseLimitExceeded)
+	}
+	if _, available := classSeats(getConcert(t, h, concert.ID), "Regular"); available != 8 {
+		t.Errorf("regular seats after the refused booking = %d, want 8", available)
+	}
+	secondBooking := second.book(bookingRequest(concert.ID, regularID, 1, 2))
+
+	// The card is checked before it is charged.
+	pay := func(c *Client, booking bookingmodels.BookingResponse, status int) *Response {
+		t.Helper()
+		return c.Expect(status, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+			BookingID:     booking.ID,
+			Amount:        booking.TotalPrice,
+			PaymentMethod: "credit_card",
+			CardNumber:    "4111 1111 1111 1111",
+			ExpiryDate:    "12/30",
+			CVV:           "123",
+		})
+	}
+	pay(scalper, first, http.StatusOK)
+	if problem := pay(second, secondBooking, http.StatusConflict).Problem(t); problem.Code != paymentmodels.CodePurchaseLimitExceeded {
+		t.Errorf("payment over the card limit: code %q, want %q", problem.Code, paymentmodels.CodePurchaseLimitExceeded)
+	}
+	if status := second.getBooking(secondBooking.ID).Status; status != bookingmodels.BookingStatusPending {
+		t.Errorf("booking refused at payment is %q, want it still pending", status)
+	}
+	var payments int64
+	h.PaymentDB.Table("payments").Where("booking_id = ?", secondBooking.ID).Count(&payments)
+	if payments != 0 {
+		t.Errorf("%d payments recorded for the refused card, want none", payments)
+	}
+
+	var clusters []bookingmodels.IdentityCluster
+	admin.Expect(http.StatusOK, http.MethodGet, h.BookingURL+fmt.Sprintf("/admin/bookings/identity-clusters?concert_id=%d", concert.ID), nil).Decode(t, &clusters)
+	if len(clusters) != 1 || len(clusters[0].UserIDs) != 2 || clusters[0].ActiveTickets != 3 {
+		t.Fatalf("identity clusters = %+v, want the two scalper accounts with 3 tickets", clusters)
+	}
+	if shared := clusters[0].SharedIdentifiers; len(shared) != 1 || shared[0].Kinds[0] != bookingmodels.IdentityPhone || len(shared[0].Fingerprint) != 12 {
+		t.Errorf("shared identifiers = %+v, want the phone number", shared)
+	}
+	scalper.Expect(http.StatusForbidden, http.MethodGet, h.BookingURL+"/admin/bookings/identity-clusters", nil)
+}
+
+func TestPurchaseLimitPerKTPAcrossBookings(t *testing.T) {
+	h := Start(t)
+	admin := h.NewClient(t)
+	admin.RegisterAdmin("promoter")
+
+	var created bookingmodels.ConcertResponse
+	admin.Expect(http.StatusCreated, http.MethodPost, h.BookingURL+"/admin/concerts/", bookingmodels.CreateConcertRequest{
+		Name:           "Dewa 19",
+		Artist:         "Dewa 19",
+		Date:           time.Now().Add(30 * 24 * time.Hour),
+		Venue:          "JIS",
+		PurchaseLimits: bookingmodels.PurchaseLimits{BuyerKTP: 3},
+		ImageUrl:       "https://example.com/poster.jpg",
+		TicketClasses:  []bookingmodels.CreateTicketClassRequest{{Name: "Regular", Price: 500000, TotalSeatsInClass: 10}},
+	}).Decode(t, &created)
+	regularID, _ := classSeats(getConcert(t, h, created.ID), "Regular")
+
+	// One buyer KTP, and one ticket holder KTP, on bookings from two accounts.
+	request := func(quantity int) bookingmodels.CreateBookingRequest {
+		req := bookingRequest(created.ID, regularID, quantity, 1)
+		req.TicketHolderInfo = &bookingmodels.TicketHolderRequest{FullName: "Siti Rahma", KTPNumber: KTP(2)}
+		return req
+	}
+	first := h.NewClient(t)
+	first.Register("budi1")
+	first.book(request(1))
+	second := h.NewClient(t)
+	second.Register("budi2")
+	second.book(request(2))
+
+	third := h.NewClient(t)
+	third.Register("budi3")
+	problem := third.Expect(http.StatusConflict, http.MethodPost, h.BookingURL+"/bookings/", request(1)).Problem(t)
+	if problem.Code != bookingmodels.CodePurchaseLimitExceeded {
+		t.Fatalf("booking over the buyer KTP limit: code %q, want %q", problem.Code, bookingmodels.CodePurchaseLimitExceeded)
+	}
+	if _, available := classSeats(getConcert(t, h, created.ID), "Regular"); available != 7 {
+		t.Errorf("regular seats after the refused booking = %d, want 7", available)
+	}
+}
@@ -0,0 +1,134 @@
+package e2e
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	bookingconfig "backend/booking-service/config"
+	bookingmodels "backend/booking-service/models"
+	paymentconfig "backend/payment-service/config"
+	paymentmodels "backend/payment-service/models"
+	userconfig "backend/user-service/config"
+	usermodels "backend/user-service/models"
+)
+
+func TestRateLimits(t *testing.T) {
+	h := Start(t, func(c *Configs) {
+		c.User.RateLimitLogin = userconfig.RateLimit{Requests: 5, Per: time.Minute}
+		c.Booking.RateLimitBooking = bookingconfig.RateLimit{Requests: 2, Per: time.Minute}
+		c.Payment.RateLimitPayment = paymentconfig.RateLimit{Requests: 1, Per: time.Minute}
+		// The test servers listen on loopback, so that is where a proxy in
+		// front of the booking service would connect from.
+		c.Booking.TrustedProxies = []string{"127.0.0.1"}
+	})
+
+	// Registering and logging in spend the five login tokens of the address.
+	other := h.NewClient(t)
+	other.Register("siti")
+	fan := h.NewClient(t)
+	fan.Register("budi")
+	login := usermodels.UserLoginRequest{Username: "budi", Password: "secret-budi"}
+	resp := fan.Expect(http.StatusOK, http.MethodPost, h.UserURL+"/login", login)
+	if got := resp.Header.Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining after the last login token = %q, want 0", got)
+	}
+	resp = fan.Expect(http.StatusTooManyRequests, http.MethodPost, h.UserURL+"/login", login)
+	if code := resp.Problem(t).Code; code != usermodels.CodeRateLimited {
+		t.Errorf("login over the limit: code %q, want %q", code, usermodels.CodeRateLimited)
+	}
+	if got := resp.Header.Get("RateLimit-Limit"); got != "5" {
+		t.Errorf("RateLimit-Limit = %q, want 5", got)
+	}
+	// One token comes back every 12 seconds.
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err != nil || retryAfter < 1 || retryAfter > 12 {
+		t.Errorf("Retry-After = %q, want 1 to 12 seconds", resp.Header.Get("Retry-After"))
+	}
+	// The user service trusts no proxies, so a forged X-Forwarded-For does
+	// not buy a fresh bucket.
+	fan.Expect(http.StatusTooManyRequests, http.MethodPost, h.UserURL+"/login", login, "X-Forwarded-For", "203.0.113.7")
+
+	// Booking creation is limited per user and per address, behind a trusted
+	// proxy that names the client.
+	for i := 0; i < 2; i++ {
+		fan.Expect(http.StatusBadRequest, http.MethodPost, h.BookingURL+"/bookings/", bookingmodels.CreateBookingRequest{}, "X-Forwarded-For", "203.0.113.7")
+	}
+	problem := fan.Expect(http.StatusTooManyRequests, http.MethodPost, h.BookingURL+"/bookings/", bookingmodels.CreateBookingRequest{}, "X-Forwarded-For", "203.0.113.8").Problem(t)
+	if problem.Code != bookingmodels.CodeRateLimited {
+		t.Errorf("booking over the user limit from a new address: code %q, want %q", problem.Code, bookingmodels.CodeRateLimited)
+	}
+	fan.Expect(http.StatusOK, http.MethodGet, h.BookingURL+"/bookings/my", nil)
+
+	problem = other.Expect(http.StatusTooManyRequests, http.MethodPost, h.BookingURL+"/bookings/", bookingmodels.CreateBookingRequest{}, "X-Forwarded-For", "203.0.113.7").Problem(t)
+	if problem.Code != bookingmodels.CodeRateLimited {
+		t.Errorf("booking over the address limit as a new user: code %q, want %q", problem.Code, bookingmodels.CodeRateLimited)
+	}
+	other.Expect(http.StatusBadRequest, http.MethodPost, h.BookingURL+"/bookings/", bookingmodels.CreateBookingRequest{}, "X-Forwarded-For", "203.0.113.9")
+
+	fan.Expect(http.StatusBadRequest, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{})
+	problem = fan.Expect(http.StatusTooManyRequests, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{}).Problem(t)
+	if problem.Code != paymentmodels.CodeRateLimited {
+		t.Errorf("payment over the limit: code %q, want %q", problem.Code, paymentmodels.CodeRateLimited)
+	}
+}
+
+func TestInternalCallsSkipDefaultRateLimit(t *testing.T) {
+	h := Start(t, func(c *Configs) {
+		c.Booking.RateLimitDefault = bookingconfig.RateLimit{Requests: 3, Per: time.Minute}
+	})
+	// Creating and reading the concert and booking spend the three tokens of
+	// the address, which the payment service's calls come from too.
+	concert := createConcert(t, h)
+	vipID, _ := classSeats(concert, "VIP")
+	fan := h.NewClient(t)
+	fan.Register("budi")
+	booking := fan.book(bookingRequest(concert.ID, vipID, 1, 1))
+
+	// Paying claims the card and confirms the booking, both internal calls.
+	fan.Expect(http.StatusOK, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    "12/30",
+		CVV:           "123",
+	})
+	var status string
+	if err := h.BookingDB.Table("bookings").Select("status").Where("id = ?", booking.ID).Scan(&status).Error; err != nil {
+		t.Fatal(err)
+	}
+	if status != bookingmodels.BookingStatusConfirmed {
+		t.Errorf("booking paid with the address out of tokens is %q, want %q", status, bookingmodels.BookingStatusConfirmed)
+	}
+	fan.Expect(http.StatusTooManyRequests, http.MethodGet, h.BookingURL+"/concerts", nil)
+}
+
+func TestRateLimiterFailureModes(t *testing.T) {
+	h := Start(t, func(c *Configs) {
+		c.User.RateLimitDefault = userconfig.RateLimit{Requests: 100, Per: time.Minute}
+		c.User.RateLimitFailOpen = true
+		c.Booking.RateLimitDefault = bookingconfig.RateLimit{Requests: 100, Per: time.Minute}
+		c.Booking.RateLimitFailOpen = false
+	})
+	fan := h.NewClient(t)
+	fan.Register("budi")
+	resp := fan.Expect(http.StatusOK, http.MethodGet, h.BookingURL+"/concerts", nil)
+	if got := resp.Header.Get("RateLimit-Remaining"); got != "99" {
+		t.Errorf("RateLimit-Remaining = %q, want 99", got)
+	}
+
+	h.Redis.Close()
+
+	// The user service fails open, the booking service closed.
+	fan.Expect(http.StatusOK, http.MethodPost, h.UserURL+"/login", usermodels.UserLoginRequest{Username: "budi", Password: "secret-budi"})
+	resp = fan.Expect(http.StatusServiceUnavailable, http.MethodGet, h.BookingURL+"/concerts", nil)
+	if code := resp.Problem(t).Code; code != bookingmodels.CodeRateLimiterDown {
+		t.Errorf("booking service without Redis: code %q, want %q", code, bookingmodels.CodeRateLimiterDown)
+	}
+	if got := resp.Header.Get("Retry-After"); got == "" {
+		t.Error("booking service without Redis: no Retry-After header")
+	}
+	fan.Expect(http.StatusOK, http.MethodGet, strings.TrimSuffix(h.BookingURL, "/api/v1")+"/healthz", nil)
+}
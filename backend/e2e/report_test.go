@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	bookingmodels "backend/booking-service/models"
+	paymentmodels "backend/payment-service/models"
+)
+
+func TestSalesReportSeparatesExpiredFromCancelled(t *testing.T) {
+	h := Start(t)
+	concert := createConcert(t, h)
+	vipID, _ := classSeats(concert, "VIP")
+	regularID, _ := classSeats(concert, "Regular")
+
+	paid := h.NewClient(t)
+	paid.Register("budi")
+	sold := paid.book(bookingRequest(concert.ID, vipID, 2, 1))
+	paid.Expect(http.StatusOK, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID:     sold.ID,
+		Amount:        sold.TotalPrice,
+		PaymentMethod: "credit_card",
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    "12/30",
+		CVV:           "123",
+	})
+
+	quitter := h.NewClient(t)
+	quitter.Register("siti")
+	cancelled := quitter.book(bookingRequest(concert.ID, regularID, 1, 2))
+	sleeper := h.NewClient(t)
+	sleeper.Register("andi")
+	expired := sleeper.book(bookingRequest(concert.ID, regularID, 1, 3))
+
+	// Both holds have run out. One user cancels before the expiry job gets to
+	// their booking, which still counts as a cancellation.
+	h.Clock.Advance(16 * time.Minute)
+	quitter.Expect(http.StatusOK, http.MethodPut, h.BookingURL+"/bookings/"+cancelled.ID+"/cancel", nil)
+	if err := h.Booking.BookingService.CancelExpiredPendingBookings(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := sleeper.getBooking(expired.ID); got.CancellationReason == nil || *got.CancellationReason != bookingmodels.CancellationReasonHoldExpired {
+		t.Fatalf("expired booking reason = %v, want %q", got.CancellationReason, bookingmodels.CancellationReasonHoldExpired)
+	}
+
+	waiting := h.NewClient(t)
+	waiting.Register("dewi")
+	waiting.book(bookingRequest(concert.ID, regularID, 1, 4))
+
+	admin := h.NewClient(t)
+	admin.RegisterAdmin("auditor")
+	var report bookingmodels.SalesReportResponse
+	admin.Expect(http.StatusOK, http.MethodGet, fmt.Sprintf("%s/admin/reports/sales?group_by=concert&concert_id=%d", h.BookingURL, concert.ID), nil).Decode(t, &report)
+
+	if len(report.Rows) != 1 {
+		t.Fatalf("report rows = %+v, want one for concert %d", report.Rows, concert.ID)
+	}
+	row := report.Rows[0]
+	want := bookingmodels.SalesReportRow{
+		ConcertID:         concert.ID,
+		ConcertName:       concert.Name,
+		TotalBookings:     4,
+		ConfirmedBookings: 1,
+		PendingBookings:   1,
+		CancelledBookings: 1,
+		ExpiredBookings:   1,
+		TicketsSold:       2,
+		Revenue:           3000000,
+		AverageOrderValue: 3000000,
+	}
+	if row != want {
+		t.Errorf("report row = %+v, want %+v", row, want)
+	}
+	if report.Totals.CancelledBookings != 1 || report.Totals.ExpiredBookings != 1 {
+		t.Errorf("report totals = %+v, want one cancelled and one expired booking", report.Totals)
+	}
+}
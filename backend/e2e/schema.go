@@ -0,0 +1,142 @@
+package e2e
+
+// SQLite versions of the tables created by the migrations in each service's
+// database/migrations, one database per service as in docker-compose. Keep
+// them in step with the migrations.
+
+var userSchema = []string{
+	`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		username VARCHAR(255) NOT NULL UNIQUE,
+		email VARCHAR(255) NOT NULL UNIQUE,
+		password VARCHAR(255) NOT NULL,
+		role VARCHAR(255) DEFAULT 'user',
+		last_login DATETIME
+	)`,
+}
+
+var bookingSchema = []string{
+	`CREATE TABLE concerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		name VARCHAR(255) NOT NULL,
+		artist VARCHAR(255) NOT NULL,
+		date DATETIME NOT NULL,
+		venue VARCHAR(255) NOT NULL,
+		minimum_age INTEGER NOT NULL DEFAULT 0,
+		max_tickets_per_buyer_ktp INTEGER NOT NULL DEFAULT 0,
+		max_tickets_per_holder_ktp INTEGER NOT NULL DEFAULT 0,
+		max_tickets_per_phone INTEGER NOT NULL DEFAULT 0,
+		max_tickets_per_card INTEGER NOT NULL DEFAULT 0,
+		total_seats INTEGER NOT NULL,
+		available_seats INTEGER NOT NULL,
+		description TEXT,
+		status VARCHAR(255) DEFAULT 'pending_seat_creation',
+		image_url VARCHAR(255)
+	)`,
+	`CREATE TABLE ticket_classes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		concert_id INTEGER NOT NULL REFERENCES concerts (id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		price DECIMAL(10,2) NOT NULL,
+		total_seats_in_class INTEGER NOT NULL,
+		available_seats_in_class INTEGER NOT NULL,
+		UNIQUE (concert_id, name)
+	)`,
+	`CREATE TABLE seats (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		concert_id INTEGER NOT NULL REFERENCES concerts (id) ON DELETE CASCADE,
+		ticket_class_id INTEGER NOT NULL REFERENCES ticket_classes (id) ON DELETE CASCADE,
+		seat_number VARCHAR(255) NOT NULL,
+		status VARCHAR(255) NOT NULL DEFAULT 'available',
+		user_id INTEGER,
+		booking_id VARCHAR(36),
+		UNIQUE (concert_id, ticket_class_id, seat_number)
+	)`,
+	`CREATE TABLE bookings (
+		id VARCHAR(36) PRIMARY KEY,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		user_id INTEGER NOT NULL,
+		concert_id INTEGER NOT NULL REFERENCES concerts (id) ON DELETE CASCADE,
+		seat_ids TEXT NOT NULL,
+		total_price DECIMAL(10,2) NOT NULL,
+		status VARCHAR(255) NOT NULL DEFAULT 'pending',
+		cancellation_reason VARCHAR(32),
+		payment_id INTEGER,
+		expires_at DATETIME,
+		checked_in_at DATETIME
+	)`,
+	`CREATE TABLE booking_seats (
+		booking_id VARCHAR(36) NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+		seat_id INTEGER NOT NULL REFERENCES seats (id) ON DELETE CASCADE,
+		PRIMARY KEY (booking_id, seat_id)
+	)`,
+	`CREATE TABLE buyers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		booking_id VARCHAR(36) NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+		full_name VARCHAR(255) NOT NULL,
+		phone_number VARCHAR(1024) NOT NULL,
+		email VARCHAR(1024) NOT NULL,
+		ktp_number VARCHAR(1024) NOT NULL,
+		ktp_index CHAR(64)
+	)`,
+	`CREATE TABLE ticket_holders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		booking_id VARCHAR(36) NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+		full_name VARCHAR(255) NOT NULL,
+		ktp_number VARCHAR(1024) NOT NULL,
+		ktp_index CHAR(64)
+	)`,
+	`CREATE TABLE booking_identities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		booking_id VARCHAR(36) NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+		concert_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		kind VARCHAR(32) NOT NULL,
+		value_index CHAR(64) NOT NULL,
+		tickets INTEGER NOT NULL,
+		released_at DATETIME
+	)`,
+	`CREATE TABLE purchase_limit_counters (
+		concert_id INTEGER NOT NULL REFERENCES concerts (id) ON DELETE CASCADE,
+		kind VARCHAR(32) NOT NULL,
+		value_index CHAR(64) NOT NULL,
+		tickets INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (concert_id, kind, value_index)
+	)`,
+}
+
+var paymentSchema = []string{
+	`CREATE TABLE payments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		booking_id VARCHAR(36) NOT NULL,
+		amount DECIMAL(10,2) NOT NULL,
+		payment_method VARCHAR(255) NOT NULL,
+		transaction_id VARCHAR(255) UNIQUE,
+		status VARCHAR(255) NOT NULL DEFAULT 'pending',
+		payment_gateway_response TEXT
+	)`,
+}
@@ -0,0 +1,90 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	paymentmodels "backend/payment-service/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider that keeps every span in memory until
+// the test ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestPaymentCallbackContinuesTheCallersTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	h := Start(t)
+	concert := createConcert(t, h)
+	regularID, _ := classSeats(concert, "Regular")
+
+	fan := h.NewClient(t)
+	fan.Register("sari")
+	booking := fan.book(bookingRequest(concert.ID, regularID, 1, 1))
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	fan.Expect(http.StatusOK, http.MethodPost, h.PaymentURL+"/payments/", paymentmodels.ProcessPaymentRequest{
+		BookingID:     booking.ID,
+		Amount:        booking.TotalPrice,
+		PaymentMethod: "credit_card",
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    "12/30",
+		CVV:           "123",
+	}, "traceparent", traceparent)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	clientCalls, queries := 0, 0
+	routes := make(map[string]bool)
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID() != traceID {
+			continue
+		}
+		query := false
+		for _, attr := range span.Attributes() {
+			switch attr.Key {
+			case "http.route":
+				routes[attr.Value.AsString()] = true
+			case "db.system":
+				query = true
+			}
+		}
+		switch {
+		case query:
+			queries++
+		case span.SpanKind() == trace.SpanKindClient:
+			clientCalls++
+		}
+	}
+
+	// The payment handler, its call to the booking service and the booking
+	// service's handler of that call all belong to the caller's trace.
+	for _, route := range []string{"/api/v1/payments/", "/api/v1/internal/bookings/:id/status"} {
+		if !routes[route] {
+			t.Errorf("no server span for %s in the trace; routes seen: %v", route, routes)
+		}
+	}
+	if clientCalls == 0 {
+		t.Error("no client span for the call from the payment service to the booking service")
+	}
+	if queries == 0 {
+		t.Error("no database spans in the trace")
+	}
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +23,20 @@ type Config struct {
 	RedisDB              int
 	ServicePort          string
 	BookingServiceAPIURL string
+	CardFingerprintKey   string
+	IdempotencyKeyTTL    time.Duration
+	TracingExporter      string
+	TracingFile          string
+	TracingSampleRatio   float64
+	LogLevel             string
+	LogFormat            string
+	MigrateOnStart       bool
+	ShutdownTimeout      time.Duration
+	RateLimitDefault     RateLimit
+	RateLimitPayment     RateLimit
+	RateLimitFailOpen    bool
+	TrustedProxies       []string
+	InternalSigningKey   string
 }
 
 func LoadConfig() *Config {
@@ -46,12 +63,92 @@ func LoadConfig() *Config {
 		RedisDB:              redisDB,
 		ServicePort:          getEnv("PORT", "8082"),
 		BookingServiceAPIURL: getEnv("BOOKING_SERVICE_API_URL", "http://localhost:8081/api/v1"),
+		CardFingerprintKey:   getEnv("CARD_FINGERPRINT_KEY", ""),
+		IdempotencyKeyTTL:    getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		TracingExporter:      getEnv("TRACING_EXPORTER", "none"),
+		TracingFile:          getEnv("TRACING_FILE", "traces.jsonl"),
+		TracingSampleRatio:   getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		LogLevel:             getEnv("LOG_LEVEL", "info"),
+		LogFormat:            getEnv("LOG_FORMAT", "json"),
+		MigrateOnStart:       getEnvBool("MIGRATE_ON_START", true),
+		ShutdownTimeout:      getEnvDuration("SHUTDOWN_TIMEOUT", 25*time.Second),
+		RateLimitDefault:     getEnvRateLimit("RATE_LIMIT_DEFAULT", RateLimit{Requests: 200, Per: time.Minute}),
+		RateLimitPayment:     getEnvRateLimit("RATE_LIMIT_PAYMENT", RateLimit{Requests: 10, Per: time.Minute}),
+		RateLimitFailOpen:    getEnvBool("RATE_LIMIT_FAIL_OPEN", true),
+		TrustedProxies:       getEnvList("TRUSTED_PROXIES"),
+		InternalSigningKey:   getEnv("INTERNAL_SIGNING_KEY", ""),
 	}
 }
 
+// getEnvList reads a comma-separated list, leaving out empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, defaultValue.String()))
+	if err != nil {
+		log.Printf("Invalid %s value, defaulting to %s: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, strconv.FormatFloat(defaultValue, 'f', -1, 64)), 64)
+	if err != nil {
+		log.Printf("Invalid %s value, defaulting to %g: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, strconv.FormatBool(defaultValue)))
+	if err != nil {
+		log.Printf("Invalid %s value, defaulting to %t: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return value
+}
+
+// RateLimit is a token bucket that holds Requests tokens and refills them
+// evenly over Per, so a client may send a burst of Requests requests and then
+// Requests per Per. A RateLimit of zero Requests does not limit.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// getEnvRateLimit reads a rate limit written as requests/period, e.g. 10/1m.
+func getEnvRateLimit(key string, defaultValue RateLimit) RateLimit {
+	value := getEnv(key, defaultValue.String())
+	requests, per, _ := strings.Cut(value, "/")
+	limit := RateLimit{}
+	var err error
+	if limit.Requests, err = strconv.Atoi(requests); err == nil {
+		limit.Per, err = time.ParseDuration(per)
+	}
+	if err != nil || limit.Requests < 0 || limit.Per <= 0 {
+		log.Printf("Invalid %s value %q, defaulting to %s: want requests/period, e.g. 10/1m", key, value, defaultValue)
+		return defaultValue
+	}
+	return limit
+}
@@ -3,7 +3,6 @@ package controllers
 import (
 	"net/http"
 	"strconv"
-	"strings"
 
 	"backend/payment-service/models"
 	"backend/payment-service/services"
@@ -32,39 +31,42 @@ func NewPaymentController(ps *services.PaymentService) *PaymentController {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param payment body models.ProcessPaymentRequest true "Payment request data"
+// @Param Idempotency-Key header string false "Makes retries safe: a repeated request with the same key returns the original response"
 // @Success 200 {object} models.PaymentResponse
-// @Failure 400 {object} map[string]string "Bad Request - Invalid input or validation errors"
-// @Failure 401 {object} map[string]string "Unauthorized - Missing or invalid token"
-// @Failure 500 {object} map[string]string "Internal Server Error - Failed to process payment"
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid input or validation errors"
+// @Failure 401 {object} models.ProblemDetails "Unauthorized - Missing or invalid token"
+// @Failure 409 {object} models.ProblemDetails "Conflict - Card over the purchase limit, booking not payable, or a request with the same Idempotency-Key still being processed"
+// @Failure 422 {object} models.ProblemDetails "Unprocessable Entity - Idempotency-Key reused with a different payload"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to process payment"
 // @Router /payments [post]
 func (ctrl *PaymentController) ProcessPayment(c *gin.Context) {
 
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.LogWarning("User ID not found in context for payment processing. Assuming internal call or direct frontend call without prior booking check.")
+		utils.LogWarningContext(c.Request.Context(), "User ID not found in context for payment processing. Assuming internal call or direct frontend call without prior booking check.")
 
 	} else {
-		utils.LogInfo("Processing payment initiated by user ID: %d", userID.(uint))
+		utils.LogInfoContext(c.Request.Context(), "Processing payment initiated by user ID: %d", userID.(uint))
 	}
 
 	var req models.ProcessPaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogError("Invalid JSON body for process payment: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		utils.LogErrorContext(c.Request.Context(), "Invalid JSON body for process payment: %v", err)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body")
 		return
 	}
 
 	if err := ctrl.Validate.Struct(req); err != nil {
 		validationErrors := err.(validator.ValidationErrors)
-		utils.LogError("Validation error for process payment: %v", validationErrors)
-		c.JSON(http.StatusBadRequest, gin.H{"error": utils.FormatValidationErrors(validationErrors)})
+		utils.LogErrorContext(c.Request.Context(), "Validation error for process payment: %v", validationErrors)
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeValidationFailed, utils.FormatValidationErrors(validationErrors))
 		return
 	}
 
 	resp, err := ctrl.PaymentService.ProcessPayment(c.Request.Context(), &req)
 	if err != nil {
-		utils.LogError("Failed to process payment for booking %d: %v", req.BookingID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment: " + err.Error()})
+		utils.LogErrorContext(c.Request.Context(), "Failed to process payment for booking %s: %v", req.BookingID, err)
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -77,38 +79,30 @@ func (ctrl *PaymentController) ProcessPayment(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Param id path int true "Payment ID"
 // @Success 200 {object} models.PaymentResponse
-// @Failure 400 {object} map[string]string "Bad Request - Invalid payment ID"
-// @Failure 401 {object} map[string]string "Unauthorized - Missing or invalid token"
-// @Failure 403 {object} map[string]string "Forbidden - Not authorized to view this payment"
-// @Failure 404 {object} map[string]string "Not Found - Payment not found"
-// @Failure 500 {object} map[string]string "Internal Server Error - Failed to retrieve payment"
+// @Failure 400 {object} models.ProblemDetails "Bad Request - Invalid payment ID"
+// @Failure 401 {object} models.ProblemDetails "Unauthorized - Missing or invalid token"
+// @Failure 403 {object} models.ProblemDetails "Forbidden - Not authorized to view this payment"
+// @Failure 404 {object} models.ProblemDetails "Not Found - Payment not found"
+// @Failure 500 {object} models.ProblemDetails "Internal Server Error - Failed to retrieve payment"
 // @Router /payments/{id} [get]
 func (ctrl *PaymentController) GetPaymentByID(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.LogError("User ID not found in context for get payment by ID")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user ID from token"})
+		utils.LogErrorContext(c.Request.Context(), "User ID not found in context for get payment by ID")
+		utils.AbortWithProblem(c, http.StatusInternalServerError, models.CodeInternalError, "Failed to get user ID from token")
 		return
 	}
 
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID format"})
+		utils.AbortWithProblem(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid payment ID format")
 		return
 	}
 
 	resp, err := ctrl.PaymentService.GetPaymentDetails(c.Request.Context(), uint(id), userID.(uint))
 	if err != nil {
-		utils.LogError("Failed to get payment ID %d for user %d: %v", id, userID, err)
-		if err.Error() == "payment not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "unauthorized") {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve payment: " + err.Error()})
+		utils.LogErrorContext(c.Request.Context(), "Failed to get payment ID %d for user %d: %v", id, userID, err)
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
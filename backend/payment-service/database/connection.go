@@ -6,10 +6,11 @@ import (
 	"time"
 
 	"backend/payment-service/config"
+	"backend/payment-service/utils"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 var DB *gorm.DB
@@ -30,7 +31,7 @@ func ConnectDB(cfg *config.Config) {
 
 	for counts <= maxRetries {
 		DB, dbErr = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info),
+			Logger: queryLogger{},
 		})
 		if dbErr != nil {
 			log.Printf("Attempt %d/%d: Failed to connect to database: %v. Retrying in %s...", counts, maxRetries, dbErr, retryDelay)
@@ -47,6 +48,10 @@ func ConnectDB(cfg *config.Config) {
 		log.Fatalf("Failed to connect to database after %d retries: %v", maxRetries, dbErr)
 	}
 
+	if err := DB.Use(tracing.NewPlugin(tracing.WithoutMetrics())); err != nil {
+		log.Fatalf("Failed to enable query tracing: %v", err)
+	}
+
 	sqlDB, err := DB.DB()
 	if err != nil {
 		log.Fatalf("Failed to get generic database object: %v", err)
@@ -56,7 +61,25 @@ func ConnectDB(cfg *config.Config) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
 
+	if err := utils.RegisterDBStats(sqlDB, cfg.DBName); err != nil {
+		utils.LogWarning("Failed to export database pool metrics: %v", err)
+	}
+
 	log.Println("Database connected successfully!")
+}
 
-	log.Println("Database migrations completed!")
+// CloseDB closes the connection pool once the service has stopped using it.
+func CloseDB() {
+	if DB == nil {
+		return
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		utils.LogWarning("Failed to close database connections: %v", err)
+		return
+	}
+	utils.LogInfo("Database connections closed.")
 }
@@ -1,21 +1,20 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"backend/payment-service/config"
-	"backend/payment-service/controllers"
 	"backend/payment-service/database"
 	"backend/payment-service/middlewares"
-	"backend/payment-service/repositories"
-	"backend/payment-service/services"
+	"backend/payment-service/server"
 	"backend/payment-service/utils"
 
 	_ "backend/payment-service/docs"
-
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // @title Concert Ticket Booking System - Payment Service API
@@ -38,41 +37,53 @@ import (
 // @description "Type 'Bearer YOUR_TOKEN' to authenticate. Example: 'Bearer eyJhbGciOiJIUzI1Ni...'"
 func main() {
 	cfg := config.LoadConfig()
+	if err := utils.InitLogger(os.Stdout, cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
+	}
 	utils.InitJWT(cfg)
-	database.ConnectDB(cfg)
-	middlewares.InitRedis(cfg)
-
-	paymentRepo := repositories.NewPaymentRepository(database.DB)
-
-	bookingServiceAPIURL := cfg.BookingServiceAPIURL
-
-	paymentService := services.NewPaymentService(paymentRepo, bookingServiceAPIURL)
-
-	paymentController := controllers.NewPaymentController(paymentService)
-
-	router := gin.Default()
-	router.Use(middlewares.CORSMiddleware())
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-
-	router.Use(middlewares.RateLimitMiddleware(200, 1*time.Minute))
 
-	v1 := router.Group("/api/v1")
-	{
-		payments := v1.Group("/payments")
-
-		payments.Use(middlewares.AuthMiddleware())
-		{
-			payments.POST("/", paymentController.ProcessPayment)
-			payments.GET("/:id", paymentController.GetPaymentByID)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+	if err := utils.InitInternalAuth(cfg); err != nil {
+		log.Fatalf("Failed to load internal signing key: %v", err)
+	}
+	if err := utils.CheckCardFingerprintKey(cfg.CardFingerprintKey); err != nil {
+		log.Fatalf("Failed to load card fingerprint key: %v", err)
+	}
 
+	shutdownTracing, err := utils.InitTracing(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			utils.LogWarning("Failed to flush traces: %v", err)
+		}
+	}()
+	database.ConnectDB(cfg)
+	defer database.CloseDB()
+	if cfg.MigrateOnStart {
+		if err := database.Migrate(context.Background(), database.DB); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
 		}
 	}
+	middlewares.InitRedis(cfg)
+	defer middlewares.CloseRedis()
+
+	srv := server.New(cfg, database.DB)
 
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	utils.LogInfo("Payment Service running on port %s", cfg.ServicePort)
-	if err := router.Run(":" + cfg.ServicePort); err != nil {
-		utils.LogError("Failed to start payment service: %v", err)
+	if err := srv.ListenAndServe(ctx, ":"+cfg.ServicePort, cfg.ShutdownTimeout); err != nil {
+		utils.LogError("Payment Service HTTP server failed: %v", err)
 	}
+	utils.LogInfo("Payment Service stopped.")
 }
@@ -54,3 +54,9 @@ type UpdateBookingStatusInternalRequest struct {
 	Status    string `json:"status" validate:"required"`
 	PaymentID uint   `json:"payment_id" validate:"required"`
 }
+
+// ClaimPaymentCardInternalRequest asks the booking service to count a booking
+// against the card it is about to be charged to.
+type ClaimPaymentCardInternalRequest struct {
+	CardFingerprint string `json:"card_fingerprint"`
+}
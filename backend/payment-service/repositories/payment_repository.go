@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+
 	"backend/payment-service/models"
 
 	"gorm.io/gorm"
@@ -14,22 +16,22 @@ func NewPaymentRepository(db *gorm.DB) *PaymentRepository {
 	return &PaymentRepository{DB: db}
 }
 
-func (r *PaymentRepository) CreatePayment(payment *models.Payment) error {
-	return r.DB.Create(payment).Error
+func (r *PaymentRepository) CreatePayment(ctx context.Context, payment *models.Payment) error {
+	return r.DB.WithContext(ctx).Create(payment).Error
 }
 
-func (r *PaymentRepository) GetPaymentByID(id uint) (*models.Payment, error) {
+func (r *PaymentRepository) GetPaymentByID(ctx context.Context, id uint) (*models.Payment, error) {
 	var payment models.Payment
-	err := r.DB.First(&payment, id).Error
+	err := r.DB.WithContext(ctx).First(&payment, id).Error
 	return &payment, err
 }
 
-func (r *PaymentRepository) GetPaymentByBookingID(bookingID uint) (*models.Payment, error) {
+func (r *PaymentRepository) GetPaymentByBookingID(ctx context.Context, bookingID uint) (*models.Payment, error) {
 	var payment models.Payment
-	err := r.DB.Where("booking_id = ?", bookingID).First(&payment).Error
+	err := r.DB.WithContext(ctx).Where("booking_id = ?", bookingID).First(&payment).Error
 	return &payment, err
 }
 
-func (r *PaymentRepository) UpdatePayment(payment *models.Payment) error {
-	return r.DB.Save(payment).Error
+func (r *PaymentRepository) UpdatePayment(ctx context.Context, payment *models.Payment) error {
+	return r.DB.WithContext(ctx).Save(payment).Error
 }
@@ -0,0 +1,130 @@
+// Package server wires the payment service's repositories, services and
+// controllers into a Gin router. main connects it to MySQL and Redis; the
+// end-to-end tests run it on in-memory stand-ins.
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend/payment-service/config"
+	"backend/payment-service/controllers"
+	"backend/payment-service/middlewares"
+	"backend/payment-service/repositories"
+	"backend/payment-service/services"
+	"backend/payment-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"gorm.io/gorm"
+)
+
+type Server struct {
+	Router         *gin.Engine
+	PaymentService *services.PaymentService
+}
+
+// New builds the service on db. The rate limiting and idempotency middlewares
+// use middlewares.RedisClient, which must be initialised first.
+func New(cfg *config.Config, db *gorm.DB) *Server {
+	paymentRepo := repositories.NewPaymentRepository(db)
+
+	bookingServiceAPIURL := cfg.BookingServiceAPIURL
+
+	paymentService := services.NewPaymentService(paymentRepo, bookingServiceAPIURL)
+	paymentService.CardFingerprintKey = []byte(cfg.CardFingerprintKey)
+
+	healthService := services.NewHealthService(healthChecks(db), dbStats(db), middlewares.RedisClient)
+
+	paymentController := controllers.NewPaymentController(paymentService)
+	healthController := controllers.NewHealthController(healthService)
+
+	router := gin.New()
+	router.Use(otelgin.Middleware(utils.ServiceName))
+	router.Use(middlewares.RequestIDMiddleware())
+	router.Use(middlewares.RequestLoggerMiddleware())
+	router.Use(middlewares.MetricsMiddleware())
+	router.Use(middlewares.CORSMiddleware())
+	router.Use(gin.Recovery())
+
+	// The probes are registered before the rate limiter, which needs Redis, so
+	// they keep answering when Redis is down.
+	router.GET("/healthz", healthController.Healthz)
+	router.GET("/readyz", healthController.Readyz)
+
+	// Only the proxies in TRUSTED_PROXIES may name the client in
+	// X-Forwarded-For; otherwise anyone could pick the IP they are rate
+	// limited under.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		utils.LogError("Invalid TRUSTED_PROXIES, trusting no proxies: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+	router.Use(rateLimit(cfg, "default", cfg.RateLimitDefault))
+
+	v1 := router.Group("/api/v1")
+	{
+		payments := v1.Group("/payments")
+
+		payments.Use(middlewares.AuthMiddleware())
+		{
+			payments.POST("/", rateLimit(cfg, "payment", cfg.RateLimitPayment), middlewares.IdempotencyMiddleware(cfg.IdempotencyKeyTTL), paymentController.ProcessPayment)
+			payments.GET("/:id", paymentController.GetPaymentByID)
+
+		}
+
+		adminDiagnostics := v1.Group("/admin/diagnostics")
+		adminDiagnostics.Use(middlewares.AuthMiddleware())
+		adminDiagnostics.Use(middlewares.AdminAuthMiddleware())
+		{
+			adminDiagnostics.GET("", healthController.GetDiagnostics)
+		}
+	}
+
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	return &Server{Router: router, PaymentService: paymentService}
+}
+
+// healthChecks lists the dependencies /readyz checks.
+func healthChecks(db *gorm.DB) []services.HealthCheck {
+	return []services.HealthCheck{
+		{Name: "mysql", Check: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}},
+		{Name: "redis", Check: func(ctx context.Context) error {
+			if middlewares.RedisClient == nil {
+				return errors.New("redis client not initialized")
+			}
+			return middlewares.RedisClient.Ping(ctx).Err()
+		}},
+	}
+}
+
+func dbStats(db *gorm.DB) func() sql.DBStats {
+	return func() sql.DBStats {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return sql.DBStats{}
+		}
+		return sqlDB.Stats()
+	}
+}
+
+// rateLimit builds the middleware for one of the rate limits in cfg.
+func rateLimit(cfg *config.Config, name string, limit config.RateLimit) gin.HandlerFunc {
+	return middlewares.RateLimitMiddleware(middlewares.RateLimitPolicy{
+		Name:     name,
+		Requests: limit.Requests,
+		Per:      limit.Per,
+		FailOpen: cfg.RateLimitFailOpen,
+	})
+}
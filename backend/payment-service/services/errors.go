@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"backend/payment-service/models"
+)
+
+// Error kinds group domain errors by how a caller should react. Check them with
+// errors.Is, e.g. errors.Is(err, services.ErrNotFound).
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrForbidden    = errors.New("forbidden")
+	ErrInternal     = errors.New("internal error")
+)
+
+// DomainError is the structured error returned by the service layer. Code is the
+// stable code sent to clients and Message is safe to show them; Err is the
+// underlying cause and only ends up in logs.
+type DomainError struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *DomainError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *DomainError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Is matches any DomainError with the same code, so errors.Is(err, ErrBookingNotFound)
+// also holds for copies made with Withf or Wrap.
+func (e *DomainError) Is(target error) bool {
+	t, ok := target.(*DomainError)
+	return ok && t.Code == e.Code
+}
+
+// Withf returns a copy of the error with a more specific message.
+func (e *DomainError) Withf(format string, args ...any) *DomainError {
+	copied := *e
+	copied.Message = fmt.Sprintf(format, args...)
+	return &copied
+}
+
+// Wrap returns a copy of the error that records its cause.
+func (e *DomainError) Wrap(err error) *DomainError {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+func newInternalError(message string, err error) *DomainError {
+	return &DomainError{Kind: ErrInternal, Code: models.CodeInternalError, Message: message, Err: err}
+}
+
+var (
+	ErrPaymentNotFound       = &DomainError{Kind: ErrNotFound, Code: models.CodePaymentNotFound, Message: "payment not found"}
+	ErrPurchaseLimitExceeded = &DomainError{Kind: ErrConflict, Code: models.CodePurchaseLimitExceeded, Message: "the purchase limit of this concert has been reached for this card"}
+	ErrBookingNotPayable     = &DomainError{Kind: ErrConflict, Code: models.CodeBookingNotPayable, Message: "the booking cannot be paid"}
+)
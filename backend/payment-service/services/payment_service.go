@@ -26,6 +26,9 @@ const (
 type PaymentService struct {
 	PaymentRepo          *repositories.PaymentRepository
 	BookingServiceAPIURL string
+	// CardFingerprintKey keys the card fingerprints sent to the booking
+	// service for its per-card purchase limits.
+	CardFingerprintKey []byte
 }
 
 func NewPaymentService(pRepo *repositories.PaymentRepository, bookingServiceAPIURL string) *PaymentService {
@@ -36,6 +39,14 @@ func NewPaymentService(pRepo *repositories.PaymentRepository, bookingServiceAPIU
 }
 
 func (s *PaymentService) ProcessPayment(ctx context.Context, req *models.ProcessPaymentRequest) (*models.PaymentResponse, error) {
+	ctx = utils.WithBookingID(ctx, req.BookingID)
+
+	if req.CardNumber != "" {
+		fingerprint := utils.CardFingerprint(s.CardFingerprintKey, req.CardNumber)
+		if err := s.ClaimCardWithBookingService(ctx, req.BookingID, fingerprint); err != nil {
+			return nil, err
+		}
+	}
 
 	payment := &models.Payment{
 		BookingID:     req.BookingID,
@@ -44,10 +55,11 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, req *models.Process
 		Status:        PaymentStatusPending,
 	}
 
-	if err := s.PaymentRepo.CreatePayment(payment); err != nil {
-		utils.LogError("Failed to create pending payment record for booking %s: %v", req.BookingID, err)
-		return nil, errors.New("failed to initiate payment: database error")
+	if err := s.PaymentRepo.CreatePayment(ctx, payment); err != nil {
+		utils.LogErrorContext(ctx, "Failed to create pending payment record for booking %s: %v", req.BookingID, err)
+		return nil, newInternalError("failed to initiate payment: database error", err)
 	}
+	ctx = utils.WithPaymentID(ctx, payment.ID)
 
 	gatewayReq := utils.SimulatePaymentGatewayRequest{
 		Amount:        req.Amount,
@@ -65,22 +77,23 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, req *models.Process
 	if gatewayResp.Status == "success" {
 		payment.Status = PaymentStatusCompleted
 		bookingNewStatus = "confirmed"
-		utils.LogInfo("Payment for booking %s completed successfully. TxID: %s", req.BookingID, payment.TransactionID)
+		utils.LogInfoContext(ctx, "Payment for booking %s completed successfully. TxID: %s", req.BookingID, payment.TransactionID)
 	} else {
 		payment.Status = PaymentStatusFailed
 		bookingNewStatus = "failed"
-		utils.LogWarning("Payment for booking %s failed. Reason: %s. TxID: %s", req.BookingID, gatewayResp.Message, payment.TransactionID)
+		utils.LogWarningContext(ctx, "Payment for booking %s failed. Reason: %s. TxID: %s", req.BookingID, gatewayResp.Message, payment.TransactionID)
 	}
+	utils.RecordPayment(payment.PaymentMethod, payment.Status)
 
-	if err := s.PaymentRepo.UpdatePayment(payment); err != nil {
-		utils.LogError("Failed to update payment record %d after gateway response: %v", payment.ID, err)
+	if err := s.PaymentRepo.UpdatePayment(ctx, payment); err != nil {
+		utils.LogErrorContext(ctx, "Failed to update payment record %d after gateway response: %v", payment.ID, err)
 
-		return nil, errors.New("payment processed but failed to update record")
+		return nil, newInternalError("payment processed but failed to update record", err)
 	}
 
 	err := s.SendBookingStatusUpdateToBookingService(ctx, payment.BookingID, bookingNewStatus, payment.ID)
 	if err != nil {
-		utils.LogError("Failed to notify booking service for booking %s status update to %s: %v", payment.BookingID, bookingNewStatus, err)
+		utils.LogErrorContext(ctx, "Failed to notify booking service for booking %s status update to %s: %v", payment.BookingID, bookingNewStatus, err)
 
 	}
 
@@ -105,8 +118,11 @@ func (s *PaymentService) SendBookingStatusUpdateToBookingService(ctx context.Con
 		return fmt.Errorf("failed to create HTTP request to booking service: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if err := utils.SignInternalRequest(req, jsonBody); err != nil {
+		return fmt.Errorf("failed to sign request to booking service: %w", err)
+	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := utils.TracedHTTPClient(10 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request to booking service: %w", err)
@@ -114,29 +130,77 @@ func (s *PaymentService) SendBookingStatusUpdateToBookingService(ctx context.Con
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errorBody map[string]string
+		var problem models.ProblemDetails
 
-		if err := json.NewDecoder(resp.Body).Decode(&errorBody); err != nil {
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
 			return fmt.Errorf("booking service returned non-200 status: %d, no readable error body", resp.StatusCode)
 		}
-		return fmt.Errorf("booking service returned non-200 status: %d, error: %s", resp.StatusCode, errorBody["error"])
+		return fmt.Errorf("booking service returned non-200 status: %d, error: %s (%s)", resp.StatusCode, problem.Detail, problem.Code)
 	}
 
-	utils.LogInfo("Successfully notified booking service for booking %s status update to %s", bookingID, status)
+	utils.LogInfoContext(ctx, "Successfully notified booking service for booking %s status update to %s", bookingID, status)
 	return nil
 }
 
+// ClaimCardWithBookingService has the booking service count the booking
+// against the card's purchase limit before the card is charged. A card over
+// the limit, or a booking that can no longer be paid, is refused with a
+// conflict; if the booking service cannot be asked, the payment is not made.
+func (s *PaymentService) ClaimCardWithBookingService(ctx context.Context, bookingID, cardFingerprint string) error {
+	url := fmt.Sprintf("%s/internal/bookings/%s/payment-card", s.BookingServiceAPIURL, bookingID)
+
+	jsonBody, err := json.Marshal(models.ClaimPaymentCardInternalRequest{CardFingerprint: cardFingerprint})
+	if err != nil {
+		return newInternalError("failed to check the card against purchase limits", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return newInternalError("failed to check the card against purchase limits", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := utils.SignInternalRequest(req, jsonBody); err != nil {
+		return newInternalError("failed to check the card against purchase limits", err)
+	}
+
+	client := utils.TracedHTTPClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		utils.LogErrorContext(ctx, "Failed to claim the payment card of booking %s with booking service: %v", bookingID, err)
+		return newInternalError("failed to check the card against purchase limits", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var problem models.ProblemDetails
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		utils.LogErrorContext(ctx, "Booking service refused the payment card of booking %s with status %d, no readable error body", bookingID, resp.StatusCode)
+		return newInternalError("failed to check the card against purchase limits", fmt.Errorf("booking service returned status %d", resp.StatusCode))
+	}
+	utils.LogWarningContext(ctx, "Booking service refused the payment card of booking %s: %d, error: %s (%s)", bookingID, resp.StatusCode, problem.Detail, problem.Code)
+	switch {
+	case problem.Code == models.CodePurchaseLimitExceeded:
+		return ErrPurchaseLimitExceeded.Withf("%s", problem.Detail)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return ErrBookingNotPayable.Withf("%s", problem.Detail)
+	}
+	return newInternalError("failed to check the card against purchase limits", fmt.Errorf("booking service returned status %d: %s", resp.StatusCode, problem.Detail))
+}
+
 func (s *PaymentService) GetPaymentDetails(ctx context.Context, paymentID uint, userID uint) (*models.PaymentResponse, error) {
-	payment, err := s.PaymentRepo.GetPaymentByID(paymentID)
+	ctx = utils.WithPaymentID(ctx, paymentID)
+	payment, err := s.PaymentRepo.GetPaymentByID(ctx, paymentID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("payment not found")
+			return nil, ErrPaymentNotFound
 		}
-		utils.LogError("DB error getting payment %d: %v", paymentID, err)
-		return nil, errors.New("failed to retrieve payment details")
+		utils.LogErrorContext(ctx, "DB error getting payment %d: %v", paymentID, err)
+		return nil, newInternalError("failed to retrieve payment details", err)
 	}
 
-	utils.LogWarning("WARNING: User authorization for payment details (Payment ID: %d, User ID: %d) is NOT implemented via booking service. This is a security risk in production.", paymentID, userID)
+	utils.LogWarningContext(ctx, "WARNING: User authorization for payment details (Payment ID: %d, User ID: %d) is NOT implemented via booking service. This is a security risk in production.", paymentID, userID)
 
 	resp := payment.ToPaymentResponse()
 	return &resp, nil
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// CheckCardFingerprintKey makes sure CARD_FINGERPRINT_KEY is set, to at least
+// 32 bytes.
+func CheckCardFingerprintKey(key string) error {
+	if key == "" {
+		return errors.New("card fingerprint key is not configured: set CARD_FINGERPRINT_KEY")
+	}
+	if len(key) < 32 {
+		return errors.New("card fingerprint key must be at least 32 bytes")
+	}
+	return nil
+}
+
+// CardFingerprint identifies a card number without revealing it: an
+// HMAC-SHA256 of its digits under key. The booking service limits tickets per
+// fingerprint, so the key must stay the same for the limits to hold.
+func CardFingerprint(key []byte, cardNumber string) string {
+	digits := make([]byte, 0, len(cardNumber))
+	for i := 0; i < len(cardNumber); i++ {
+		if c := cardNumber[i]; c >= '0' && c <= '9' {
+			digits = append(digits, c)
+		}
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(digits)
+	return hex.EncodeToString(mac.Sum(nil))
+}
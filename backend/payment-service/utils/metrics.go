@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metricsNamespace prefixes every metric of the service, so the three services
+// can share one registry (as they do in the end-to-end tests).
+const metricsNamespace = "payment"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	PaymentsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "payments_total",
+		Help:      "Payments processed by the gateway, by payment method and result.",
+	}, []string{"method", "status"})
+)
+
+// knownPaymentMethods bounds the method label; clients can send any string.
+var knownPaymentMethods = map[string]bool{
+	"credit_card":   true,
+	"bank_transfer": true,
+	"e_wallet":      true,
+}
+
+// RecordPayment counts a payment that finished with status.
+func RecordPayment(method, status string) {
+	if !knownPaymentMethods[method] {
+		method = "other"
+	}
+	PaymentsTotal.WithLabelValues(method, status).Inc()
+}
+
+// RegisterDBStats exports the connection pool statistics of db.
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, dbName))
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"backend/user-service/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is the duration from which queries are logged as warnings.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// queryLogger sends GORM's logs to the service logger, with the request ID and
+// fields of the query's context. Every query is logged at debug level, slow
+// queries as warnings and failed ones as errors.
+type queryLogger struct{}
+
+func (l queryLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (queryLogger) Info(ctx context.Context, format string, args ...interface{}) {
+	utils.LogInfoContext(ctx, format, args...)
+}
+
+func (queryLogger) Warn(ctx context.Context, format string, args ...interface{}) {
+	utils.LogWarningContext(ctx, format, args...)
+}
+
+func (queryLogger) Error(ctx context.Context, format string, args ...interface{}) {
+	utils.LogErrorContext(ctx, format, args...)
+}
+
+func (queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	msg := "SQL query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "SQL query failed"
+	case elapsed >= slowQueryThreshold:
+		level, msg = slog.LevelWarn, "Slow SQL query"
+	}
+	if !utils.Logger().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("elapsed", elapsed)}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	utils.Logger().LogAttrs(ctx, level, msg, attrs...)
+}
@@ -10,4 +10,4 @@ CREATE TABLE IF NOT EXISTS `users` (
     `last_login` datetime(3) DEFAULT NULL,
     PRIMARY KEY (`id`),
     KEY `idx_users_deleted_at` (`deleted_at`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,3 @@
+-- An admin account for local development.
+INSERT IGNORE INTO `users` (`id`, `username`, `email`, `password`, `role`, `created_at`, `updated_at`) VALUES
+(1, 'admin', 'admin@example.com', '$2a$10$WpP6Z3E7X2aR7gM1Y5z3D.u8.z5.a6.o9.l0.k7.i5.t4.j2', 'admin', NOW(), NOW());
@@ -1,22 +1,20 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"backend/user-service/config"
-	"backend/user-service/controllers"
 	"backend/user-service/database"
 	"backend/user-service/middlewares"
-	"backend/user-service/repositories"
-	"backend/user-service/services"
+	"backend/user-service/server"
 	"backend/user-service/utils"
 
-	"github.com/gin-gonic/gin"
-
 	_ "backend/user-service/docs"
-
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // @title Concert Ticket Booking System - User Service API
@@ -40,44 +38,49 @@ import (
 func main() {
 
 	cfg := config.LoadConfig()
+	if err := utils.InitLogger(os.Stdout, cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
+	}
 	utils.InitJWT(cfg)
 
-	database.ConnectDB(cfg)
-
-	middlewares.InitRedis(cfg)
-
-	userRepo := repositories.NewUserRepository(database.DB)
-	userService := services.NewUserService(userRepo)
-	userController := controllers.NewUserController(userService)
-
-	router := gin.Default()
-
-	router.Use(middlewares.CORSMiddleware())
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-
-	router.Use(middlewares.RateLimitMiddleware(100, 1*time.Minute))
-
-	v1 := router.Group("/api/v1/users")
-	{
-
-		v1.POST("/register", userController.Register)
-		v1.POST("/login", userController.Login)
-		v1.POST("/logout", userController.Logout)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
 
-		authenticated := v1.Group("/")
-		authenticated.Use(middlewares.AuthMiddleware())
-		{
-			authenticated.GET("/profile", userController.GetProfile)
-			authenticated.PUT("/profile", userController.UpdateProfile)
+	shutdownTracing, err := utils.InitTracing(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			utils.LogWarning("Failed to flush traces: %v", err)
+		}
+	}()
 
+	database.ConnectDB(cfg)
+	defer database.CloseDB()
+	if cfg.MigrateOnStart {
+		if err := database.Migrate(context.Background(), database.DB); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
 		}
 	}
 
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	middlewares.InitRedis(cfg)
+	defer middlewares.CloseRedis()
+
+	srv := server.New(cfg, database.DB)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	utils.LogInfo("User Service running on port %s", cfg.ServicePort)
-	if err := router.Run(":" + cfg.ServicePort); err != nil {
-		utils.LogError("Failed to start user service: %v", err)
+	if err := srv.ListenAndServe(ctx, ":"+cfg.ServicePort, cfg.ShutdownTimeout); err != nil {
+		utils.LogError("User Service HTTP server failed: %v", err)
 	}
+	utils.LogInfo("User Service stopped.")
 }
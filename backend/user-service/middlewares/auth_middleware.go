@@ -3,6 +3,7 @@ package middlewares
 import (
 	"net/http"
 
+	"backend/user-service/models"
 	"backend/user-service/utils"
 
 	"github.com/gin-gonic/gin"
@@ -13,45 +14,39 @@ func AuthMiddleware() gin.HandlerFunc {
 		tokenString, err := c.Cookie("token")
 		if err != nil {
 			if err == http.ErrNoCookie {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: No token cookie found"})
-				c.Abort()
+				utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Unauthorized: No token cookie found")
 				return
 			}
-			utils.LogError("Error getting token cookie: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error reading token"})
-			c.Abort()
+			utils.LogErrorContext(c.Request.Context(), "Error getting token cookie: %v", err)
+			utils.AbortWithProblem(c, http.StatusInternalServerError, models.CodeInternalError, "Internal server error reading token")
 			return
 		}
 
 		claims, err := utils.ParseJWT(tokenString)
 		if err != nil {
-			utils.LogError("JWT parsing error from cookie: %v", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			utils.LogWarningContext(c.Request.Context(), "JWT parsing error from cookie: %v", err)
+			utils.AbortWithProblem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Invalid or expired token")
 			return
 		}
 
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Request = c.Request.WithContext(utils.WithUserID(c.Request.Context(), claims.UserID))
 
 		c.Next()
 	}
 }
 
+// AdminAuthMiddleware rejects users without the admin role. It must run after
+// AuthMiddleware, which sets the role; it does not authenticate by itself, as
+// calling AuthMiddleware here would run the handler before the role check.
 func AdminAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		AuthMiddleware()(c)
-
-		if c.IsAborted() {
-			return
-		}
-
 		role, exists := c.Get("role")
 		if !exists || role.(string) != "admin" {
-			utils.LogWarning("Unauthorized access attempt: User %s (ID: %d) tried to access admin route", c.GetString("username"), c.GetUint("userID"))
-			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Requires admin role"})
-			c.Abort()
+			utils.LogWarningContext(c.Request.Context(), "Unauthorized access attempt: User %s (ID: %d) tried to access admin route", c.GetString("username"), c.GetUint("userID"))
+			utils.AbortWithProblem(c, http.StatusForbidden, models.CodeForbidden, "Forbidden: Requires admin role")
 			return
 		}
 		c.Next()
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusDown = "down"
+)
+
+// DependencyCheck is the result of checking one dependency for readiness.
+type DependencyCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ReadinessReport struct {
+	Status string            `json:"status"`
+	Checks []DependencyCheck `json:"checks"`
+}
+
+type BuildInfo struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit,omitempty"`
+	GoVersion string    `json:"go_version"`
+	StartedAt time.Time `json:"started_at"`
+	Uptime    string    `json:"uptime"`
+}
+
+type DBPoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+}
+
+type RedisPoolStats struct {
+	TotalConnections uint32 `json:"total_connections"`
+	IdleConnections  uint32 `json:"idle_connections"`
+	StaleConnections uint32 `json:"stale_connections"`
+	Hits             uint32 `json:"hits"`
+	Misses           uint32 `json:"misses"`
+	Timeouts         uint32 `json:"timeouts"`
+}
+
+// Diagnostics is the detailed state of one instance for admins.
+type Diagnostics struct {
+	Readiness ReadinessReport `json:"readiness"`
+	Build     BuildInfo       `json:"build"`
+	Database  DBPoolStats     `json:"database"`
+	Redis     *RedisPoolStats `json:"redis,omitempty"`
+}
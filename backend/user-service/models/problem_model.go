@@ -0,0 +1,33 @@
+package models
+
+// ProblemDetails is the RFC 7807 (application/problem+json) body of every error
+// response. Code is stable and meant for clients to branch on or localize; Error
+// repeats Detail for clients that still read the old {"error": ...} body.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Error codes are part of the API contract. Add new codes instead of changing
+// existing ones.
+const (
+	CodeInternalError      = "INTERNAL_ERROR"
+	CodeInvalidRequestBody = "INVALID_REQUEST_BODY"
+	CodeValidationFailed   = "VALIDATION_FAILED"
+	CodeInvalidParameter   = "INVALID_PARAMETER"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeRateLimiterDown    = "RATE_LIMITER_UNAVAILABLE"
+
+	CodeUsernameTaken       = "USERNAME_TAKEN"
+	CodeEmailTaken          = "EMAIL_TAKEN"
+	CodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	CodeUserNotFound        = "USER_NOT_FOUND"
+	CodeProfileAccessDenied = "PROFILE_ACCESS_DENIED"
+)
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+
 	"backend/user-service/models"
 
 	"gorm.io/gorm"
@@ -14,37 +16,37 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{DB: db}
 }
 
-func (r *UserRepository) CreateUser(user *models.User) error {
-	return r.DB.Create(user).Error
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	return r.DB.WithContext(ctx).Create(user).Error
 }
 
-func (r *UserRepository) FindUserByUsername(username string) (*models.User, error) {
+func (r *UserRepository) FindUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.DB.Where("username = ?", username).First(&user).Error
+	err := r.DB.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindUserByEmail(email string) (*models.User, error) {
+func (r *UserRepository) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.DB.Where("email = ?", email).First(&user).Error
+	err := r.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindUserByID(id uint) (*models.User, error) {
+func (r *UserRepository) FindUserByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.DB.First(&user, id).Error
+	err := r.DB.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) UpdateUser(user *models.User) error {
-	return r.DB.Save(user).Error
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	return r.DB.WithContext(ctx).Save(user).Error
 }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -19,28 +20,28 @@ func NewUserService(userRepo *repositories.UserRepository) *UserService {
 	return &UserService{UserRepo: userRepo}
 }
 
-func (s *UserService) RegisterUser(req *models.UserRegisterRequest) (*models.UserResponse, error) {
+func (s *UserService) RegisterUser(ctx context.Context, req *models.UserRegisterRequest) (*models.UserResponse, error) {
 
-	_, errUsername := s.UserRepo.FindUserByUsername(req.Username)
+	_, errUsername := s.UserRepo.FindUserByUsername(ctx, req.Username)
 	if errUsername == nil {
-		return nil, errors.New("username already exists")
+		return nil, ErrUsernameTaken
 	}
 	if !errors.Is(errUsername, gorm.ErrRecordNotFound) {
-		return nil, errUsername
+		return nil, newInternalError("failed to register user", errUsername)
 	}
 
-	_, errEmail := s.UserRepo.FindUserByEmail(req.Email)
+	_, errEmail := s.UserRepo.FindUserByEmail(ctx, req.Email)
 	if errEmail == nil {
-		return nil, errors.New("email already exists")
+		return nil, ErrEmailTaken
 	}
 	if !errors.Is(errEmail, gorm.ErrRecordNotFound) {
-		return nil, errEmail
+		return nil, newInternalError("failed to register user", errEmail)
 	}
 
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		utils.LogError("Failed to hash password during registration: %v", err)
-		return nil, errors.New("failed to process password")
+		utils.LogErrorContext(ctx, "Failed to hash password during registration: %v", err)
+		return nil, newInternalError("failed to process password", err)
 	}
 
 	user := &models.User{
@@ -50,89 +51,90 @@ func (s *UserService) RegisterUser(req *models.UserRegisterRequest) (*models.Use
 		Role:     "user",
 	}
 
-	if err := s.UserRepo.CreateUser(user); err != nil {
-		utils.LogError("Failed to create user in database: %v", err)
-		return nil, errors.New("failed to register user")
+	if err := s.UserRepo.CreateUser(ctx, user); err != nil {
+		utils.LogErrorContext(ctx, "Failed to create user in database: %v", err)
+		return nil, newInternalError("failed to register user", err)
 	}
 
 	response := user.ToUserResponse()
 	return &response, nil
 }
 
-func (s *UserService) LoginUser(req *models.UserLoginRequest) (string, *models.UserResponse, error) {
-	user, err := s.UserRepo.FindUserByUsername(req.Username)
+func (s *UserService) LoginUser(ctx context.Context, req *models.UserLoginRequest) (string, *models.UserResponse, error) {
+	user, err := s.UserRepo.FindUserByUsername(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", nil, errors.New("invalid credentials")
+			return "", nil, ErrInvalidCredentials
 		}
-		utils.LogError("Database error finding user '%s' during login: %v", req.Username, err)
-		return "", nil, errors.New("internal server error during login")
+		utils.LogErrorContext(ctx, "Database error finding user '%s' during login: %v", req.Username, err)
+		return "", nil, newInternalError("internal server error during login", err)
 	}
 
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		return "", nil, errors.New("invalid credentials")
+		return "", nil, ErrInvalidCredentials
 	}
+	ctx = utils.WithUserID(ctx, user.ID)
 
 	now := time.Now()
 	user.LastLogin = &now
-	if err := s.UserRepo.UpdateUser(user); err != nil {
+	if err := s.UserRepo.UpdateUser(ctx, user); err != nil {
 
-		utils.LogWarning("Failed to update last login for user %s (ID: %d): %v", user.Username, user.ID, err)
+		utils.LogWarningContext(ctx, "Failed to update last login for user %s (ID: %d): %v", user.Username, user.ID, err)
 	}
 
 	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role)
 	if err != nil {
-		utils.LogError("Failed to generate JWT for user %s (ID: %d): %v", user.Username, user.ID, err)
-		return "", nil, errors.New("failed to authenticate user")
+		utils.LogErrorContext(ctx, "Failed to generate JWT for user %s (ID: %d): %v", user.Username, user.ID, err)
+		return "", nil, newInternalError("failed to authenticate user", err)
 	}
 
 	response := user.ToUserResponse()
 	return token, &response, nil
 }
 
-func (s *UserService) GetUserProfile(userID uint) (*models.UserResponse, error) {
-	user, err := s.UserRepo.FindUserByID(userID)
+func (s *UserService) GetUserProfile(ctx context.Context, userID uint) (*models.UserResponse, error) {
+	user, err := s.UserRepo.FindUserByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
-		utils.LogError("Database error fetching user profile for ID %d: %v", userID, err)
-		return nil, errors.New("internal server error fetching profile")
+		utils.LogErrorContext(ctx, "Database error fetching user profile for ID %d: %v", userID, err)
+		return nil, newInternalError("internal server error fetching profile", err)
 	}
 	response := user.ToUserResponse()
 	return &response, nil
 }
 
-func (s *UserService) UpdateUserProfile(userID uint, updatedData *models.UserResponse) (*models.UserResponse, error) {
-	user, err := s.UserRepo.FindUserByID(userID)
+func (s *UserService) UpdateUserProfile(ctx context.Context, userID uint, updatedData *models.UserResponse) (*models.UserResponse, error) {
+	user, err := s.UserRepo.FindUserByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
-		utils.LogError("Database error finding user for update ID %d: %v", userID, err)
-		return nil, errors.New("internal server error updating profile")
+		utils.LogErrorContext(ctx, "Database error finding user for update ID %d: %v", userID, err)
+		return nil, newInternalError("internal server error updating profile", err)
 	}
 
 	if updatedData.ID != userID {
-		utils.LogWarning("Unauthorized attempt to update profile with mismatched user ID. Auth ID: %d, Request ID: %d", userID, updatedData.ID)
-		return nil, errors.New("unauthorized: cannot update another user's profile")
+		utils.LogWarningContext(ctx, "Unauthorized attempt to update profile with mismatched user ID. Auth ID: %d, Request ID: %d", userID, updatedData.ID)
+		return nil, ErrProfileAccessDenied
 	}
 
 	if updatedData.Email != "" && updatedData.Email != user.Email {
 
-		existingUserWithEmail, errEmail := s.UserRepo.FindUserByEmail(updatedData.Email)
+		existingUserWithEmail, errEmail := s.UserRepo.FindUserByEmail(ctx, updatedData.Email)
 		if errEmail == nil && existingUserWithEmail.ID != user.ID {
-			return nil, errors.New("email already taken by another user")
+			return nil, ErrEmailTaken.Withf("email already taken by another user")
 		}
 		if !errors.Is(errEmail, gorm.ErrRecordNotFound) {
-			return nil, errEmail
+			return nil, newInternalError("internal server error updating profile", errEmail)
 		}
 		user.Email = updatedData.Email
 	}
 
-	if err := s.UserRepo.UpdateUser(user); err != nil {
-		utils.LogError("Failed to update user profile for ID %d: %v", userID, err)
-		return nil, errors.New("failed to update profile")
+	if err := s.UserRepo.UpdateUser(ctx, user); err != nil {
+		utils.LogErrorContext(ctx, "Failed to update user profile for ID %d: %v", userID, err)
+		return nil, newInternalError("failed to update profile", err)
 	}
 
 	response := user.ToUserResponse()
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+
+	"backend/user-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// NewProblem builds a problem document. The type URI is derived from the code,
+// e.g. USERNAME_TAKEN becomes /problems/username-taken.
+func NewProblem(status int, code, detail, instance string) models.ProblemDetails {
+	return models.ProblemDetails{
+		Type:     "/problems/" + strings.ToLower(strings.ReplaceAll(code, "_", "-")),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: instance,
+		Code:     code,
+		Error:    detail,
+	}
+}
+
+// AbortWithProblem writes an application/problem+json response and stops the
+// handler chain.
+func AbortWithProblem(c *gin.Context, status int, code, detail string) {
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(status, NewProblem(status, code, detail, c.Request.URL.Path))
+}